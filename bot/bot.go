@@ -0,0 +1,184 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/Lavizord/checkers-server/models"
+)
+
+// The house bot engine, a small minimax over the move generators of the boards. It does not need to play
+// well, only to play legal moves and not give pieces away.
+
+const (
+	damasDepth = 3 // Plies, a capture sequence counts as a single ply.
+	chessDepth = 2
+	winScore   = 10000
+)
+
+// ChooseMove picks the move of the bot, playerID, on the board. The bot plays one step at a time, when a
+// damas capture sequence goes on it's called again for the next jump.
+func ChooseMove(board models.Board, playerID string) (models.MoveInterface, error) {
+	var moves []models.MoveInterface
+	var score func(models.MoveInterface) int
+	switch b := board.(type) {
+	case *models.DamasBoard:
+		opponentID := opponentOf(b.GetPieces(), playerID)
+		moves = b.PlayerMoves(playerID)
+		score = func(m models.MoveInterface) int {
+			return damasAfterMove(b, m, playerID, opponentID, playerID, damasDepth)
+		}
+	case *models.ChessBoard:
+		opponentID := opponentOf(b.GetPieces(), playerID)
+		moves = b.PlayerMoves(playerID)
+		score = func(m models.MoveInterface) int {
+			sim := b.Clone()
+			sim.ApplyMove(m)
+			return chessSearch(sim, opponentID, playerID, opponentID, chessDepth-1)
+		}
+	default:
+		return nil, fmt.Errorf("(ChooseMove) - unsupported board: %T", board)
+	}
+	if len(moves) == 0 {
+		return nil, fmt.Errorf("(ChooseMove) - player with id: %v has no moves", playerID)
+	}
+
+	// Among the moves with the best score one is picked at random, so the bot does not always play the same game.
+	var best []models.MoveInterface
+	bestScore := 0
+	for _, m := range moves {
+		s := score(m)
+		if len(best) == 0 || s > bestScore {
+			best, bestScore = []models.MoveInterface{m}, s
+		} else if s == bestScore {
+			best = append(best, m)
+		}
+	}
+	return best[rand.Intn(len(best))], nil
+}
+
+func opponentOf(pieces []models.PieceInterface, playerID string) string {
+	for _, p := range pieces {
+		if p != nil && p.GetPlayerID() != playerID {
+			return p.GetPlayerID()
+		}
+	}
+	return ""
+}
+
+// damasMaterial scores the board for me, a man is worth 1 and a king 3.
+func damasMaterial(b *models.DamasBoard, me string) int {
+	score := 0
+	for _, p := range b.GetPieces() {
+		if p == nil {
+			continue
+		}
+		value := 1
+		if p.IsPieceKinged() {
+			value = 3
+		}
+		if p.GetPlayerID() == me {
+			score += value
+		} else {
+			score -= value
+		}
+	}
+	return score
+}
+
+// damasAfterMove plays the move of mover on a copy of the board and scores it, the mover keeps playing
+// while the same piece must keep capturing, like on the damas worker.
+func damasAfterMove(b *models.DamasBoard, move models.MoveInterface, me, opponent, mover string, depth int) int {
+	sim := b.Clone()
+	piece, _ := sim.GetPiece(move.GetFrom())
+	wasKing := piece != nil && piece.IsPieceKinged()
+	if err := sim.ApplyMove(move); err != nil {
+		return damasMaterial(b, me)
+	}
+	kinged := !wasKing && piece != nil && piece.IsPieceKinged()
+	if move.IsCaptureMove() && sim.CanPieceCaptureNEW(move.GetTo()) && (!kinged || sim.Rules().KingedCaptureGoesOn) {
+		sim.CaptureLock = move.GetTo()
+		return damasSearch(sim, me, opponent, mover, depth)
+	}
+	sim.CaptureLock = ""
+	next := opponent
+	if mover == opponent {
+		next = me
+	}
+	return damasSearch(sim, me, opponent, next, depth-1)
+}
+
+func damasSearch(b *models.DamasBoard, me, opponent, mover string, depth int) int {
+	moves := b.PlayerMoves(mover)
+	if len(moves) == 0 {
+		// Without moves the game is lost.
+		if mover == me {
+			return -winScore
+		}
+		return winScore
+	}
+	if depth <= 0 {
+		return damasMaterial(b, me)
+	}
+	best := 0
+	for i, m := range moves {
+		s := damasAfterMove(b, m, me, opponent, mover, depth)
+		if i == 0 || (mover == me && s > best) || (mover != me && s < best) {
+			best = s
+		}
+	}
+	return best
+}
+
+var chessValues = map[string]int{"pawn": 1, "knight": 3, "bishop": 3, "rook": 5, "queen": 9}
+
+func chessMaterial(b *models.ChessBoard, me string) int {
+	score := 0
+	for _, p := range b.GetPieces() {
+		if p == nil {
+			continue
+		}
+		if p.GetPlayerID() == me {
+			score += chessValues[p.GetType()]
+		} else {
+			score -= chessValues[p.GetType()]
+		}
+	}
+	return score
+}
+
+// chessSearch scores the board for me with mover to play. On the last ply only the side in check looks
+// for moves, to see the mates without generating every move.
+func chessSearch(b *models.ChessBoard, mover, me, opponent string, depth int) int {
+	if depth <= 0 && !b.IsInCheck(b.SideToMove) {
+		return chessMaterial(b, me)
+	}
+	moves := b.PlayerMoves(mover)
+	if len(moves) == 0 {
+		color := b.SideToMove
+		if !b.IsInCheck(color) {
+			return 0 // Stalemate.
+		}
+		if mover == me {
+			return -winScore
+		}
+		return winScore
+	}
+	if depth <= 0 {
+		return chessMaterial(b, me)
+	}
+	next := opponent
+	if mover == opponent {
+		next = me
+	}
+	best := 0
+	for i, m := range moves {
+		sim := b.Clone()
+		sim.ApplyMove(m)
+		s := chessSearch(sim, next, me, opponent, depth-1)
+		if i == 0 || (mover == me && s > best) || (mover != me && s < best) {
+			best = s
+		}
+	}
+	return best
+}
@@ -42,6 +42,14 @@ func main() {
 		logger.Default.Fatalf("no GAME_ENGINE env variable defined, exiting")
 	}
 
+	// The games that were already running when the live games index came in are not on it.
+	indexed, err := redisClient.IndexLiveGames(gameEngine)
+	if err != nil {
+		logger.Default.Errorf("[BroadcastWorker] failed to index the live games: %v", err)
+	} else {
+		logger.Default.Infof("broadcastworker indexed %d live games...", indexed)
+	}
+
 	logger.Default.Infof("broadcastworker loaded timer configuration...")
 	for range ticker.C {
 		key := fmt.Sprintf("game_info:{%v}", gameEngine)
@@ -54,5 +62,17 @@ func main() {
 			//log.Printf("[BroadcastWorker] Published room aggregates")
 		}
 
+		featuredCount := config.Cfg.Services["broadcastworker"].FeaturedGames
+		if featuredCount > 0 {
+			msgFeatured, err := messages.GenerateFeaturedGamesMessageBytes(redisClient, gameEngine, featuredCount)
+			if err != nil {
+				log.Printf("[BroadcastWorker] Error generating featured games: %v\n", err)
+				continue
+			}
+			if err := redisClient.Publish(key, msgFeatured); err != nil {
+				log.Printf("[BroadcastWorker] Error publishing featured games: %v\n", err)
+			}
+		}
+
 	}
 }
@@ -15,11 +15,23 @@ import (
 	"services": {
 		"wsapi": { "ports": [8080, 8081, 8082] },
 		"pstatusworker": {},
-		"roomworker": {},
+		"broadcastworker": {
+			"timer": 5,
+			"featured_games": 10 				// Games on the featured_games feed, 0 disables the feed
+		},
+		"roomworker": {
+			"bot_wait": 30 						// Seconds a player waits alone before the house bot joins, 0 disables the bot
+		},
 		"gameworker": {
 			"timer": 15,
 			"timer_settings": "reset", 			// Options: "reset" or "cumulative"
-			"pieces_in_match": 10 				// Number of pieces in the match
+			"pieces_in_match": 10, 				// Number of pieces in the match
+			"draw_king_moves": 20, 				// Damas is drawn after this many king moves in a row without a capture
+			"move_shards": 16, 					// Lists the moves are split over by game id, serverws reads it too
+			"time_controls": { 					// Clock of the games by bet tier, overrides timer_settings, see models.ParseTimeControl
+				"default": "3+2",
+				"5": "5d3/30"
+			}
 		}
 	}
 	}
@@ -46,10 +58,15 @@ type Config struct {
 		Password string `json:"password"`
 	} `json:"email"`
 	Services map[string]struct {
-		Ports         []int  `json:"ports,omitempty"`
-		Timer         int    `json:"timer,omitempty"`
-		TimerSetting  string `json:"timer_setting,omitempty"`
-		PiecesInMatch int    `json:"pieces_in_match,omitempty"`
+		Ports         []int             `json:"ports,omitempty"`
+		Timer         int               `json:"timer,omitempty"`
+		TimerSetting  string            `json:"timer_setting,omitempty"`
+		PiecesInMatch int               `json:"pieces_in_match,omitempty"`
+		DrawKingMoves int               `json:"draw_king_moves,omitempty"`
+		BotWait       int               `json:"bot_wait,omitempty"`
+		TimeControls  map[string]string `json:"time_controls,omitempty"`
+		MoveShards    int               `json:"move_shards,omitempty"`
+		FeaturedGames int               `json:"featured_games,omitempty"`
 	} `json:"services"`
 }
 
@@ -13,7 +13,11 @@ BEGIN
             Active BOOLEAN DEFAULT TRUE, 
             GameBaseUrl VARCHAR(255),    
             OperatorWalletBaseUrl VARCHAR(255),
-            WinFactor DECIMAL(5,4)                      
+            WinFactor DECIMAL(5,4),
+            Variant VARCHAR(50) DEFAULT '',
+            BotEnabled BOOLEAN DEFAULT FALSE,
+            WalletConfig JSONB,
+            Signing JSONB
         );
 
         INSERT INTO operators (OperatorName, OperatorGameName, GameName, GameBaseUrl, OperatorWalletBaseUrl, WinFactor)
@@ -44,6 +48,13 @@ BEGIN
     END IF;
 END $$;
 
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS Variant VARCHAR(50) DEFAULT '';
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS BotEnabled BOOLEAN DEFAULT FALSE;
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS WalletConfig JSONB;
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS Signing JSONB;
+-- Bets offered per currency in major units, like {"EUR": ["0", "0.5", "1"]}. The default ones when NULL.
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS BetTiers JSONB;
+
 CREATE TABLE IF NOT EXISTS sessions (
     SessionId UUID PRIMARY KEY,  
     Token VARCHAR(255),         
@@ -66,17 +77,28 @@ CREATE TABLE IF NOT EXISTS games (
     EndDate TIMESTAMP,
     NumMoves INT,
     Moves JSONB DEFAULT '[]',       
-    BetAmount DECIMAL(10,2) CHECK (BetAmount >= 0),  
+    BetAmount DECIMAL(16,4) CHECK (BetAmount >= 0),  
     Winner UUID ,                     
     WinFactor DECIMAL(5,4), 
     GameOverReason VARCHAR(50),
-    GamePlayers JSONB DEFAULT '[]'  
+    GamePlayers JSONB DEFAULT '[]',
+    Variant VARCHAR(50) DEFAULT '',
+    BotGame BOOLEAN DEFAULT FALSE,
+    FreePlay BOOLEAN DEFAULT FALSE
 );
 
+ALTER TABLE games ADD COLUMN IF NOT EXISTS Variant VARCHAR(50) DEFAULT '';
+ALTER TABLE games ADD COLUMN IF NOT EXISTS BotGame BOOLEAN DEFAULT FALSE;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS FreePlay BOOLEAN DEFAULT FALSE;
+-- Bets in minor units with their currency, BetAmount keeps the decimal amount for the reports.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS BetMinor BIGINT;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS Currency VARCHAR(10);
+ALTER TABLE games ALTER COLUMN BetAmount TYPE DECIMAL(16,4);
+
 CREATE TABLE IF NOT EXISTS transactions (
     TransactionID UUID PRIMARY KEY,    
     SessionID UUID ,                   
-    Type VARCHAR(50)  CHECK (Type IN ('bet', 'win')), 
+    Type VARCHAR(50)  CHECK (Type IN ('bet', 'win', 'refund', 'rollback')), 
     Amount INTEGER  CHECK (Amount >= 0),  
     Currency VARCHAR(10) ,  
     Platform VARCHAR(100) , 
@@ -85,10 +107,44 @@ CREATE TABLE IF NOT EXISTS transactions (
     Game VARCHAR(100) ,         
     Status VARCHAR(100) ,               
     Description VARCHAR(600),           
-    RoundID UUID,                       
-    Timestamp TIMESTAMP  DEFAULT CURRENT_TIMESTAMP  
+    RoundID UUID,
+    Timestamp TIMESTAMP  DEFAULT CURRENT_TIMESTAMP,
+    BotGame BOOLEAN DEFAULT FALSE
 );
 
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS BotGame BOOLEAN DEFAULT FALSE;
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check CHECK (Type IN ('bet', 'win', 'refund', 'rollback'));
+
+CREATE TABLE IF NOT EXISTS wallet_ledger (
+    TransactionID UUID PRIMARY KEY,
+    SessionID UUID,
+    Type VARCHAR(50) CHECK (Type IN ('bet', 'win', 'refund', 'rollback')),
+    Amount INTEGER CHECK (Amount >= 0),
+    Currency VARCHAR(10),
+    Operator VARCHAR(100),
+    Game VARCHAR(100),
+    RoundID UUID,
+    Status VARCHAR(20) NOT NULL CHECK (Status IN ('pending', 'sent', 'confirmed', 'failed', 'unknown', 'rolled_back')),
+    Attempts INT DEFAULT 0,
+    NextAttemptAt TIMESTAMP,
+    LastError VARCHAR(600),
+    Payload JSONB,
+    Session JSONB,
+    Response JSONB,
+    CreatedAt TIMESTAMP DEFAULT NOW(),
+    UpdatedAt TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS wallet_ledger_retry_idx ON wallet_ledger (Status, NextAttemptAt);
+CREATE INDEX IF NOT EXISTS wallet_ledger_round_idx ON wallet_ledger (RoundID);
+
+ALTER TABLE wallet_ledger ADD COLUMN IF NOT EXISTS Response JSONB;
+ALTER TABLE wallet_ledger DROP CONSTRAINT IF EXISTS wallet_ledger_type_check;
+ALTER TABLE wallet_ledger ADD CONSTRAINT wallet_ledger_type_check CHECK (Type IN ('bet', 'win', 'refund', 'rollback'));
+ALTER TABLE wallet_ledger DROP CONSTRAINT IF EXISTS wallet_ledger_status_check;
+ALTER TABLE wallet_ledger ADD CONSTRAINT wallet_ledger_status_check CHECK (Status IN ('pending', 'sent', 'confirmed', 'failed', 'unknown', 'rolled_back'));
+
 CREATE TABLE IF NOT EXISTS users (
     Id UUID PRIMARY KEY,
     Email VARCHAR(255) UNIQUE NOT NULL,
@@ -15,7 +15,11 @@ BEGIN
             Active BOOLEAN DEFAULT TRUE,        -- Whether the operator is active or not, default to TRUE (1)
             GameBaseUrl VARCHAR(255),             -- gamelaunch base url
             OperatorWalletBaseUrl VARCHAR(255),
-            WinFactor DECIMAL(5,4)                      
+            WinFactor DECIMAL(5,4),
+            Variant VARCHAR(50) DEFAULT '',    -- Rule variant (classic, brazilian, international, american, russian), empty is classic.
+            BotEnabled BOOLEAN DEFAULT FALSE,  -- Whether a house bot joins when a player waits alone in the queue.
+            WalletConfig JSONB,                -- Generic wallet settings, null for the operators with their own module.
+            Signing JSONB                      -- HMAC signing of the wallet calls and callbacks, null when unsigned.
         );
 
         -- Insert a row into the table after creating it
@@ -39,6 +43,16 @@ BEGIN
     END IF;
 END $$;
 
+-- Operators created before the rule variants existed.
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS Variant VARCHAR(50) DEFAULT '';
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS BotEnabled BOOLEAN DEFAULT FALSE;
+-- Generic seamless wallet settings, operators without a module of their own need it. See models.WalletConfig.
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS WalletConfig JSONB;
+-- Signing of the wallet calls, see models.WalletSigning.
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS Signing JSONB;
+-- Bets offered per currency in major units, like {"EUR": ["0", "0.5", "1"]}. The default ones when NULL.
+ALTER TABLE operators ADD COLUMN IF NOT EXISTS BetTiers JSONB;
+
 CREATE TABLE IF NOT EXISTS sessions (
     SessionId UUID PRIMARY KEY,  -- Unique session ID
     Token VARCHAR(255),          -- Session token
@@ -64,17 +78,29 @@ CREATE TABLE IF NOT EXISTS games (
     EndDate TIMESTAMP,
     NumMoves INT,
     Moves JSONB DEFAULT '[]',       
-    BetAmount DECIMAL(10,2) CHECK (BetAmount >= 0),  
+    BetAmount DECIMAL(16,4) CHECK (BetAmount >= 0),  
     Winner UUID ,                     
     WinFactor DECIMAL(5,4), 
     GameOverReason VARCHAR(50),
-    GamePlayers JSONB DEFAULT '[]'  
+    GamePlayers JSONB DEFAULT '[]',
+    Variant VARCHAR(50) DEFAULT '',   -- Damas rule variant, needed to number the squares on the exports.
+    BotGame BOOLEAN DEFAULT FALSE,    -- One of the players was the house bot.
+    FreePlay BOOLEAN DEFAULT FALSE    -- Practice game from the free-play queue, nothing was bet.
 );
 
+-- Games saved before the exports.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS Variant VARCHAR(50) DEFAULT '';
+ALTER TABLE games ADD COLUMN IF NOT EXISTS BotGame BOOLEAN DEFAULT FALSE;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS FreePlay BOOLEAN DEFAULT FALSE;
+-- Bets in minor units with their currency, BetAmount keeps the decimal amount for the reports.
+ALTER TABLE games ADD COLUMN IF NOT EXISTS BetMinor BIGINT;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS Currency VARCHAR(10);
+ALTER TABLE games ALTER COLUMN BetAmount TYPE DECIMAL(16,4);
+
 CREATE TABLE IF NOT EXISTS transactions (
     TransactionID UUID PRIMARY KEY,    
     SessionID UUID ,                   
-    Type VARCHAR(50)  CHECK (Type IN ('bet', 'win')), 
+    Type VARCHAR(50)  CHECK (Type IN ('bet', 'win', 'refund', 'rollback')), 
     Amount INTEGER  CHECK (Amount >= 0),  
     Currency VARCHAR(10) ,  
     Platform VARCHAR(100) , -- Platform name
@@ -84,9 +110,48 @@ CREATE TABLE IF NOT EXISTS transactions (
     Status VARCHAR(100) ,               
     Description VARCHAR(600),           -- Description (e.g., "Insufficient Funds" or "OK")
     RoundID UUID,                       -- Foreign key to the round / game
-    Timestamp TIMESTAMP  DEFAULT CURRENT_TIMESTAMP  -- Timestamp in UTC
+    Timestamp TIMESTAMP  DEFAULT CURRENT_TIMESTAMP,  -- Timestamp in UTC
+    BotGame BOOLEAN DEFAULT FALSE       -- Transaction of a game against the house bot, accounted apart.
 );
 
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS BotGame BOOLEAN DEFAULT FALSE;
+-- Bets given back when the game never started.
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_type_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_type_check CHECK (Type IN ('bet', 'win', 'refund', 'rollback'));
+
+/*
+ Wallet ledger, every post to the operator wallet is saved here before it is sent.
+ The ledger worker sends the failed and stale ones again with the same TransactionID.
+*/
+CREATE TABLE IF NOT EXISTS wallet_ledger (
+    TransactionID UUID PRIMARY KEY,
+    SessionID UUID,
+    Type VARCHAR(50) CHECK (Type IN ('bet', 'win', 'refund', 'rollback')),
+    Amount INTEGER CHECK (Amount >= 0),
+    Currency VARCHAR(10),
+    Operator VARCHAR(100),
+    Game VARCHAR(100),
+    RoundID UUID,                       -- The game, same as on the transactions.
+    Status VARCHAR(20) NOT NULL CHECK (Status IN ('pending', 'sent', 'confirmed', 'failed', 'unknown', 'rolled_back')),
+    Attempts INT DEFAULT 0,
+    NextAttemptAt TIMESTAMP,            -- Null when it is not going to be sent again.
+    LastError VARCHAR(600),
+    Payload JSONB,                      -- Body posted to the operator.
+    Session JSONB,                      -- Session when it was posted, to send it again after it expires.
+    Response JSONB,                     -- Answer of the operator, the bet one is needed to roll it back.
+    CreatedAt TIMESTAMP DEFAULT NOW(),
+    UpdatedAt TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS wallet_ledger_retry_idx ON wallet_ledger (Status, NextAttemptAt);
+CREATE INDEX IF NOT EXISTS wallet_ledger_round_idx ON wallet_ledger (RoundID);
+
+ALTER TABLE wallet_ledger ADD COLUMN IF NOT EXISTS Response JSONB;
+ALTER TABLE wallet_ledger DROP CONSTRAINT IF EXISTS wallet_ledger_type_check;
+ALTER TABLE wallet_ledger ADD CONSTRAINT wallet_ledger_type_check CHECK (Type IN ('bet', 'win', 'refund', 'rollback'));
+ALTER TABLE wallet_ledger DROP CONSTRAINT IF EXISTS wallet_ledger_status_check;
+ALTER TABLE wallet_ledger ADD CONSTRAINT wallet_ledger_status_check CHECK (Status IN ('pending', 'sent', 'confirmed', 'failed', 'unknown', 'rolled_back'));
+
 CREATE TABLE IF NOT EXISTS users (
     Id UUID PRIMARY KEY,
     Email VARCHAR(255) UNIQUE NOT NULL,
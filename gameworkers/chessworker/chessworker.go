@@ -20,11 +20,17 @@ func New(redis *redisdb.RedisClient, db *postgrescli.PostgresCli) *ChessWorker {
 }
 
 func (cw *ChessWorker) Run() {
+	cw.RecoverClocks()
+	go cw.RunClockScheduler()
 	go cw.ProcessGameCreationList() // ChessWorker’s version
 	go cw.ProcessGameMovesList()    // ChessWorker’s version
 	go cw.ProcessLeaveGameList()
 	go cw.ProcessDisconnectFromGameList()
 	go cw.ProcessReconnectFromGameList()
+	go cw.ProcessDrawList()
+	go cw.ProcessResignList()
+	go cw.ProcessPremoveList()
+	go cw.ProcessTakebackList()
 }
 
 func (cw *ChessWorker) ProcessGameMovesList() {
@@ -32,26 +38,54 @@ func (cw *ChessWorker) ProcessGameMovesList() {
 }
 
 func (cw *ChessWorker) HandleMove(game *models.Game, move models.MoveInterface, player *models.Player, piece models.PieceInterface) error {
-	// Since the move was validated and passed to the other player, its time to check for our end turn / end game conditions.
-	// This means we can add the move to our game.
+	board, ok := game.Board.(*models.ChessBoard)
+	if !ok {
+		return fmt.Errorf("(Process Game Moves) - game with id: %v does not have a chess board", game.ID)
+	}
+	_, err := board.ValidateMove(move, piece)
+	if err != nil {
+		msg := fmt.Errorf("(Process Game Moves) - invalid move: %+v from game with id: %v, from player with id: %v, with err: %v", move, player.GameID, move.GetPlayerID(), err)
+		logger.Default.Errorf(msg.Error())
+		boardState, _ := messages.GenerateGameBoardState(*game)
+		msginv, _ := messages.NewMessage("invalid_move", boardState)
+		cw.RedisClient.PublishToPlayer(*player, string(msginv))
+		return gameworker.RejectMove(models.MoveRejectIllegal, msg)
+	}
+	// The move is valid, so we can add it to our game and apply it to the board.
 	game.Moves = append(game.Moves, move)
-	game.Board.MovePiece(move.GetFrom(), move.GetTo()) // This should handle the capture as well, since it overwrites the destination position.
+	if err := board.ApplyMove(move); err != nil {
+		return fmt.Errorf("(Process Game Moves) - failed to apply move: %+v, from game with id: %v, with err: %v", move, game.ID, err)
+	}
 	game.UpdatePlayerPieces()
+	// The move message goes to the opponent once the game is saved, so it is not sent twice when the
+	// move is done again after a version conflict.
 	msg, err := messages.GenerateMoveMessage(move)
 	if err != nil {
 		logger.Default.Errorf("(Process Game Moves) - failed to generate move message: %+v, from game with id: %v, from player with id: %v", move, player.GameID, move.GetPlayerID())
 	}
 	opponent, _ := game.GetOpponentGamePlayer(move.GetPlayerID())
-	cw.RedisClient.PublishToGamePlayer(*opponent, string(msg))
 
-	// We check for game Over
-	if game.CheckGameOver() {
-		msg := fmt.Sprintf("(Process Game Moves) - determined game is over, from game with id: %v, from player with id: %v", player.GameID, move.GetPlayerID())
-		logger.Default.Infof(msg)
-		cw.HandleGameEnd(game, "winner", move.GetPlayerID())
+	// We check for game Over, only a checkmate has a winner, the other reasons are draws.
+	if reason := board.GameOverReason(); reason != "" {
+		logger.Default.Infof("(Process Game Moves) - determined game is over by %v, from game with id: %v, from player with id: %v", reason, player.GameID, move.GetPlayerID())
+		winnerID := ""
+		if reason == models.ChessReasonCheckmate {
+			winnerID = move.GetPlayerID()
+		}
+		if err := cw.CommitGameEnd(game, winnerID); err != nil {
+			return err
+		}
+		cw.RedisClient.PublishToGamePlayer(*opponent, string(msg))
+		cw.RedisClient.PublishToSpectators(game.ID, string(msg))
+		cw.AnnounceGameEnd(game, reason, winnerID)
 		return nil
 	}
-	cw.HandleTurnChange(game)
+	if err := cw.CommitTurnChange(game); err != nil {
+		return err
+	}
+	cw.RedisClient.PublishToGamePlayer(*opponent, string(msg))
+	cw.RedisClient.PublishToSpectators(game.ID, string(msg))
+	cw.AnnounceTurnChange(game)
 
 	return nil
 }
@@ -3,6 +3,7 @@ package damasworker
 import (
 	"fmt"
 
+	"github.com/Lavizord/checkers-server/config"
 	"github.com/Lavizord/checkers-server/gameworkers/gameworker"
 	"github.com/Lavizord/checkers-server/logger"
 	"github.com/Lavizord/checkers-server/messages"
@@ -20,11 +21,17 @@ func New(redis *redisdb.RedisClient, db *postgrescli.PostgresCli) *DamasWorker {
 }
 
 func (dw *DamasWorker) Run() {
+	dw.RecoverClocks()
+	go dw.RunClockScheduler()
 	go dw.ProcessGameCreationList()
 	go dw.ProcessGameMovesList()
 	go dw.ProcessLeaveGameList()
 	go dw.ProcessDisconnectFromGameList()
 	go dw.ProcessReconnectFromGameList()
+	go dw.ProcessDrawList()
+	go dw.ProcessResignList()
+	go dw.ProcessPremoveList()
+	go dw.ProcessTakebackList()
 }
 
 func (cw *DamasWorker) ProcessGameMovesList() {
@@ -32,25 +39,29 @@ func (cw *DamasWorker) ProcessGameMovesList() {
 }
 
 func (dw *DamasWorker) HandleMove(game *models.Game, move models.MoveInterface, player *models.Player, piece models.PieceInterface) error {
-
-	_, err := game.Board.ValidateMove(move, piece)
+	board, ok := game.Board.(*models.DamasBoard)
+	if !ok {
+		return fmt.Errorf("(Process Game Moves) - game with id: %v does not have a damas board", game.ID)
+	}
+	_, err := board.ValidateMove(move, piece)
 	if err != nil {
-		msg := fmt.Errorf("(Process Game Moves) - invalid move: %+v from game with id: %v, from player with id: %v", move, player.GameID, move.GetPlayerID())
+		msg := fmt.Errorf("(Process Game Moves) - invalid move: %+v from game with id: %v, from player with id: %v, with err: %v", move, player.GameID, move.GetPlayerID(), err)
 		logger.Default.Errorf(msg.Error())
 		boardState, _ := messages.GenerateGameBoardState(*game)
 		msginv, _ := messages.NewMessage("invalid_move", boardState)
 		dw.RedisClient.PublishToPlayer(*player, string(msginv))
-		return msg
+		return gameworker.RejectMove(models.MoveRejectIllegal, msg)
 		//continue
 	}
 	// We move our piece.
+	wasKingMove := piece.IsPieceKinged()
 	if !game.MovePiece(move) {
 		msg := fmt.Errorf("(Process Game Moves) - invalid move, board missmatch: %+v from game with id: %v, from player with id: %v", move, player.GameID, move.GetPlayerID())
 		logger.Default.Errorf(msg.Error())
 		boardState, _ := messages.GenerateGameBoardState(*game)
 		msginv, _ := messages.NewMessage("invalid_move", boardState)
 		dw.RedisClient.PublishToPlayer(*player, string(msginv))
-		return msg
+		return gameworker.RejectMove(models.MoveRejectIllegal, msg)
 	}
 	game.UpdatePlayerPieces()
 	move.SetIsKingedMove(game.Board.WasPieceKinged(move.GetTo(), piece))
@@ -58,47 +69,86 @@ func (dw *DamasWorker) HandleMove(game *models.Game, move models.MoveInterface,
 		piece.SetIsPieceKinged(move.IsKingedMove())
 	}
 
-	// We send the message to the opponent player.
+	// The move message goes to the opponent once the game is saved, a move that loses against another
+	// change of the game is done again, and it must not be sent twice.
 	msg, err := messages.GenerateMoveMessage(move)
 	if err != nil {
 		logger.Default.Errorf("(Process Game Moves) - failed to generate move message: %+v, from game with id: %v, from player with id: %v", move, player.GameID, move.GetPlayerID())
 	}
 	//log.Printf("[%s-%d] - (Process Game Moves) - Message to publish: %v\n", name, pid, string(msg))
 	opponent, _ := game.GetOpponentGamePlayer(move.GetPlayerID())
-	dw.RedisClient.PublishToGamePlayer(*opponent, string(msg))
+	sendMove := func() {
+		dw.RedisClient.PublishToGamePlayer(*opponent, string(msg))
+		dw.RedisClient.PublishToSpectators(game.ID, string(msg))
+	}
 
-	// Since the move was validated and passed to the other player, its time to check for our end turn / end game conditions.
+	// Since the move was validated, its time to check for our end turn / end game conditions.
 	// This means we can add the move to our game.
 	game.Moves = append(game.Moves, move)
+	board.RecordMove(wasKingMove, move.IsCaptureMove())
 
 	// We check for game Over
 	if game.CheckGameOver() {
 		msg := fmt.Sprintf("(Process Game Moves) - determined game is over, from game with id: %v, from player with id: %v", player.GameID, move.GetPlayerID())
 		logger.Default.Infof(msg)
-		dw.HandleGameEnd(game, "winner", move.GetPlayerID())
+		if err := dw.CommitGameEnd(game, move.GetPlayerID()); err != nil {
+			return err
+		}
+		sendMove()
+		dw.AnnounceGameEnd(game, "winner", move.GetPlayerID())
 		return nil
 	}
 	// We check for a capture.
 	if !move.IsCaptureMove() {
 		msg := fmt.Sprintf("(Process Game Moves) - move is not a capture changing turn, from game with id: %v, from player with id: %v", player.GameID, move.GetPlayerID())
 		logger.Default.Infof(msg)
-		dw.HandleTurnChange(game)
-		return nil
+		return dw.handleTurnChange(game, board, sendMove)
 	}
 	if move.IsCaptureMove() && !game.Board.CanPieceCaptureNEW(move.GetTo()) {
 		msg := fmt.Errorf("(Process Game Moves) - move is capture and cant capture any more pieces, from game with id: %v, from player with id: %v", player.GameID, move.GetPlayerID())
 		logger.Default.Infof(msg.Error())
-		dw.HandleTurnChange(game)
-		return nil
+		return dw.handleTurnChange(game, board, sendMove)
 
 	}
-	if move.IsKingedMove() {
+	if move.IsKingedMove() && !board.Rules().KingedCaptureGoesOn {
 		msg := fmt.Errorf("(Process Game Moves) - move is kinged, handling turn change, from game with id: %v, from player with id: %v", player.GameID, move.GetPlayerID())
 		logger.Default.Infof(msg.Error())
-		dw.HandleTurnChange(game)
-		return nil
+		return dw.handleTurnChange(game, board, sendMove)
 
 	}
-	dw.RedisClient.UpdateGame(game) // we update our game at the end. I guess this probably never happens
+	// The piece captured and can capture again, the player keeps the turn and must continue with this piece.
+	board.CaptureLock = move.GetTo()
+	if err := dw.RedisClient.UpdateGame(game); err != nil {
+		return err
+	}
+	sendMove()
+	dw.PlayBotTurn(game)
+	return nil
+}
+
+// handleTurnChange releases the multi-jump lock before passing the turn to the opponent, and checks
+// the automatic draws for the position the opponent gets. sendMove runs once the game is saved.
+func (dw *DamasWorker) handleTurnChange(game *models.Game, board *models.DamasBoard, sendMove func()) error {
+	board.CaptureLock = ""
+	opponent, err := game.GetOpponentGamePlayer(game.CurrentPlayerID)
+	if err != nil {
+		logger.Default.Errorf("(Process Game Moves) - failed to get opponent of player with id: %v, from game with id: %v", game.CurrentPlayerID, game.ID)
+	} else {
+		board.RecordPosition(opponent.Color)
+		if reason := board.DrawReason(opponent.Color, config.Cfg.Services["gameworker"].DrawKingMoves); reason != "" {
+			logger.Default.Infof("(Process Game Moves) - determined game is drawn by %v, from game with id: %v", reason, game.ID)
+			if err := dw.CommitGameEnd(game, ""); err != nil {
+				return err
+			}
+			sendMove()
+			dw.AnnounceGameEnd(game, reason, "")
+			return nil
+		}
+	}
+	if err := dw.CommitTurnChange(game); err != nil {
+		return err
+	}
+	sendMove()
+	dw.AnnounceTurnChange(game)
 	return nil
 }
@@ -0,0 +1,46 @@
+package gameworker
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Lavizord/checkers-server/bot"
+	"github.com/Lavizord/checkers-server/logger"
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/Lavizord/checkers-server/redisdb"
+)
+
+// Time the bot waits before moving, so the player can follow the game.
+const botMoveDelay = 1 * time.Second
+
+// PlayBotTurn makes the house bot move when it is its turn. The move is pushed to the moves list, like the
+// moves sent by serverws, so it goes through the same validation as the moves of the players.
+func (gw *GameWorker) PlayBotTurn(game *models.Game) {
+	current, err := game.GetGamePlayer(game.CurrentPlayerID)
+	if err != nil || !current.IsBot {
+		return
+	}
+	go func(gameID, botID string, numMoves int) {
+		time.Sleep(botMoveDelay)
+		game, err := gw.RedisClient.GetGame(gameID)
+		if err != nil {
+			return // The game ended while the bot was waiting.
+		}
+		if game.CurrentPlayerID != botID || len(game.Moves) != numMoves {
+			return
+		}
+		move, err := bot.ChooseMove(game.Board, botID)
+		if err != nil {
+			logger.Default.Errorf("(Bot) - failed to choose a move for game with id: %v, with err: %v", gameID, err)
+			return
+		}
+		data, err := json.Marshal(move)
+		if err != nil {
+			logger.Default.Errorf("(Bot) - failed to marshal move: %+v, for game with id: %v, with err: %v", move, gameID, err)
+			return
+		}
+		if err := gw.RedisClient.RPushGeneric(redisdb.GenerateMoveListKey(gw.GameName, gameID), data); err != nil {
+			logger.Default.Errorf("(Bot) - failed to push move for game with id: %v, with err: %v", gameID, err)
+		}
+	}(game.ID, current.ID, len(game.Moves))
+}
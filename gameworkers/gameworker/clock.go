@@ -0,0 +1,275 @@
+package gameworker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Lavizord/checkers-server/logger"
+	"github.com/Lavizord/checkers-server/messages"
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// The game clocks are deadlines in redis, not goroutines, so any gameworker can run them and they survive a
+// restart. One gameworker at a time, the one with the scheduler lock, handles the deadlines and sends the
+// timer messages, if it goes down another one takes the lock.
+const (
+	clockPollInterval = 200 * time.Millisecond
+	clockLockTTL      = 3 * time.Second
+	clockBatchSize    = 100
+	clockRefireAfter  = 10 * time.Second // A deadline handled this long ago, with the game still on it, is handled again.
+)
+
+// StartClock starts the clock of a new game.
+func (gw *GameWorker) StartClock(game *models.Game) {
+	err := gw.RedisClient.SaveClock(models.NewGameClock(game, time.Now()))
+	if err != nil {
+		logger.Default.Errorf("(Clock) - failed to start clock for game with id: %v, with err: %v", game.ID, err)
+	}
+}
+
+// SwitchClock hands the clock over to the current player of the game.
+func (gw *GameWorker) SwitchClock(game *models.Game) {
+	err := gw.RedisClient.UpdateClock(game.ID, func(clock *models.GameClock) error {
+		clock.Switch(game.CurrentPlayerID, time.Now())
+		return nil
+	})
+	if err != nil {
+		logger.Default.Errorf("(Clock) - failed to switch clock for game with id: %v, with err: %v", game.ID, err)
+	}
+}
+
+// syncClockTimers copies the time the players have left from the clock to the game, the game only has it
+// for the messages and the saved game, the clock is what counts.
+func (gw *GameWorker) syncClockTimers(game *models.Game) {
+	clock, err := gw.RedisClient.GetClock(game.ID)
+	if err != nil {
+		return
+	}
+	clock.SyncTimers(game, time.Now())
+}
+
+// StopClock removes the clock of a game that ended.
+func (gw *GameWorker) StopClock(gameID string) {
+	if err := gw.RedisClient.RemoveClock(gw.GameName, gameID); err != nil {
+		logger.Default.Errorf("(Clock) - failed to stop clock for game with id: %v, with err: %v", gameID, err)
+	}
+}
+
+// RecoverClocks runs on startup, it arms again the clocks of the games in redis, and starts a clock for the
+// games that don't have one.
+func (gw *GameWorker) RecoverClocks() {
+	gameIDs, err := gw.RedisClient.GetGameIDs()
+	if err != nil {
+		logger.Default.Errorf("(Clock) - failed to get games to recover clocks, with err: %v", err)
+		return
+	}
+	recovered := 0
+	for _, gameID := range gameIDs {
+		game, err := gw.RedisClient.GetGame(gameID)
+		if err != nil || game.OperatorIdentifier.GameName != gw.GameName {
+			continue
+		}
+		_, err = gw.RedisClient.GetClock(gameID)
+		if errors.Is(err, redis.Nil) {
+			gw.StartClock(game)
+			recovered++
+			continue
+		}
+		if err != nil {
+			logger.Default.Errorf("(Clock) - failed to get clock for game with id: %v, with err: %v", gameID, err)
+			continue
+		}
+		// Saving the clock puts its deadline back in the deadlines set.
+		err = gw.RedisClient.UpdateClock(gameID, func(clock *models.GameClock) error {
+			if clock.FiredDeadline == clock.Deadline && time.Now().UnixMilli()-clock.Deadline > clockRefireAfter.Milliseconds() {
+				clock.FiredDeadline = 0
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Default.Errorf("(Clock) - failed to recover clock for game with id: %v, with err: %v", gameID, err)
+			continue
+		}
+		recovered++
+	}
+	logger.Default.Infof("(Clock) - recovered %v clocks for %v", recovered, gw.GameName)
+}
+
+// RunClockScheduler polls the deadlines while this gameworker has the scheduler lock.
+func (gw *GameWorker) RunClockScheduler() {
+	lockKey := fmt.Sprintf("clock_scheduler:{%s}", gw.GameName)
+	owner := models.GenerateUUID()
+	ticker := time.NewTicker(clockPollInterval)
+	defer ticker.Stop()
+	lastBroadcast := time.Now()
+
+	for range ticker.C {
+		leader, err := gw.RedisClient.AcquireLock(lockKey, owner, clockLockTTL)
+		if err != nil {
+			logger.Default.Errorf("(Clock) - failed to get the scheduler lock for %v, with err: %v", gw.GameName, err)
+			continue
+		}
+		if !leader {
+			continue
+		}
+		gw.fireDueClocks()
+		if time.Since(lastBroadcast) >= time.Second {
+			lastBroadcast = time.Now()
+			gw.broadcastClocks()
+			gw.rearmStaleClocks()
+		}
+	}
+}
+
+func (gw *GameWorker) fireDueClocks() {
+	gameIDs, err := gw.RedisClient.ClaimDueClocks(gw.GameName, time.Now(), clockBatchSize)
+	if err != nil {
+		logger.Default.Errorf("(Clock) - failed to claim due clocks for %v, with err: %v", gw.GameName, err)
+		return
+	}
+	for _, gameID := range gameIDs {
+		gw.fireClock(gameID)
+	}
+}
+
+// fireClock handles a deadline, the active player loses the turn or, out of time, the game. The claim took the
+// deadline off the set, so only this gameworker handles it. It is marked as handled once the turn change or the
+// game end is saved, if this fails or the gameworker dies first, rearmStaleClocks puts it back.
+func (gw *GameWorker) fireClock(gameID string) {
+	now := time.Now()
+	clock, err := gw.RedisClient.GetClock(gameID)
+	if errors.Is(err, redis.Nil) {
+		return // The game ended.
+	}
+	if err != nil {
+		logger.Default.Errorf("(Clock) - failed to fire clock for game with id: %v, with err: %v", gameID, err)
+		return
+	}
+	if !clock.Due(now) {
+		// The clock switched after the claim, saving it sets the new deadline.
+		if err := gw.RedisClient.UpdateClock(gameID, func(*models.GameClock) error { return nil }); err != nil && !errors.Is(err, redis.Nil) {
+			logger.Default.Errorf("(Clock) - failed to re-arm clock for game with id: %v, with err: %v", gameID, err)
+		}
+		return
+	}
+	deadline := clock.Deadline
+	losesOnTime := clock.LosesOnTime(now)
+	activeID := clock.ActivePlayerID
+	handled := false
+	err = gw.retryOnConflict("Clock", "game with id: "+gameID, func() error {
+		game, err := gw.RedisClient.GetGame(gameID)
+		if err != nil {
+			gw.StopClock(gameID) // The game is gone, so is its clock.
+			return nil
+		}
+		if game.CurrentPlayerID != activeID {
+			if now.UnixMilli()-deadline <= clockRefireAfter.Milliseconds() {
+				// A move is switching the clock right now. The deadline is left unhandled, so if the switch
+				// never comes rearmStaleClocks puts it back.
+				return nil
+			}
+			// The turn change was saved but the gameworker died before switching the clock, the clock goes
+			// to the player on turn.
+			logger.Default.Warnf("(Clock) - clock of game with id: %v was left on player: %v, switching it to player: %v", gameID, activeID, game.CurrentPlayerID)
+			gw.AnnounceTurnChange(game)
+			handled = true
+			return nil
+		}
+		if losesOnTime {
+			winnerID, _ := game.GetOpponentPlayerID(activeID)
+			if err := gw.HandleGameEnd(game, "timeout", winnerID); err != nil {
+				return err
+			}
+			handled = true
+			return nil
+		}
+		if err := gw.HandleTurnChange(game); err != nil {
+			return err
+		}
+		handled = true
+		return nil
+	})
+	if err != nil {
+		logger.Default.Errorf("(Clock) - failed to handle deadline for game with id: %v, with err: %v", gameID, err)
+		return
+	}
+	if !handled {
+		return
+	}
+	// A turn change already moved the deadline, this only marks the ones that stayed.
+	err = gw.RedisClient.UpdateClock(gameID, func(clock *models.GameClock) error {
+		if clock.Deadline == deadline {
+			clock.FiredDeadline = deadline
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		logger.Default.Errorf("(Clock) - failed to mark deadline as handled for game with id: %v, with err: %v", gameID, err)
+	}
+}
+
+// rearmStaleClocks puts back the deadlines that were claimed or marked as handled long ago, with the game
+// still on them, like when the gameworker handling them died. Only the scheduler leader runs it.
+func (gw *GameWorker) rearmStaleClocks() {
+	gameIDs, err := gw.RedisClient.GetGameIDs()
+	if err != nil {
+		logger.Default.Errorf("(Clock) - failed to get games to re-arm clocks, with err: %v", err)
+		return
+	}
+	now := time.Now()
+	for _, gameID := range gameIDs {
+		if err := gw.rearmClock(gameID, now); err != nil && !errors.Is(err, redis.Nil) {
+			logger.Default.Errorf("(Clock) - failed to re-arm clock for game with id: %v, with err: %v", gameID, err)
+		}
+	}
+}
+
+// rearmClock saves the clock again, which puts its deadline back in the deadlines set. A deadline handled
+// more than clockRefireAfter ago is handled again.
+func (gw *GameWorker) rearmClock(gameID string, now time.Time) error {
+	clock, err := gw.RedisClient.GetClock(gameID)
+	if err != nil {
+		return err
+	}
+	if clock.GameName != gw.GameName || now.UnixMilli()-clock.Deadline <= clockRefireAfter.Milliseconds() {
+		return nil
+	}
+	return gw.RedisClient.UpdateClock(gameID, func(clock *models.GameClock) error {
+		if clock.FiredDeadline == clock.Deadline && now.UnixMilli()-clock.Deadline > clockRefireAfter.Milliseconds() {
+			clock.FiredDeadline = 0
+		}
+		return nil
+	})
+}
+
+// broadcastClocks sends the time left of the active player to the players of each game.
+func (gw *GameWorker) broadcastClocks() {
+	gameIDs, err := gw.RedisClient.GetClockGameIDs(gw.GameName)
+	if err != nil {
+		logger.Default.Errorf("(Clock) - failed to get clocks for %v, with err: %v", gw.GameName, err)
+		return
+	}
+	now := time.Now()
+	for _, gameID := range gameIDs {
+		clock, err := gw.RedisClient.GetClock(gameID)
+		if err != nil {
+			continue
+		}
+		timer := int((clock.TimeLeft(clock.ActivePlayerID, now) + 999) / 1000)
+		if clock.TimerSetting == "reset" && !isEven(timer) {
+			continue
+		}
+		game, err := gw.RedisClient.GetGame(gameID)
+		if err != nil {
+			continue
+		}
+		msg, _ := messages.GenerateGameTimerMessage(*game, timer)
+		gw.BroadCastToGamePlayers(msg, *game)
+	}
+}
+
+func isEven(n int) bool {
+	return n&1 == 0 // Last bit = 0 → even
+}
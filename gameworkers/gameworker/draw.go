@@ -0,0 +1,105 @@
+package gameworker
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Lavizord/checkers-server/logger"
+	"github.com/Lavizord/checkers-server/messages"
+	"github.com/Lavizord/checkers-server/models"
+)
+
+// Process the draw offers and answers sent by serverws, the offer_draw and respond_draw commands.
+func (gw *GameWorker) ProcessDrawList() {
+	listName := fmt.Sprintf("draw_game:{%v}", gw.GameName)
+	for {
+		drawData, err := gw.RedisClient.BLPopGeneric(listName, 0)
+		if err != nil {
+			logger.Default.Errorf("(Process Draw) - error retrieving data from draw_game queue: %v", err)
+			continue
+		}
+		var req models.DrawRequest
+		if err := json.Unmarshal([]byte(drawData[1]), &req); err != nil {
+			logger.Default.Errorf("(Process Draw) - JSON Unmarshal Error: %v", err)
+			continue
+		}
+		player, err := gw.RedisClient.GetPlayer(req.PlayerID)
+		if err != nil {
+			logger.Default.Errorf("(Process Draw) - failed to get data of player with id: %v, with err: %v", req.PlayerID, err)
+			continue
+		}
+		err = gw.retryOnConflict("Process Draw", "game with id: "+player.GameID, func() error {
+			game, err := gw.RedisClient.GetGame(player.GameID)
+			if err != nil {
+				logger.Default.Errorf("(Process Draw) - failed to get game with id: %v, from player with id: %v", player.GameID, player.ID)
+				return nil
+			}
+			switch req.Command {
+			case "offer_draw":
+				return gw.HandleDrawOffer(game, player)
+			case "respond_draw":
+				return gw.HandleDrawResponse(game, player, req.Accept)
+			default:
+				logger.Default.Warnf("(Process Draw) - unknown draw command: %v, from player with id: %v", req.Command, player.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Default.Errorf("(Process Draw) - failed to handle %v for game with id: %v, with err: %v", req.Command, player.GameID, err)
+		}
+	}
+}
+
+// HandleDrawOffer saves the offer in the game and lets the opponent know about it. Only one offer can be pending.
+func (gw *GameWorker) HandleDrawOffer(game *models.Game, player *models.Player) error {
+	if game.DrawOfferedBy != "" {
+		msg, _ := messages.GenerateGenericMessage("invalid", "There is already a draw offer pending.")
+		gw.RedisClient.PublishToPlayer(*player, string(msg))
+		return nil
+	}
+	opponent, err := game.GetOpponentGamePlayer(player.ID)
+	if err != nil {
+		logger.Default.Errorf("(Process Draw) - failed to get opponent of player with id: %v, from game with id: %v", player.ID, game.ID)
+		return nil
+	}
+	if opponent.IsBot {
+		// The bot plays on, it declines every offer.
+		msg, _ := messages.NewMessage("draw_declined", opponent.ID)
+		gw.RedisClient.PublishToPlayer(*player, string(msg))
+		return nil
+	}
+	game.DrawOfferedBy = player.ID
+	if err := gw.RedisClient.UpdateGame(game); err != nil {
+		return err
+	}
+	msg, _ := messages.NewMessage("draw_offered", player.ID)
+	gw.RedisClient.PublishToGamePlayer(*opponent, string(msg))
+	logger.Default.Infof("(Process Draw) - player with id: %v offered a draw, in game with id: %v", player.ID, game.ID)
+	return nil
+}
+
+// HandleDrawResponse ends the game as a draw when the offer is accepted, otherwise the offer is dropped and
+// the player that made it is told.
+func (gw *GameWorker) HandleDrawResponse(game *models.Game, player *models.Player, accept bool) error {
+	if game.DrawOfferedBy == "" || game.DrawOfferedBy == player.ID {
+		msg, _ := messages.GenerateGenericMessage("invalid", "There is no draw offer to respond to.")
+		gw.RedisClient.PublishToPlayer(*player, string(msg))
+		return nil
+	}
+	if accept {
+		logger.Default.Infof("(Process Draw) - player with id: %v accepted the draw, in game with id: %v", player.ID, game.ID)
+		return gw.HandleGameEnd(game, models.ReasonDrawAgreed, "")
+	}
+	offerer, err := game.GetGamePlayer(game.DrawOfferedBy)
+	if err != nil {
+		logger.Default.Errorf("(Process Draw) - failed to get the player that offered the draw, from game with id: %v", game.ID)
+		return nil
+	}
+	game.DrawOfferedBy = ""
+	if err := gw.RedisClient.UpdateGame(game); err != nil {
+		return err
+	}
+	msg, _ := messages.NewMessage("draw_declined", player.ID)
+	gw.RedisClient.PublishToGamePlayer(*offerer, string(msg))
+	return nil
+}
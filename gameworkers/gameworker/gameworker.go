@@ -2,6 +2,7 @@ package gameworker
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 
@@ -19,10 +20,9 @@ type Worker interface {
 }
 
 type GameWorker struct {
-	RedisClient      *redisdb.RedisClient
-	Db               *postgrescli.PostgresCli
-	GameName         string
-	QueueBetAmmounts []float64
+	RedisClient *redisdb.RedisClient
+	Db          *postgrescli.PostgresCli
+	GameName    string
 }
 
 // Processes a set of redis queues and
@@ -35,11 +35,17 @@ func New(redis *redisdb.RedisClient, db *postgrescli.PostgresCli, gn string) *Ga
 }
 
 func (gw *GameWorker) Run() {
+	gw.RecoverClocks() // Before anything, so the games that were running get their clocks back.
+	go gw.RunClockScheduler()
 	go gw.ProcessGameCreationList()
 	go gw.ProcessGameMovesList()
 	go gw.ProcessLeaveGameList()
 	go gw.ProcessDisconnectFromGameList()
 	go gw.ProcessReconnectFromGameList()
+	go gw.ProcessDrawList()
+	go gw.ProcessResignList()
+	go gw.ProcessPremoveList()
+	go gw.ProcessTakebackList()
 }
 
 func (gw *GameWorker) GetGameName() string {
@@ -128,7 +134,8 @@ func (gw *GameWorker) ProcessGameCreationList() {
 			gw.RedisClient.PublishToGamePlayer(*opponent, string(msg))
 		}
 		logger.Default.Infof("(Process Game Creation) game started for players with id: %v and : %v", player1.ID, player2.ID)
-		go gw.StartTimer(game) // Start turn timer
+		gw.StartClock(game) // Start turn timer
+		gw.PlayBotTurn(game)
 	}
 }
 
@@ -137,57 +144,125 @@ func (gw *GameWorker) ProcessGameMovesList() {
 	gw.ProcessMovesLoop(gw)
 }
 
-// Shared method to process the moves of the redis list.
+// Shared method to process the moves of the redis lists.
 //
-// Does all the preliminary data validation, and calles the Worker.HandleMove, to
-// process the move within the game-
+// The moves are split over shard lists by game id, see manageMoveShards, so a game only has
+// its moves processed by one worker at a time.
 func (gw *GameWorker) ProcessMovesLoop(w Worker) {
-	listName := fmt.Sprintf("move_piece:{%v}", gw.GameName)
-	for {
-		moveData, err := gw.RedisClient.BLPopGeneric(listName, 0)
-		if err != nil {
-			logger.Default.Infof("(Process Game Moves) - Error retrieving move data: %v", err)
-			continue
-		}
+	logger.Default.Infof("starting processing gameworker move shards for: %v", gw.GameName)
+	gw.manageMoveShards(w)
+}
 
-		move, err := models.UnmarshalMove([]byte(moveData[1]), gw.GameName)
-		if err != nil {
-			logger.Default.Infof("(Process Game Moves) - JSON Unmarshal Error: %v", err)
-			continue
-		}
+// Games are saved with a compare and set on their version, see RedisClient.UpdateGame. A change that lost
+// against another one is done again on a fresh copy, this many times at most.
+const maxGameUpdateAttempts = 3
 
-		player, err := gw.RedisClient.GetPlayer(move.GetPlayerID())
-		if err != nil {
-			player = gw.RedisClient.GetDisconnectedPlayerData(move.GetPlayerID())
-			if player == nil {
-				logger.Default.Errorf("(Process Game Moves) - failed to get data of player with id: %v", move.GetPlayerID())
-				continue
-			}
-			logger.Default.Warnf("(Process Game Moves) - player retrieved from disconnected list: %v", move.GetPlayerID())
+// retryOnConflict runs fn again while it fails with a version conflict, fn must read the game itself so
+// each run works on the latest copy. The lost updates are logged.
+func (gw *GameWorker) retryOnConflict(path, subject string, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= maxGameUpdateAttempts; attempt++ {
+		err = fn()
+		if !errors.Is(err, redisdb.ErrGameVersionConflict) {
+			return err
 		}
+		logger.Default.Warnf("(%v) - lost update on %v, attempt %v of %v", path, subject, attempt, maxGameUpdateAttempts)
+	}
+	return err
+}
+
+// MoveRejection is the error of a move that was refused, the reason is sent to the player with move_rejected.
+type MoveRejection struct {
+	Reason string
+	Err    error
+}
+
+func (r *MoveRejection) Error() string { return r.Err.Error() }
+func (r *MoveRejection) Unwrap() error { return r.Err }
 
-		game, err := gw.RedisClient.GetGame(player.GameID)
+// RejectMove refuses a move for the reason, one of the models.MoveReject reasons.
+func RejectMove(reason string, err error) error {
+	return &MoveRejection{Reason: reason, Err: err}
+}
+
+// Does all the preliminary data validation, and calles the Worker.HandleMove, to
+// process the move within the game. The player always gets a move_accepted or a move_rejected.
+func (gw *GameWorker) processMove(w Worker, moveData string) {
+	move, err := models.UnmarshalMove([]byte(moveData), gw.GameName)
+	if err != nil {
+		// Without the move there is no player to answer, serverws already checks the json.
+		logger.Default.Infof("(Process Game Moves) - JSON Unmarshal Error: %v", err)
+		return
+	}
+	var game *models.Game
+	var duplicate bool
+	err = gw.retryOnConflict("Process Game Moves", "move of player with id: "+move.GetPlayerID(), func() error {
+		// The move is read again on each try, HandleMove changes it.
+		move, err := models.UnmarshalMove([]byte(moveData), gw.GameName)
 		if err != nil {
-			logger.Default.Errorf("(Process Game Moves) - failed to get game with id: %v, from player with id: %v", player.GameID, move.GetPlayerID())
-			continue
-		}
-		if game.CurrentPlayerID != move.GetPlayerID() {
-			logger.Default.Errorf("(Process Game Moves) - incorrect current player: %+v", move)
-			continue
+			return err
 		}
+		game, duplicate, err = gw.applyMove(w, move)
+		return err
+	})
+	gw.acknowledgeMove(move, game, duplicate, err)
+}
 
-		piece, _ := game.Board.GetPieceByID(move.GetPieceID())
-		if piece == nil {
-			logger.Default.Errorf("(Process Game Moves) - error getting piece from board")
-			continue
+// applyMove checks the move against the game, and hands it to the worker. A resend of a move the game
+// already has is not applied again, it is reported as a duplicate.
+func (gw *GameWorker) applyMove(w Worker, move models.MoveInterface) (*models.Game, bool, error) {
+	player, err := gw.RedisClient.GetPlayer(move.GetPlayerID())
+	if err != nil {
+		player = gw.RedisClient.GetDisconnectedPlayerData(move.GetPlayerID())
+		if player == nil {
+			return nil, false, RejectMove(models.MoveRejectNoPlayer, fmt.Errorf("failed to get data of player with id: %v", move.GetPlayerID()))
 		}
+		logger.Default.Warnf("(Process Game Moves) - player retrieved from disconnected list: %v", move.GetPlayerID())
+	}
 
-		// delegate to worker-specific move handler
-		if err := w.HandleMove(game, move, player, piece); err != nil {
-			logger.Default.Errorf("(Process Game Moves) - handle move error: %v", err)
-			continue
-		}
+	game, err := gw.RedisClient.GetGame(player.GameID)
+	if err != nil {
+		return nil, false, RejectMove(models.MoveRejectNoGame, fmt.Errorf("failed to get game with id: %v, from player with id: %v", player.GameID, move.GetPlayerID()))
+	}
+	if game.IsDuplicateMove(move) {
+		return game, true, nil
+	}
+	if game.CurrentPlayerID != move.GetPlayerID() {
+		return game, false, RejectMove(models.MoveRejectNotYourTurn, fmt.Errorf("incorrect current player: %+v", move))
+	}
+	if turn := move.GetExpectedTurn(); turn != nil && *turn != game.Turn {
+		return game, false, RejectMove(models.MoveRejectWrongTurn, fmt.Errorf("move for turn %v, game with id: %v is on turn %v", *turn, game.ID, game.Turn))
 	}
+
+	piece, _ := game.Board.GetPieceByID(move.GetPieceID())
+	if piece == nil {
+		return game, false, RejectMove(models.MoveRejectNoPiece, fmt.Errorf("error getting piece from board: %v", move.GetPieceID()))
+	}
+
+	// The sequence number is saved with the game by the worker, when the move is.
+	game.RecordMoveSeq(move)
+	// delegate to worker-specific move handler
+	return game, false, w.HandleMove(game, move, player, piece)
+}
+
+// acknowledgeMove answers the player with move_accepted, or move_rejected and the reason.
+func (gw *GameWorker) acknowledgeMove(move models.MoveInterface, game *models.Game, duplicate bool, err error) {
+	var msg []byte
+	var rejection *MoveRejection
+	switch {
+	case err == nil:
+		msg, _ = messages.GenerateMoveAcceptedMessage(move.GetSeq(), game.Turn, duplicate)
+	case errors.As(err, &rejection):
+		logger.Default.Warnf("(Process Game Moves) - move rejected with reason %v: %v", rejection.Reason, err)
+		msg, _ = messages.GenerateMoveRejectedMessage(move.GetSeq(), rejection.Reason)
+	case errors.Is(err, redisdb.ErrGameVersionConflict):
+		logger.Default.Errorf("(Process Game Moves) - handle move error: %v", err)
+		msg, _ = messages.GenerateMoveRejectedMessage(move.GetSeq(), models.MoveRejectConflict)
+	default:
+		logger.Default.Errorf("(Process Game Moves) - handle move error: %v", err)
+		msg, _ = messages.GenerateMoveRejectedMessage(move.GetSeq(), models.MoveRejectInternal)
+	}
+	gw.RedisClient.PublishToPlayerID(move.GetPlayerID(), string(msg))
 }
 
 func (gw *GameWorker) ProcessLeaveGameList() {
@@ -206,13 +281,22 @@ func (gw *GameWorker) ProcessLeaveGameList() {
 			logger.Default.Errorf("(Process Leave Game) - error fetching playerData: %v, from game with id", playerData.ID, playerData.GameID)
 			continue
 		}
-		game, err := gw.RedisClient.GetGame(playerData.GameID)
+		err = gw.retryOnConflict("Process Leave Game", "game with id: "+playerData.GameID, func() error {
+			game, err := gw.RedisClient.GetGame(playerData.GameID)
+			if err != nil {
+				logger.Default.Errorf("(Process Leave Game) - error retrieving game: %v, for player with id: %v", playerData.GameID, playerData.ID)
+				return nil
+			}
+			// Leaving before any move was made just aborts the game, nobody wins.
+			if len(game.Moves) == 0 {
+				return gw.HandleGameEnd(game, models.ReasonAborted, "")
+			}
+			winnrID, _ := game.GetOpponentPlayerID(playerData.ID)
+			return gw.HandleGameEnd(game, "player_left", winnrID)
+		})
 		if err != nil {
-			logger.Default.Errorf("(Process Leave Game) - error retrieving game: %v, for player with id: %v", playerData.GameID, playerData.ID)
-			continue
+			logger.Default.Errorf("(Process Leave Game) - failed to end game: %v, for player with id: %v, with err: %v", playerData.GameID, playerData.ID, err)
 		}
-		winnrID, _ := game.GetOpponentPlayerID(playerData.ID)
-		gw.HandleGameEnd(game, "player_left", winnrID)
 	}
 }
 
@@ -256,6 +340,7 @@ func (gw *GameWorker) ProcessReconnectFromGameList() {
 			continue
 		}
 		// We send a message to the reconnected player with the board state.
+		gw.syncClockTimers(game)
 		msg, err := messages.GenerateGameReconnectMessage(*game)
 		if err != nil {
 			log.Printf("(Process Reconnect Game) - Error generating game reconnect message: %v", err)
@@ -1,19 +1,47 @@
 package gameworker
 
 import (
+	"time"
+
 	"github.com/Lavizord/checkers-server/interfaces"
 	"github.com/Lavizord/checkers-server/logger"
 	"github.com/Lavizord/checkers-server/messages"
 	"github.com/Lavizord/checkers-server/models"
 )
 
-func (gw *GameWorker) HandleGameEnd(game *models.Game, reason string, winnerID string) {
-	//log.Printf("Handling Game End for game [%v] - reason: [%v]", game.ID, reason)
+// HandleGameEnd ends the game, it fails with redisdb.ErrGameVersionConflict when the game was changed since
+// it was read, then nothing was done and the caller has to decide again on a fresh copy of the game.
+func (gw *GameWorker) HandleGameEnd(game *models.Game, reason string, winnerID string) error {
+	if err := gw.CommitGameEnd(game, winnerID); err != nil {
+		return err
+	}
+	gw.AnnounceGameEnd(game, reason, winnerID)
+	return nil
+}
 
-	gw.PublishStopToTimerChannel(game.ID)
+// CommitGameEnd finishes the game and removes it from redis. Only one of the things that can end a game at
+// the same time gets to do it, the others get a version conflict.
+func (gw *GameWorker) CommitGameEnd(game *models.Game, winnerID string) error {
+	gw.syncClockTimers(game)
 	game.FinishGame(winnerID)
+	return gw.RedisClient.RemoveGameVersion(game)
+}
 
-	winAmount := interfaces.CalculateWinAmount(int64(game.BetValue*100), game.OperatorIdentifier.WinFactor)
+// AnnounceGameEnd does the rest of the game end, once it is committed: the wallet posts, the messages and
+// saving the game to postgres.
+func (gw *GameWorker) AnnounceGameEnd(game *models.Game, reason string, winnerID string) {
+	//log.Printf("Handling Game End for game [%v] - reason: [%v]", game.ID, reason)
+	gw.StopClock(game.ID)
+	gw.ClearPremoves(game)
+
+	winAmount := interfaces.CalculateWinAmount(game.BetValue, game.WinFactor())
+	if winnerID == "" {
+		// A draw or an aborted game, each player just gets the bet back.
+		winAmount = game.BetValue
+	}
+	if game.FreePlay {
+		winAmount = models.NewMoney(0, game.BetValue.Currency)
+	}
 	gameOverMsg, err := messages.GenerateGameOverMessage(reason, *game, winAmount)
 	if err != nil {
 		logger.Default.Errorf("[gameworker] - (Handle Game Over) - Failed to generate game over message, for game: %v, with player1 session: %v and player2 session: %v, with err: %v", game.ID, game.Players[0].SessionID, game.Players[1].SessionID, err)
@@ -24,16 +52,47 @@ func (gw *GameWorker) HandleGameEnd(game *models.Game, reason string, winnerID s
 	go gw.CleanUpGameDisconnectedPlayers(*game)
 	go gw.HandleGameEndForPlayer(winnerID, game, p1, reason, winAmount, gameOverMsg)
 	go gw.HandleGameEndForPlayer(winnerID, game, p2, reason, winAmount, gameOverMsg)
+	gw.RedisClient.PublishToSpectators(game.ID, string(gameOverMsg))
+	go gw.saveGame(*game, reason)
+}
 
-	// since the game is Over, we remove it from redis.
-	if gw.RedisClient.RemoveGame(game.ID) != nil {
-		logger.Default.Errorf("failed to remove game with id: %s, for player 1 with session id: %v and player 2 with session id: %s, from redis, with err: %v", game.ID, game.Players[0].SessionID, game.Players[1].SessionID, err)
+// saveGame saves the finished game, trying again on failure. The ledgerworker takes the bets of a round with
+// no saved game as orphans, so the save has to get through.
+func (gw *GameWorker) saveGame(game models.Game, reason string) {
+	for attempt := 1; ; attempt++ {
+		err := gw.Db.SaveGame(game, reason)
+		if err == nil {
+			return
+		}
+		if attempt == 5 {
+			logger.Default.Errorf("[gameworker] - failed to save game %v after %v attempts, with err: %v", game.ID, attempt, err)
+			return
+		}
+		logger.Default.Warnf("[gameworker] - failed to save game %v, attempt %v, with err: %v", game.ID, attempt, err)
+		time.Sleep(time.Duration(attempt) * time.Second)
 	}
-	go gw.Db.SaveGame(*game, reason)
 }
 
-func (gw *GameWorker) HandleGameEndForPlayer(winnerID string, game *models.Game, gamePlayer models.GamePlayer, reason string, winAmount int64, gameOverMsg []byte) {
-	interfaceModule := interfaces.OperatorModules[game.OperatorIdentifier.OperatorName]
+func (gw *GameWorker) HandleGameEndForPlayer(winnerID string, game *models.Game, gamePlayer models.GamePlayer, reason string, winAmount models.Money, gameOverMsg []byte) {
+	if gamePlayer.IsBot {
+		// The bot has no wallet, it is only removed.
+		gw.RedisClient.RemovePlayer(gamePlayer.ID)
+		return
+	}
+	if game.FreePlay {
+		// Nothing was bet, so there is nothing to post to the wallet.
+		gw.finishGameForPlayer(game, gamePlayer, gameOverMsg)
+		return
+	}
+	if game.HasBot() {
+		// Bot games are accounted apart, once the wallet posts are done.
+		defer func() {
+			if err := gw.Db.MarkBotGameTransactions(game.ID); err != nil {
+				logger.Default.Errorf("failed to flag the transactions of bot game with id: %s, for player with id: %s, with err: %v", game.ID, gamePlayer.ID, err)
+			}
+		}()
+	}
+	interfaceModule := interfaces.GetOperatorModule(game.OperatorIdentifier.OperatorName)
 	var balanceUpdateMsg []byte
 
 	// 1. The Winner needs to have a post to the wallet.
@@ -48,19 +107,44 @@ func (gw *GameWorker) HandleGameEndForPlayer(winnerID string, game *models.Game,
 			logger.Default.Errorf("session id of the winner is nil for game with id: %s, for player 1 with session id: %v and player 2 with session id: %s, from redis, with err: %v", game.ID, game.Players[0].SessionID, game.Players[1].SessionID, err)
 			return
 		}
+		if game.HasBot() {
+			// The bot stake is not real money, the player is only paid its own stake back.
+			winnerSession.OperatorIdentifier.WinFactor = game.WinFactor()
+		}
 		// we use our winner session here, because this way the winner will be payed out even if offline.
-		var newBalance int64
-		newBalance, _, err = interfaceModule.HandlePostWin(gw.Db, gw.RedisClient, *winnerSession, int64(game.BetValue*100), game.ID)
+		var newBalance models.Money
+		newBalance, _, err = interfaceModule.HandlePostWin(gw.Db, gw.RedisClient, *winnerSession, game.BetValue, game.ID)
 		if err != nil {
 			logger.Default.Errorf("error posting the win to the api, for session: %s, for game with id: %s, for player 1 with session id: %v and player 2 with session id: %s, from redis, with err: %v", winnerSession, game.ID, game.Players[0].SessionID, game.Players[1].SessionID, err)
 		} else {
 			// We then generate the balance update message and send it over to the game Player. The player can be offline, but I guess the message just wont get delivered.
 			// I could try to fetch the player from redis...? Is it worth it?... I fetch the player down the line...
-			balanceUpdateMsg, _ = messages.NewMessage("balance_update", float64(newBalance)/100)
+			balanceUpdateMsg, _ = messages.GenerateBalanceUpdateMessage(newBalance)
+			gw.RedisClient.PublishToGamePlayer(gamePlayer, string(balanceUpdateMsg))
+		}
+	}
+
+	// 1.1 On a draw or an aborted game there is no winner, both players get a refund of their bet.
+	if winnerID == "" {
+		session, err := gw.RedisClient.GetSessionByID(gamePlayer.ID)
+		if err != nil || session == nil {
+			logger.Default.Errorf("failed to fetch the player session to refund, player with id: %s, for game with id: %s, for player 1 with session id: %v and player 2 with session id: %s, from redis, with err: %v", gamePlayer.ID, game.ID, game.Players[0].SessionID, game.Players[1].SessionID, err)
+			return
+		}
+		newBalance, err := interfaceModule.HandlePostRefund(gw.Db, gw.RedisClient, *session, game.BetValue, game.ID)
+		if err != nil {
+			logger.Default.Errorf("error posting the refund to the api, for session: %s, for game with id: %s, for player 1 with session id: %v and player 2 with session id: %s, with err: %v", session.ID, game.ID, game.Players[0].SessionID, game.Players[1].SessionID, err)
+		} else {
+			balanceUpdateMsg, _ = messages.GenerateBalanceUpdateMessage(newBalance)
 			gw.RedisClient.PublishToGamePlayer(gamePlayer, string(balanceUpdateMsg))
 		}
 	}
 
+	gw.finishGameForPlayer(game, gamePlayer, gameOverMsg)
+}
+
+// finishGameForPlayer puts the player back online and sends the game over message.
+func (gw *GameWorker) finishGameForPlayer(game *models.Game, gamePlayer models.GamePlayer, gameOverMsg []byte) {
 	// 2. Update player data, if it exists. If not prolly offline.
 	player, err := gw.RedisClient.GetPlayer(gamePlayer.ID)
 	if err != nil {
@@ -77,14 +161,39 @@ func (gw *GameWorker) HandleGameEndForPlayer(winnerID string, game *models.Game,
 	gw.RedisClient.PublishToGamePlayer(gamePlayer, string(gameOverMsg))
 }
 
-func (gw *GameWorker) HandleTurnChange(game *models.Game) {
-	// publishStopToTimerChannel(game.ID)
+// HandleTurnChange passes the turn, like HandleGameEnd it fails with redisdb.ErrGameVersionConflict when the
+// game changed since it was read.
+func (gw *GameWorker) HandleTurnChange(game *models.Game) error {
+	if err := gw.CommitTurnChange(game); err != nil {
+		return err
+	}
+	gw.AnnounceTurnChange(game)
+	return nil
+}
+
+// CommitTurnChange passes the turn and saves the game.
+func (gw *GameWorker) CommitTurnChange(game *models.Game) error {
+	// The opponent played instead of answering the draw offer, so the offer is gone.
+	if game.DrawOfferedBy != "" && game.DrawOfferedBy != game.CurrentPlayerID {
+		game.DrawOfferedBy = ""
+	}
+	// A takeback request is about the position it was made on.
+	game.TakebackRequestedBy = ""
 	game.NextPlayer()
-	gw.RedisClient.UpdateGame(game)
+	if err := game.SaveSnapshot(); err != nil {
+		logger.Default.Errorf("(Turn Change) - failed to save snapshot of game with id: %v, with err: %v", game.ID, err)
+	}
+	return gw.RedisClient.UpdateGame(game)
+}
+
+// AnnounceTurnChange switches the clock and lets the players know, once the turn change is committed.
+func (gw *GameWorker) AnnounceTurnChange(game *models.Game) {
+	gw.SwitchClock(game)
 	msg, err := messages.NewMessage("turn_switch", game.CurrentPlayerID)
 	if err != nil {
 		logger.Default.Errorf("failed to generate turn_swith message for game with id: %s, for player 1 with session id: %s, and player 2 with session id:%s from redis, with err: %v", game.ID, game.Players[0].SessionID, game.Players[1].SessionID, err)
 	}
 	gw.BroadCastToGamePlayers(msg, *game)
-	gw.PublishSwitchToTimerChannel(game.ID) // Start a fresh timer or switch player timer.
+	gw.PlayBotTurn(game)
+	gw.PlayPremove(game)
 }
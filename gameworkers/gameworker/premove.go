@@ -0,0 +1,125 @@
+package gameworker
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Lavizord/checkers-server/logger"
+	"github.com/Lavizord/checkers-server/messages"
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/Lavizord/checkers-server/redisdb"
+)
+
+// ProcessPremoveList keeps the premoves players make during the opponent's turn. A premove is played by
+// PlayPremove once the turn changes, it then goes through the move list like any other move.
+func (gw *GameWorker) ProcessPremoveList() {
+	listName := fmt.Sprintf("premove_game:{%v}", gw.GameName)
+	for {
+		premoveData, err := gw.RedisClient.BLPopGeneric(listName, 0)
+		if err != nil {
+			logger.Default.Errorf("(Process Premove) - error retrieving data from premove_game queue: %v", err)
+			continue
+		}
+		var req models.PremoveRequest
+		if err := json.Unmarshal([]byte(premoveData[1]), &req); err != nil {
+			logger.Default.Errorf("(Process Premove) - JSON Unmarshal Error: %v", err)
+			continue
+		}
+		player, err := gw.RedisClient.GetPlayer(req.PlayerID)
+		if err != nil {
+			logger.Default.Errorf("(Process Premove) - failed to get data of player with id: %v, with err: %v", req.PlayerID, err)
+			continue
+		}
+		if err := gw.handlePremove(player, req); err != nil {
+			logger.Default.Errorf("(Process Premove) - failed to handle %v of player with id: %v, with err: %v", req.Command, player.ID, err)
+		}
+	}
+}
+
+func (gw *GameWorker) handlePremove(player *models.Player, req models.PremoveRequest) error {
+	if req.Command == "cancel_premove" {
+		if err := gw.RedisClient.ClearPremove(player.GameID, player.ID); err != nil {
+			return err
+		}
+		msg, _ := messages.NewMessage("premove_cancelled", true)
+		gw.RedisClient.PublishToPlayer(*player, string(msg))
+		return nil
+	}
+
+	game, err := gw.RedisClient.GetGame(player.GameID)
+	if err != nil {
+		return err
+	}
+	move, err := models.StripExpectedTurn(req.Move)
+	if err != nil {
+		return err
+	}
+	// It is already the player's turn, this is just a move.
+	if game.CurrentPlayerID == player.ID {
+		return gw.RedisClient.RPushGeneric(redisdb.GenerateMoveListKey(gw.GameName, game.ID), move)
+	}
+	if err := gw.RedisClient.SetPremove(game.ID, player.ID, move); err != nil {
+		return err
+	}
+	msg, _ := messages.NewMessage("premove_set", json.RawMessage(move))
+	gw.RedisClient.PublishToPlayer(*player, string(msg))
+
+	// The turn could have changed while the premove was saved, after the turn change looked for it.
+	game, err = gw.RedisClient.GetGame(player.GameID)
+	if err != nil {
+		return nil
+	}
+	if game.CurrentPlayerID == player.ID {
+		gw.PlayPremove(game)
+	}
+	return nil
+}
+
+// PlayPremove plays the premove of the player whose turn it is, if there is one and it is still legal on the
+// new position. Otherwise the premove is dropped and the player told why.
+func (gw *GameWorker) PlayPremove(game *models.Game) {
+	raw, err := gw.RedisClient.TakePremove(game.ID, game.CurrentPlayerID)
+	if err != nil {
+		logger.Default.Errorf("(Play Premove) - failed to get premove of player with id: %v, in game with id: %v, with err: %v", game.CurrentPlayerID, game.ID, err)
+		return
+	}
+	if raw == nil {
+		return
+	}
+	move, err := models.UnmarshalMove(raw, gw.GameName)
+	if err != nil {
+		logger.Default.Errorf("(Play Premove) - JSON Unmarshal Error: %v", err)
+		return
+	}
+
+	piece, _ := game.Board.GetPieceByID(move.GetPieceID())
+	if piece == nil || piece.GetPlayerID() != game.CurrentPlayerID {
+		gw.dropPremove(game.CurrentPlayerID, move, models.PremoveDroppedNoPiece)
+		return
+	}
+	if ok, _ := game.Board.ValidateMove(move, piece); !ok {
+		gw.dropPremove(game.CurrentPlayerID, move, models.PremoveDroppedIllegal)
+		return
+	}
+	if err := gw.RedisClient.RPushGeneric(redisdb.GenerateMoveListKey(gw.GameName, game.ID), raw); err != nil {
+		logger.Default.Errorf("(Play Premove) - failed to push premove of player with id: %v, in game with id: %v, with err: %v", game.CurrentPlayerID, game.ID, err)
+	}
+}
+
+// ClearPremoves drops the premoves left when the game ends.
+func (gw *GameWorker) ClearPremoves(game *models.Game) {
+	for _, player := range game.Players {
+		raw, _ := gw.RedisClient.TakePremove(game.ID, player.ID)
+		if raw == nil {
+			continue
+		}
+		if move, err := models.UnmarshalMove(raw, gw.GameName); err == nil {
+			gw.dropPremove(player.ID, move, models.PremoveDroppedGameEnded)
+		}
+	}
+}
+
+func (gw *GameWorker) dropPremove(playerID string, move models.MoveInterface, reason string) {
+	msg, _ := messages.GeneratePremoveDroppedMessage(move.GetSeq(), reason)
+	gw.RedisClient.PublishToPlayerID(playerID, string(msg))
+}
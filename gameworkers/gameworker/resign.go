@@ -0,0 +1,65 @@
+package gameworker
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Lavizord/checkers-server/logger"
+	"github.com/Lavizord/checkers-server/messages"
+	"github.com/Lavizord/checkers-server/models"
+)
+
+// Process the confirmed resigns and the aborts sent by serverws.
+//
+// A game with no moves is always aborted and both bets refunded, otherwise a resign gives the win to the
+// opponent, and an abort is refused.
+func (gw *GameWorker) ProcessResignList() {
+	listName := fmt.Sprintf("resign_game:{%v}", gw.GameName)
+	for {
+		resignData, err := gw.RedisClient.BLPopGeneric(listName, 0)
+		if err != nil {
+			logger.Default.Errorf("(Process Resign) - error retrieving data from resign_game queue: %v", err)
+			continue
+		}
+		var req models.ResignRequest
+		if err := json.Unmarshal([]byte(resignData[1]), &req); err != nil {
+			logger.Default.Errorf("(Process Resign) - JSON Unmarshal Error: %v", err)
+			continue
+		}
+		player, err := gw.RedisClient.GetPlayer(req.PlayerID)
+		if err != nil {
+			logger.Default.Errorf("(Process Resign) - failed to get data of player with id: %v, with err: %v", req.PlayerID, err)
+			continue
+		}
+		err = gw.retryOnConflict("Process Resign", "game with id: "+player.GameID, func() error {
+			return gw.handleResign(player, req.Command)
+		})
+		if err != nil {
+			logger.Default.Errorf("(Process Resign) - failed to end game with id: %v, from player with id: %v, with err: %v", player.GameID, player.ID, err)
+		}
+	}
+}
+
+func (gw *GameWorker) handleResign(player *models.Player, command string) error {
+	game, err := gw.RedisClient.GetGame(player.GameID)
+	if err != nil {
+		logger.Default.Errorf("(Process Resign) - failed to get game with id: %v, from player with id: %v", player.GameID, player.ID)
+		return nil
+	}
+	if len(game.Moves) == 0 {
+		logger.Default.Infof("(Process Resign) - player with id: %v left before the first move, aborting game with id: %v", player.ID, game.ID)
+		return gw.HandleGameEnd(game, models.ReasonAborted, "")
+	}
+	if command == "abort" {
+		msg, _ := messages.GenerateGenericMessage("invalid", "A game can only be aborted before the first move.")
+		gw.RedisClient.PublishToPlayer(*player, string(msg))
+		return nil
+	}
+	winnerID, err := game.GetOpponentPlayerID(player.ID)
+	if err != nil {
+		logger.Default.Errorf("(Process Resign) - failed to get opponent of player with id: %v, from game with id: %v", player.ID, game.ID)
+		return nil
+	}
+	logger.Default.Infof("(Process Resign) - player with id: %v resigned game with id: %v", player.ID, game.ID)
+	return gw.HandleGameEnd(game, models.ReasonResign, winnerID)
+}
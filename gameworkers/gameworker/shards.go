@@ -0,0 +1,143 @@
+package gameworker
+
+import (
+	"context"
+	"time"
+
+	"github.com/Lavizord/checkers-server/logger"
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/Lavizord/checkers-server/redisdb"
+	"github.com/redis/go-redis/v9"
+)
+
+// Each move shard is leased by one gameworker, the lease is renewed while the worker is alive, and when it
+// dies the lease runs out and another worker takes the shard. Workers take an even share of the shards.
+const (
+	moveShardLeaseTTL   = 10 * time.Second
+	moveShardRenewEvery = 3 * time.Second
+)
+
+// moveShard is the processing loop of a shard this worker holds.
+type moveShard struct {
+	cancel context.CancelFunc
+	done   chan struct{} // Closed once the loop returned, no move of the shard is in progress anymore.
+}
+
+// stop ends the loop of the shard and waits for it, so the lease is only given up once the last move is done.
+func (s moveShard) stop() {
+	s.cancel()
+	<-s.done
+}
+
+// manageMoveShards takes, renews and gives up the move shard leases, and runs a processing loop for each
+// shard this worker holds.
+func (gw *GameWorker) manageMoveShards(w Worker) {
+	owner := models.GenerateUUID()
+	shards := redisdb.MoveShardCount()
+	owned := map[int]moveShard{}
+	ticker := time.NewTicker(moveShardRenewEvery)
+	defer ticker.Stop()
+
+	for {
+		gw.migrateLegacyMoves()
+		alive, err := gw.RedisClient.HeartbeatWorker(gw.GameName, owner, moveShardLeaseTTL)
+		if err != nil || alive == 0 {
+			alive = 1
+		}
+		share := (shards + alive - 1) / alive
+		for shard := 0; shard < shards; shard++ {
+			leaseKey := redisdb.GenerateMoveShardLeaseKey(gw.GameName, shard)
+			running, isOwned := owned[shard]
+			if isOwned && len(owned) > share {
+				// More workers came up, this one hands some shards over.
+				running.stop()
+				delete(owned, shard)
+				gw.RedisClient.ReleaseLock(leaseKey, owner)
+				logger.Default.Infof("(Move Shards) - released shard %v of %v", shard, gw.GameName)
+				continue
+			}
+			if !isOwned && len(owned) >= share {
+				continue
+			}
+			held, err := gw.RedisClient.AcquireLock(leaseKey, owner, moveShardLeaseTTL)
+			if err != nil {
+				logger.Default.Errorf("(Move Shards) - failed to lease shard %v of %v, with err: %v", shard, gw.GameName, err)
+			}
+			switch {
+			case held && !isOwned:
+				ctx, cancel := context.WithCancel(context.Background())
+				running := moveShard{cancel: cancel, done: make(chan struct{})}
+				owned[shard] = running
+				go func() {
+					defer close(running.done)
+					gw.processMoveShard(ctx, w, shard, leaseKey, owner)
+				}()
+				logger.Default.Infof("(Move Shards) - took shard %v of %v", shard, gw.GameName)
+			case !held && isOwned:
+				// The lease ran out and another worker has it now.
+				running.stop()
+				delete(owned, shard)
+				logger.Default.Warnf("(Move Shards) - lost shard %v of %v", shard, gw.GameName)
+			}
+		}
+		<-ticker.C
+	}
+}
+
+// processMoveShard processes the moves of one shard, in order, until the shard is handed over. The lease is
+// checked before each move, a worker that lost it doesn't take moves the new owner should get.
+func (gw *GameWorker) processMoveShard(ctx context.Context, w Worker, shard int, leaseKey, owner string) {
+	listName := redisdb.GenerateMoveShardListKey(gw.GameName, shard)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		held, err := gw.RedisClient.HoldsLock(leaseKey, owner)
+		if err != nil || !held {
+			// manageMoveShards stops the loop on its next round, or renews the lease.
+			time.Sleep(time.Second)
+			continue
+		}
+		// Short timeout, so the loop notices the hand over.
+		moveData, err := gw.RedisClient.BLPopGeneric(listName, 1)
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			logger.Default.Infof("(Process Game Moves) - Error retrieving move data: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		gw.processMove(w, moveData[1])
+	}
+}
+
+// migrateLegacyMoves moves the moves left in the move list from before the shards to the shard of their game,
+// like the ones pushed by a serverws that was not updated yet.
+func (gw *GameWorker) migrateLegacyMoves() {
+	for {
+		moveData, err := gw.RedisClient.PopLegacyMove(gw.GameName)
+		if err == redis.Nil {
+			return
+		}
+		if err != nil {
+			logger.Default.Errorf("(Move Shards) - failed to read the legacy move list of %v, with err: %v", gw.GameName, err)
+			return
+		}
+		move, err := models.UnmarshalMove([]byte(moveData), gw.GameName)
+		if err != nil {
+			logger.Default.Warnf("(Move Shards) - dropped legacy move that can't be read, with err: %v", err)
+			continue
+		}
+		player, err := gw.RedisClient.GetPlayer(move.GetPlayerID())
+		if err != nil || player.GameID == "" {
+			logger.Default.Warnf("(Move Shards) - dropped legacy move of player with id: %v, not in a game", move.GetPlayerID())
+			continue
+		}
+		if err := gw.RedisClient.RPushGeneric(redisdb.GenerateMoveListKey(gw.GameName, player.GameID), []byte(moveData)); err != nil {
+			logger.Default.Errorf("(Move Shards) - failed to move legacy move of game with id: %v, with err: %v", player.GameID, err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package gameworker
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Lavizord/checkers-server/logger"
+	"github.com/Lavizord/checkers-server/messages"
+	"github.com/Lavizord/checkers-server/models"
+)
+
+// Process the takeback requests and answers sent by serverws, the request_takeback and accept_takeback commands.
+// Takebacks only exist in games without a stake.
+func (gw *GameWorker) ProcessTakebackList() {
+	listName := fmt.Sprintf("takeback_game:{%v}", gw.GameName)
+	for {
+		takebackData, err := gw.RedisClient.BLPopGeneric(listName, 0)
+		if err != nil {
+			logger.Default.Errorf("(Process Takeback) - error retrieving data from takeback_game queue: %v", err)
+			continue
+		}
+		var req models.TakebackRequest
+		if err := json.Unmarshal([]byte(takebackData[1]), &req); err != nil {
+			logger.Default.Errorf("(Process Takeback) - JSON Unmarshal Error: %v", err)
+			continue
+		}
+		player, err := gw.RedisClient.GetPlayer(req.PlayerID)
+		if err != nil {
+			logger.Default.Errorf("(Process Takeback) - failed to get data of player with id: %v, with err: %v", req.PlayerID, err)
+			continue
+		}
+		err = gw.retryOnConflict("Process Takeback", "game with id: "+player.GameID, func() error {
+			game, err := gw.RedisClient.GetGame(player.GameID)
+			if err != nil {
+				logger.Default.Errorf("(Process Takeback) - failed to get game with id: %v, from player with id: %v", player.GameID, player.ID)
+				return nil
+			}
+			switch req.Command {
+			case "request_takeback":
+				return gw.HandleTakebackRequest(game, player)
+			case "accept_takeback":
+				return gw.HandleTakebackResponse(game, player, req.Accept)
+			default:
+				logger.Default.Warnf("(Process Takeback) - unknown takeback command: %v, from player with id: %v", req.Command, player.ID)
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Default.Errorf("(Process Takeback) - failed to handle %v for game with id: %v, with err: %v", req.Command, player.GameID, err)
+		}
+	}
+}
+
+// HandleTakebackRequest saves the request in the game and asks the opponent. Only one request can be pending.
+func (gw *GameWorker) HandleTakebackRequest(game *models.Game, player *models.Player) error {
+	if !game.TakebacksAllowed() {
+		msg, _ := messages.GenerateGenericMessage("invalid", "Takebacks are only allowed in free-play games.")
+		gw.RedisClient.PublishToPlayer(*player, string(msg))
+		return nil
+	}
+	if game.TakebackRequestedBy != "" {
+		msg, _ := messages.GenerateGenericMessage("invalid", "There is already a takeback request pending.")
+		gw.RedisClient.PublishToPlayer(*player, string(msg))
+		return nil
+	}
+	if !game.CanTakeback(player.ID) {
+		msg, _ := messages.GenerateGenericMessage("invalid", "There is no move to take back.")
+		gw.RedisClient.PublishToPlayer(*player, string(msg))
+		return nil
+	}
+	opponent, err := game.GetOpponentGamePlayer(player.ID)
+	if err != nil {
+		logger.Default.Errorf("(Process Takeback) - failed to get opponent of player with id: %v, from game with id: %v", player.ID, game.ID)
+		return nil
+	}
+	if opponent.IsBot {
+		msg, _ := messages.NewMessage("takeback_declined", opponent.ID)
+		gw.RedisClient.PublishToPlayer(*player, string(msg))
+		return nil
+	}
+	game.TakebackRequestedBy = player.ID
+	if err := gw.RedisClient.UpdateGame(game); err != nil {
+		return err
+	}
+	msg, _ := messages.NewMessage("takeback_requested", player.ID)
+	gw.RedisClient.PublishToGamePlayer(*opponent, string(msg))
+	logger.Default.Infof("(Process Takeback) - player with id: %v requested a takeback, in game with id: %v", player.ID, game.ID)
+	return nil
+}
+
+// HandleTakebackResponse puts the game back at the start of the last turn of the player that asked, when
+// accepted, otherwise the request is dropped and that player is told.
+func (gw *GameWorker) HandleTakebackResponse(game *models.Game, player *models.Player, accept bool) error {
+	if game.TakebackRequestedBy == "" || game.TakebackRequestedBy == player.ID {
+		msg, _ := messages.GenerateGenericMessage("invalid", "There is no takeback request to respond to.")
+		gw.RedisClient.PublishToPlayer(*player, string(msg))
+		return nil
+	}
+	requester, err := game.GetGamePlayer(game.TakebackRequestedBy)
+	if err != nil {
+		logger.Default.Errorf("(Process Takeback) - failed to get the player that requested the takeback, from game with id: %v", game.ID)
+		return nil
+	}
+	if !accept {
+		game.TakebackRequestedBy = ""
+		if err := gw.RedisClient.UpdateGame(game); err != nil {
+			return err
+		}
+		msg, _ := messages.NewMessage("takeback_declined", player.ID)
+		gw.RedisClient.PublishToGamePlayer(*requester, string(msg))
+		return nil
+	}
+
+	if err := game.Takeback(requester.ID); err != nil {
+		return err
+	}
+	if err := gw.RedisClient.UpdateGame(game); err != nil {
+		return err
+	}
+	logger.Default.Infof("(Process Takeback) - player with id: %v accepted the takeback, game with id: %v is back on turn %v", player.ID, game.ID, game.Turn)
+	gw.restartClock(game)
+	for _, gamePlayer := range game.Players {
+		gw.RedisClient.ClearPremove(game.ID, gamePlayer.ID)
+	}
+	gw.syncClockTimers(game)
+	msg, err := messages.GenerateGameBoardState(*game)
+	if err != nil {
+		logger.Default.Errorf("(Process Takeback) - failed to generate board state for game with id: %v, with err: %v", game.ID, err)
+		return nil
+	}
+	gw.BroadCastToGamePlayers(msg, *game)
+	return nil
+}
+
+// restartClock gives the clock to the player the turn went back to.
+func (gw *GameWorker) restartClock(game *models.Game) {
+	err := gw.RedisClient.UpdateClock(game.ID, func(clock *models.GameClock) error {
+		clock.Restart(game.CurrentPlayerID, time.Now())
+		return nil
+	})
+	if err != nil {
+		logger.Default.Errorf("(Clock) - failed to restart clock for game with id: %v, with err: %v", game.ID, err)
+	}
+}
@@ -0,0 +1,296 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/Lavizord/checkers-server/postgrescli"
+	"github.com/Lavizord/checkers-server/redisdb"
+	"github.com/Lavizord/checkers-server/walletrequests"
+)
+
+// walletOperator gets the operator of the session, which has to be set up for the generic wallet.
+func walletOperator(rc *redisdb.RedisClient, pgs *postgrescli.PostgresCli, id models.OperatorIdentifier) (*models.Operator, error) {
+	operator, err := loadOperator(rc, pgs, id)
+	if err != nil {
+		return nil, err
+	}
+	if operator.WalletConfig == nil {
+		return nil, fmt.Errorf("operator %s has no wallet config", id.OperatorName)
+	}
+	if err := operator.WalletConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("operator %s: %v", id.OperatorName, err)
+	}
+	return operator, nil
+}
+
+// walletBody maps our fields to the ones of the operator.
+func walletBody(cfg *models.WalletConfig, fields map[string]interface{}) map[string]interface{} {
+	body := make(map[string]interface{}, len(fields))
+	for field, value := range fields {
+		if name := cfg.RequestField(field); name != "" {
+			body[name] = value
+		}
+	}
+	return body
+}
+
+func walletBalance(cfg *models.WalletConfig, answer map[string]interface{}, currency string) (models.Money, error) {
+	balance, err := cfg.FromOperatorAmount(cfg.ResponseValue(answer, "balance"), currency)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to parse balance: %v", err)
+	}
+	return balance, nil
+}
+
+func (m *GenericWalletModule) HandleGameLaunch(w http.ResponseWriter, r *http.Request, req models.GameLaunchRequest, op models.Operator, rc *redisdb.RedisClient, pgs *postgrescli.PostgresCli) {
+	cfg := op.WalletConfig
+	if cfg == nil {
+		respondWithError(w, "Operator has no wallet config", fmt.Errorf("operator: %s", op.OperatorName))
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		respondWithError(w, "Invalid wallet config", err)
+		return
+	}
+	body := walletBody(cfg, map[string]interface{}{
+		"token":    req.Token,
+		"currency": req.Currency,
+		"game_id":  op.OperatorGameName,
+	})
+	answer, err := walletrequests.GenericWalletPost(op.OperatorWalletBaseUrl, *cfg, operatorSigning(rc, &op), cfg.Endpoints.Authenticate, req.Token, body)
+	if err != nil {
+		respondWithError(w, "Failed to authenticate player", err)
+		return
+	}
+	username := cfg.ResponseString(answer, "player")
+	if username == "" {
+		respondWithError(w, "Failed to authenticate player", fmt.Errorf("no player in the operator answer"))
+		return
+	}
+	currency := cfg.ResponseString(answer, "currency")
+	if currency == "" {
+		currency = req.Currency
+	}
+	session, err := launchSession(req, op, username, currency, rc, pgs)
+	if err != nil {
+		respondWithError(w, "Failed to generate session", err)
+		return
+	}
+	gameURL, err := generateGameURL(op.GameBaseUrl, req.Token, session.ID, currency)
+	if err != nil {
+		respondWithError(w, "Failed to generate game URL", err)
+		return
+	}
+	respondWithJSON(w, http.StatusOK, models.SokkerDuelGamelaunchResponse{
+		Token: req.Token,
+		Url:   gameURL,
+	})
+}
+
+func (m *GenericWalletModule) HandleFetchWalletBalance(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, s models.Session) (models.Money, error) {
+	operator, err := walletOperator(rc, pgs, s.OperatorIdentifier)
+	if err != nil {
+		return models.Money{}, err
+	}
+	cfg := operator.WalletConfig
+	// Without a balance endpoint the authenticate one has the balance too.
+	endpoint := cfg.Endpoints.Balance
+	if endpoint == "" {
+		endpoint = cfg.Endpoints.Authenticate
+	}
+	body := walletBody(cfg, map[string]interface{}{
+		"token":    s.Token,
+		"player":   s.PlayerName,
+		"currency": s.Currency,
+		"game_id":  s.OperatorIdentifier.OperatorGameName,
+	})
+	answer, err := walletrequests.GenericWalletPost(s.OperatorBaseUrl, *cfg, operatorSigning(rc, operator), endpoint, s.Token, body)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to fetch wallet: %v", err)
+	}
+	return walletBalance(cfg, answer, s.Currency)
+}
+
+func (m *GenericWalletModule) HandlePostBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error) {
+	if betValue.Amount <= 0 {
+		return models.Money{}, fmt.Errorf("invalid bet value: %v", betValue)
+	}
+	if betValue.Currency != session.Currency {
+		return models.Money{}, fmt.Errorf("bet currency %s doesn't match the session currency %s", betValue.Currency, session.Currency)
+	}
+	return m.post(pgs, rc, session, "bet", betValue, gameID, "")
+}
+
+func (m *GenericWalletModule) HandlePostWin(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, winValue models.Money, gameID string) (models.Money, models.Money, error) {
+	if winValue.Amount <= 0 {
+		return models.Money{}, models.Money{}, fmt.Errorf("invalid win value: %v", winValue)
+	}
+	winnings := CalculateWinAmount(winValue, session.OperatorIdentifier.WinFactor)
+	balance, err := m.post(pgs, rc, session, "win", winnings, gameID, betReference(pgs, session, gameID))
+	return balance, winnings, err
+}
+
+func (m *GenericWalletModule) HandlePostRefund(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error) {
+	if betValue.Amount <= 0 {
+		return models.Money{}, fmt.Errorf("invalid refund value: %v", betValue)
+	}
+	return m.post(pgs, rc, session, "refund", betValue, gameID, betReference(pgs, session, gameID))
+}
+
+func (m *GenericWalletModule) HandleRollbackBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error) {
+	bet, err := pgs.FetchLedgerBet(session.ID, gameID)
+	if err != nil {
+		return models.Money{}, err
+	}
+	if bet.Amount != betValue.Amount || bet.Currency != betValue.Currency {
+		return models.Money{}, fmt.Errorf("rollback value %v doesn't match the bet %s of %d %s", betValue, bet.TransactionID, bet.Amount, bet.Currency)
+	}
+	balance, err := m.post(pgs, rc, session, "rollback", betValue, gameID, bet.TransactionID)
+	if err != nil {
+		return models.Money{}, err
+	}
+	if err := pgs.MarkLedgerBetRolledBack(session.ID, gameID); err != nil {
+		log.Printf("[GenericWallet] - failed to mark bet %s as rolled back: %v", bet.TransactionID, err)
+	}
+	return balance, nil
+}
+
+// HandleRetryTransaction posts the saved body of the entry again to the endpoint of its type.
+func (m *GenericWalletModule) HandleRetryTransaction(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, entry models.LedgerEntry) (models.Money, error) {
+	session, err := rc.GetSessionByID(entry.SessionID)
+	if err != nil || session == nil {
+		session, err = unmarshalLedgerSession(entry)
+		if err != nil {
+			return models.Money{}, err
+		}
+	}
+	operator, err := walletOperator(rc, pgs, session.OperatorIdentifier)
+	if err != nil {
+		return models.Money{}, err
+	}
+	cfg := operator.WalletConfig
+	endpoint, err := walletEndpoint(cfg, entry.Type)
+	if err != nil {
+		return models.Money{}, err
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(entry.Payload, &body); err != nil {
+		return models.Money{}, fmt.Errorf("failed to unmarshal %s payload: %v", entry.Type, err)
+	}
+	answer, err := walletrequests.GenericWalletPost(session.OperatorBaseUrl, *cfg, operatorSigning(rc, operator), endpoint, session.Token, body)
+	if err != nil {
+		return models.Money{}, err
+	}
+	if entry.Type == "rollback" {
+		if err := pgs.MarkLedgerBetRolledBack(entry.SessionID, entry.RoundID); err != nil {
+			log.Printf("[GenericWallet] - failed to mark bet of round %s as rolled back: %v", entry.RoundID, err)
+		}
+	}
+	saveWalletTransaction(pgs, *session, entry.TransactionID, entry.Type, models.NewMoney(entry.Amount, entry.Currency), entry.RoundID, answer)
+	return walletBalance(cfg, answer, session.Currency)
+}
+
+// post sends a transaction of the given type through the ledger. A bet is not taken if the ledger can't keep
+// track of it, the rest are posted anyway and sent again by the ledgerworker when they fail.
+func (m *GenericWalletModule) post(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, transType string, amount models.Money, gameID string, referenceID string) (models.Money, error) {
+	if gameID == "" {
+		return models.Money{}, fmt.Errorf("empty game ID")
+	}
+	if session.ID == "" {
+		return models.Money{}, fmt.Errorf("invalid session")
+	}
+	operator, err := walletOperator(rc, pgs, session.OperatorIdentifier)
+	if err != nil {
+		return models.Money{}, err
+	}
+	cfg := operator.WalletConfig
+	endpoint, err := walletEndpoint(cfg, transType)
+	if err != nil {
+		return models.Money{}, err
+	}
+	transactionID := models.GenerateUUID()
+	fields := map[string]interface{}{
+		"token":          session.Token,
+		"player":         session.PlayerName,
+		"amount":         cfg.ToOperatorAmount(amount),
+		"currency":       amount.Currency,
+		"transaction_id": transactionID,
+		"round_id":       gameID,
+		"game_id":        session.OperatorIdentifier.OperatorGameName,
+	}
+	if referenceID != "" {
+		fields["reference_id"] = referenceID
+	}
+	body := walletBody(cfg, fields)
+
+	if err := openLedgerEntry(pgs, newLedgerEntry(session, transactionID, transType, amount, gameID, body)); err != nil {
+		if transType == "bet" {
+			return models.Money{}, fmt.Errorf("failed to save bet to the ledger: %v", err)
+		}
+		log.Printf("[GenericWallet] - failed to save %s %s to the ledger: %v", transType, transactionID, err)
+	}
+	answer, err := walletrequests.GenericWalletPost(session.OperatorBaseUrl, *cfg, operatorSigning(rc, operator), endpoint, session.Token, body)
+	closeLedgerEntry(pgs, transactionID, answer, err, transType != "bet")
+	if err != nil {
+		return models.Money{}, err
+	}
+	saveWalletTransaction(pgs, session, transactionID, transType, amount, gameID, answer)
+	return walletBalance(cfg, answer, session.Currency)
+}
+
+func walletEndpoint(cfg *models.WalletConfig, transType string) (string, error) {
+	switch transType {
+	case "bet":
+		return cfg.Endpoints.Debit, nil
+	case "win", "refund":
+		return cfg.Endpoints.Credit, nil
+	case "rollback":
+		if cfg.Endpoints.Rollback != "" {
+			return cfg.Endpoints.Rollback, nil
+		}
+		return cfg.Endpoints.Credit, nil
+	}
+	return "", fmt.Errorf("invalid transaction type: %s", transType)
+}
+
+// betReference is the transaction of the bet of the player in the round, for the operators that link the
+// credits to it. Empty when it is not in the ledger.
+func betReference(pgs *postgrescli.PostgresCli, session models.Session, gameID string) string {
+	bet, err := pgs.FetchLedgerBet(session.ID, gameID)
+	if err != nil {
+		return ""
+	}
+	return bet.TransactionID
+}
+
+func saveWalletTransaction(pgs *postgrescli.PostgresCli, session models.Session, transactionID string, transType string, amount models.Money, gameID string, answer map[string]interface{}) {
+	trans := models.Transaction{
+		ID:          transactionID,
+		SessionID:   session.ID,
+		Type:        transType,
+		Amount:      amount.Amount,
+		Currency:    amount.Currency,
+		Platform:    "generic",
+		Operator:    session.OperatorIdentifier.OperatorName,
+		Client:      session.PlayerName,
+		Game:        session.OperatorIdentifier.GameName,
+		RoundID:     gameID,
+		Timestamp:   time.Now(),
+		Status:      "success",
+		Description: truncateDescription(string(mustMarshal(answer))),
+	}
+	go pgs.SaveTransaction(trans)
+}
+
+// The description column holds 600 characters, the answers of some operators are longer.
+func truncateDescription(s string) string {
+	if len(s) > 600 {
+		return s[:600]
+	}
+	return s
+}
@@ -16,12 +16,21 @@ import (
 // OperatorModule defines the interface for operator-specific code
 type OperatorInterface interface {
 	HandleGameLaunch(w http.ResponseWriter, r *http.Request, req models.GameLaunchRequest, op models.Operator, rc *redisdb.RedisClient, pgs *postgrescli.PostgresCli)
-	HandleFetchWalletBalance(s models.Session, rc *redisdb.RedisClient) (int64, error)
-	HandlePostBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue int64, gameID string) (int64, error)
-	HandlePostWin(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue int64, gameID string) (int64, int64, error)
+	HandleFetchWalletBalance(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, s models.Session) (models.Money, error)
+	HandlePostBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error)
+	// HandlePostWin pays the winnings of the bet value, returns the new balance and the winnings.
+	HandlePostWin(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, models.Money, error)
+	// HandlePostRefund gives the bet value back to the player, used when a game ends in a draw.
+	HandlePostRefund(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error)
+	// HandleRollbackBet gives back the bet of a game that never started, like when the other player failed to bet
+	// or the game crashed before it was saved.
+	HandleRollbackBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error)
+	// HandleRetryTransaction posts a ledger entry again, with the same transaction ID. Returns the new balance.
+	HandleRetryTransaction(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, entry models.LedgerEntry) (models.Money, error)
 }
 
-// OperatorModules maps operator names to their respective modules
+// OperatorModules maps operator names to their respective modules. Operators that are not here go through the
+// generic wallet, set up with the wallet config in the operators table.
 var OperatorModules = map[string]OperatorInterface{
 	"SokkerDuel": &SokkerDuelModule{},
 	"TestOp":     &TestModule{},
@@ -29,50 +38,24 @@ var OperatorModules = map[string]OperatorInterface{
 	// Add more operators as needed
 }
 
+var genericWallet = &GenericWalletModule{}
+
+// GetOperatorModule gets the module of the operator, the generic wallet if it has none of its own.
+func GetOperatorModule(operatorName string) OperatorInterface {
+	if module, ok := OperatorModules[operatorName]; ok {
+		return module
+	}
+	return genericWallet
+}
+
 // SokkerDuelModule handles requests for the SokkerDuel operator
 type SokkerDuelModule struct{}
 
 // TestModule handles requests for test accounts
 type TestModule struct{}
 
-// Helper function to save failed transactions
-func saveFailedBetTransaction(pgs *postgrescli.PostgresCli, session models.Session, betData models.SokkerDuelBet, apiError error, gameID string) error {
-	trans := models.Transaction{
-		ID:          betData.TransactionID,
-		SessionID:   session.ID,
-		Type:        "bet",
-		Amount:      betData.Amount,
-		Currency:    session.Currency,
-		Platform:    "sokkerpro",
-		Operator:    "SokkerDuel",
-		Client:      session.PlayerName,
-		Game:        session.OperatorIdentifier.GameName,
-		RoundID:     gameID,
-		Timestamp:   time.Now(),
-		Status:      "error",
-		Description: apiError.Error(),
-	}
-	return pgs.SaveTransaction(trans)
-}
-
-func saveFailedWinTransaction(pgs *postgrescli.PostgresCli, session models.Session, winData models.SokkerDuelWin, apiError error, gameID string) error {
-	trans := models.Transaction{
-		ID:          winData.TransactionID,
-		SessionID:   session.ID,
-		Type:        "win",
-		Amount:      winData.Amount,
-		Currency:    session.Currency,
-		Platform:    "sokkerpro",
-		Operator:    "SokkerDuel",
-		Client:      session.PlayerName,
-		Game:        session.OperatorIdentifier.GameName,
-		RoundID:     gameID,
-		Timestamp:   time.Now(),
-		Status:      "error",
-		Description: apiError.Error(),
-	}
-	return pgs.SaveTransaction(trans)
-}
+// GenericWalletModule handles the operators set up with a wallet config instead of a module of their own.
+type GenericWalletModule struct{}
 
 func generateGameURL(baseURL, token, sessionID, currency string) (string, error) {
 	// Parse the base URL
@@ -103,6 +86,9 @@ func generatePlayerSession(op models.Operator, token, username, currency string,
 			OperatorGameName: op.OperatorGameName,
 			GameName:         op.GameName,
 			WinFactor:        op.WinFactor,
+			Variant:          op.Variant,
+			BotEnabled:       op.BotEnabled,
+			BetTiers:         op.BetTiers,
 		},
 		OperatorBaseUrl: op.OperatorWalletBaseUrl,
 		CreatedAt:       time.Now(),
@@ -111,6 +97,78 @@ func generatePlayerSession(op models.Operator, token, username, currency string,
 	return &session, err
 }
 
+// loadOperator gets the operator of the session from the redis cache, or from postgres when we have it.
+func loadOperator(rc *redisdb.RedisClient, pgs *postgrescli.PostgresCli, id models.OperatorIdentifier) (*models.Operator, error) {
+	operator, err := rc.GetOperator(id.OperatorName, id.OperatorGameName)
+	if err == nil {
+		return operator, nil
+	}
+	if pgs == nil {
+		return nil, err
+	}
+	operator, err = pgs.FetchOperator(id.OperatorName, id.OperatorGameName)
+	if err != nil {
+		return nil, err
+	}
+	rc.AddOperator(operator)
+	return operator, nil
+}
+
+// operatorSigning is the signing of the wallet calls of the operator, nil when they are not signed. The hmac
+// auth of the generic wallet signs with its own secret when there is no signing set. The nonces of the signed
+// answers are claimed in redis, like the ones of the callbacks.
+func operatorSigning(rc *redisdb.RedisClient, operator *models.Operator) *models.WalletSigning {
+	var signing *models.WalletSigning
+	if operator.Signing != nil {
+		copied := *operator.Signing
+		signing = &copied
+	} else if operator.WalletConfig != nil {
+		signing = operator.WalletConfig.Auth.Signing()
+	}
+	if signing == nil {
+		return nil
+	}
+	operatorName := operator.OperatorName
+	signing.ClaimNonce = func(nonce string, ttl time.Duration) (bool, error) {
+		return rc.ClaimWalletNonce(operatorName, nonce, ttl)
+	}
+	return signing
+}
+
+// sessionSigning is the signing of the operator of the session. If the operator can't be loaded the call goes
+// unsigned, an operator that wants it signed will turn it down.
+func sessionSigning(rc *redisdb.RedisClient, pgs *postgrescli.PostgresCli, session models.Session) *models.WalletSigning {
+	operator, err := loadOperator(rc, pgs, session.OperatorIdentifier)
+	if err != nil {
+		log.Printf("failed to load operator %s to sign the wallet call: %v", session.OperatorIdentifier.OperatorName, err)
+		return nil
+	}
+	return operatorSigning(rc, operator)
+}
+
+// launchSession gets the session of the player for the game launch. The one of the token is used if it is
+// still there, then the previous one of the player, which gets the new token, and a new one otherwise.
+func launchSession(req models.GameLaunchRequest, op models.Operator, username string, currency string, rc *redisdb.RedisClient, pgs *postgrescli.PostgresCli) (*models.Session, error) {
+	session, err := checkExistingSession(req.Token, rc)
+	if err == nil && session != nil {
+		return session, nil
+	}
+	session, _ = checkPreviousPlayerSession(req.OperatorName, username, req.Currency, rc)
+	if session != nil {
+		rc.DisconnectPlayer(session.ID) // We send a message to disconnect the previous websocket connection.
+		rc.RemoveSession(session.ID)    // If the session exists, from a previous token, we remove the session
+		session.Token = req.Token       // We just update the token.
+		rc.AddSession(session)          // we update the session in redis.
+		return session, nil
+	}
+	session, err = generatePlayerSession(op, req.Token, username, currency, rc)
+	if err != nil {
+		return nil, err
+	}
+	go handleSaveSession(session, pgs)
+	return session, nil
+}
+
 func checkExistingSession(token string, rc *redisdb.RedisClient) (*models.Session, error) {
 	// First, check Redis for an active session
 	session, err := rc.GetSessionByToken(token)
@@ -157,12 +215,9 @@ func mustMarshal(v interface{}) []byte {
 	return b
 }
 
-func CalculateWinAmount(betValue int64, winFactor float64) int64 {
-	// Multiply by 2 then by 0.9 (equivalent to multiplying by 1.8)
-	// Using float64 for precise multiplication then converting back to int
-	winAmount := float64(betValue*2) * winFactor
-	//log.Printf("Calculating win amount:[betValue: %v], [winFactor: %v], final value: [%v]", betValue, winFactor, winAmount)
-	return int64(winAmount) // Truncates decimal places
+// CalculateWinAmount is twice the bet times the win factor, like 1.8 times the bet with a 0.9 factor.
+func CalculateWinAmount(betValue models.Money, winFactor float64) models.Money {
+	return betValue.Payout(winFactor)
 }
 
 func handleSaveSession(session *models.Session, pgs *postgrescli.PostgresCli) {
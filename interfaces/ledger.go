@@ -0,0 +1,61 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/Lavizord/checkers-server/postgrescli"
+	"github.com/Lavizord/checkers-server/walletrequests"
+)
+
+func newLedgerEntry(session models.Session, transactionID string, transType string, amount models.Money, gameID string, payload interface{}) models.LedgerEntry {
+	return models.LedgerEntry{
+		TransactionID: transactionID,
+		SessionID:     session.ID,
+		Type:          transType,
+		Amount:        amount.Amount,
+		Currency:      amount.Currency,
+		Operator:      session.OperatorIdentifier.OperatorName,
+		Game:          session.OperatorIdentifier.GameName,
+		RoundID:       gameID,
+		Status:        models.LedgerPending,
+		Payload:       mustMarshal(payload),
+		Session:       mustMarshal(session),
+	}
+}
+
+// openLedgerEntry saves the entry and marks it sent, it has to be called before the post to the operator.
+func openLedgerEntry(pgs *postgrescli.PostgresCli, entry models.LedgerEntry) error {
+	if err := pgs.CreateLedgerEntry(entry); err != nil {
+		return err
+	}
+	return pgs.MarkLedgerSent(entry.TransactionID)
+}
+
+// closeLedgerEntry saves the outcome of the post, with the answer of the operator when it went through. Failed
+// entries with retry are picked up by the ledgerworker. An entry without retry that got no answer, like a bet,
+// is unknown, the orphan bets sweep rolls it back.
+func closeLedgerEntry(pgs *postgrescli.PostgresCli, transactionID string, response interface{}, postErr error, retry bool) {
+	var err error
+	if postErr != nil && !retry && errors.Is(postErr, walletrequests.ErrNoAnswer) {
+		err = pgs.MarkLedgerUnknown(transactionID, postErr)
+	} else if postErr != nil {
+		err = pgs.MarkLedgerFailed(transactionID, postErr, retry)
+	} else {
+		err = pgs.MarkLedgerConfirmed(transactionID, mustMarshal(response))
+	}
+	if err != nil {
+		log.Printf("[Ledger] - failed to update ledger entry %s, with err: %v", transactionID, err)
+	}
+}
+
+func unmarshalLedgerSession(entry models.LedgerEntry) (*models.Session, error) {
+	var session models.Session
+	if err := json.Unmarshal(entry.Session, &session); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the session of ledger entry %s: %v", entry.TransactionID, err)
+	}
+	return &session, nil
+}
@@ -1,9 +1,10 @@
 package interfaces
 
 import (
+	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/Lavizord/checkers-server/models"
@@ -14,7 +15,7 @@ import (
 
 func (m *SokkerDuelModule) HandleGameLaunch(w http.ResponseWriter, r *http.Request, req models.GameLaunchRequest, op models.Operator, rc *redisdb.RedisClient, pgs *postgrescli.PostgresCli) {
 	// Fetch wallet information
-	logInResponse, err := walletrequests.SokkerDuelGetWallet(op.OperatorWalletBaseUrl, req.Token)
+	logInResponse, err := walletrequests.SokkerDuelGetWallet(op.OperatorWalletBaseUrl, req.Token, operatorSigning(rc, &op))
 	if err != nil {
 		respondWithError(w, "Failed to fetch wallet", err)
 		return
@@ -23,28 +24,10 @@ func (m *SokkerDuelModule) HandleGameLaunch(w http.ResponseWriter, r *http.Reque
 		respondWithError(w, "Wallet request != success", fmt.Errorf("api err: %v", logInResponse.Data))
 		return
 	}
-	session, err := checkExistingSession(req.Token, rc)
-	if err != nil || session == nil {
-		session, _ = checkPreviousPlayerSession(req.OperatorName, logInResponse.Data.Username, req.Currency, rc)
-		if session != nil {
-			rc.DisconnectPlayer(session.ID) // We send a message to disconnect the previous websocket connection.
-			rc.RemoveSession(session.ID)    // If the session exists, from a previous token, we remove the session
-			session.Token = req.Token       // We just update the token.
-			rc.AddSession(session)          // we update the session in redis.
-		} else {
-			session, err = generatePlayerSession( // then we generate a new session.
-				op,
-				req.Token,
-				logInResponse.Data.Username,
-				logInResponse.Data.Currency,
-				rc,
-			)
-			if err != nil {
-				respondWithError(w, "Failed to generate session", err)
-				return
-			}
-			go handleSaveSession(session, pgs)
-		}
+	session, err := launchSession(req, op, logInResponse.Data.Username, logInResponse.Data.Currency, rc, pgs)
+	if err != nil {
+		respondWithError(w, "Failed to generate session", err)
+		return
 	}
 	gameURL, err := generateGameURL(op.GameBaseUrl, req.Token, session.ID, logInResponse.Data.Currency)
 	if err != nil {
@@ -59,40 +42,47 @@ func (m *SokkerDuelModule) HandleGameLaunch(w http.ResponseWriter, r *http.Reque
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-func (m *SokkerDuelModule) HandleFetchWalletBalance(s models.Session, rc *redisdb.RedisClient) (int64, error) {
-	logInResponse, err := walletrequests.SokkerDuelGetWallet(s.OperatorBaseUrl, s.Token)
+func (m *SokkerDuelModule) HandleFetchWalletBalance(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, s models.Session) (models.Money, error) {
+	logInResponse, err := walletrequests.SokkerDuelGetWallet(s.OperatorBaseUrl, s.Token, sessionSigning(rc, pgs, s))
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch wallet: %v", err)
+		return models.Money{}, fmt.Errorf("failed to fetch wallet: %v", err)
 	}
-	return int64(logInResponse.Data.Balance), nil
+	// The wallet balance is already in minor units, unlike the ones of the transactions.
+	return models.NewMoney(int64(logInResponse.Data.Balance), s.Currency), nil
 }
 
-func (m *SokkerDuelModule) HandlePostBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue int64, gameID string) (int64, error) {
+func (m *SokkerDuelModule) HandlePostBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error) {
 	// Validate input parameters
-	if betValue <= 0 {
-		return -1, fmt.Errorf("invalid bet value: %d", betValue)
+	if betValue.Amount <= 0 {
+		return models.Money{}, fmt.Errorf("invalid bet value: %v", betValue)
+	}
+	if betValue.Currency != session.Currency {
+		return models.Money{}, fmt.Errorf("bet currency %s doesn't match the session currency %s", betValue.Currency, session.Currency)
 	}
 	if gameID == "" {
-		return -1, fmt.Errorf("empty game ID")
+		return models.Money{}, fmt.Errorf("empty game ID")
 	}
 	if session.ID == "" {
-		return -1, fmt.Errorf("invalid session")
+		return models.Money{}, fmt.Errorf("invalid session")
 	}
 
 	betData := models.SokkerDuelBet{
 		OperatorGameName: session.OperatorIdentifier.GameName,
 		Currency:         session.Currency,
-		Amount:           betValue,
+		Amount:           betValue.Amount,
 		TransactionID:    models.GenerateUUID(),
 		RoundID:          gameID,
 	}
+	// The bet goes to the ledger first, if we can't keep track of it we don't take the money.
+	if err := openLedgerEntry(pgs, newLedgerEntry(session, betData.TransactionID, "bet", betValue, gameID, betData)); err != nil {
+		return models.Money{}, fmt.Errorf("failed to save bet to the ledger: %v", err)
+	}
 	// Make API call - now we know it either returns success response or error
-	betResponse, err := walletrequests.SokkerDuelPostBet(session, betData)
+	betResponse, err := walletrequests.SokkerDuelPostBet(session, betData, sessionSigning(rc, pgs, session))
+	// A failed bet is not sent again, the room is closed and the game never starts.
+	closeLedgerEntry(pgs, betData.TransactionID, betResponse, err, false)
 	if err != nil {
-		if saveErr := saveFailedBetTransaction(pgs, session, betData, err, gameID); saveErr != nil {
-			return -1, fmt.Errorf("API error: %v | Transaction save error: %v", err, saveErr)
-		}
-		return -1, err // Return original API error
+		return models.Money{}, err // Return original API error
 	}
 
 	// At this point, we're guaranteed betResponse is valid and status="success"
@@ -101,8 +91,8 @@ func (m *SokkerDuelModule) HandlePostBet(pgs *postgrescli.PostgresCli, rc *redis
 		ID:          betData.TransactionID,
 		SessionID:   session.ID,
 		Type:        "bet",
-		Amount:      betValue,
-		Currency:    session.Currency,
+		Amount:      betValue.Amount,
+		Currency:    betValue.Currency,
 		Platform:    "sokkerpro",
 		Operator:    "SokkerDuel",
 		Client:      session.PlayerName,
@@ -117,50 +107,53 @@ func (m *SokkerDuelModule) HandlePostBet(pgs *postgrescli.PostgresCli, rc *redis
 	// Update session
 	session.ExtractID = betResponse.Data.ExtractID
 	if err := rc.AddSession(&session); err != nil {
-		return -1, fmt.Errorf("failed to save session: %v", err)
+		return models.Money{}, fmt.Errorf("failed to save session: %v", err)
 	}
 	// Parse balance (we know it exists from API contract)
-	fbalance, err := strconv.ParseFloat(betResponse.Data.Balance, 64)
+	balance, err := models.ParseBalance(betResponse.Data.Balance, session.Currency)
 	if err != nil {
-		return -1, fmt.Errorf("failed to parse balance: %v", err)
+		return models.Money{}, fmt.Errorf("failed to parse balance: %v", err)
 	}
-	return int64(fbalance * 100), nil
+	return balance, nil
 }
 
-func (m *SokkerDuelModule) HandlePostWin(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, winValue int64, gameID string) (int64, int64, error) {
+func (m *SokkerDuelModule) HandlePostWin(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, winValue models.Money, gameID string) (models.Money, models.Money, error) {
 	// Validate input parameters
-	if winValue <= 0 {
-		return -1, -1, fmt.Errorf("invalid win value: %d", winValue)
+	if winValue.Amount <= 0 {
+		return models.Money{}, models.Money{}, fmt.Errorf("invalid win value: %v", winValue)
 	}
 	if gameID == "" {
-		return -1, -1, fmt.Errorf("empty game ID")
+		return models.Money{}, models.Money{}, fmt.Errorf("empty game ID")
 	}
 	if session.ID == "" {
-		return -1, -1, fmt.Errorf("invalid session")
+		return models.Money{}, models.Money{}, fmt.Errorf("invalid session")
 	}
 	var winnings = CalculateWinAmount(winValue, session.OperatorIdentifier.WinFactor)
 	winData := models.SokkerDuelWin{
 		OperatorGameName: session.OperatorIdentifier.GameName,
 		Currency:         session.Currency,
-		Amount:           winnings,
+		Amount:           winnings.Amount,
 		TransactionID:    models.GenerateUUID(),
 		RoundID:          gameID,
 		ExtractID:        session.ExtractID,
 	}
+	// The winner gets paid even if the ledger is down, it just won't be sent again if the post fails.
+	if err := openLedgerEntry(pgs, newLedgerEntry(session, winData.TransactionID, "win", winnings, gameID, winData)); err != nil {
+		log.Printf("[SokkerDuel] - failed to save win %s to the ledger: %v", winData.TransactionID, err)
+	}
 	// Make API call - guaranteed to return either success response or error
-	winResponse, err := walletrequests.SokkerDuelPostWin(session, winData)
+	winResponse, err := walletrequests.SokkerDuelPostWin(session, winData, sessionSigning(rc, pgs, session))
+	closeLedgerEntry(pgs, winData.TransactionID, winResponse, err, true)
 	if err != nil {
-		// Save failed transaction before returning
-		go saveFailedWinTransaction(pgs, session, winData, err, gameID)
-		return -1, winnings, err // Return original API error
+		return models.Money{}, winnings, err // Return original API error, the ledgerworker sends it again.
 	}
 	// At this point, we're guaranteed winResponse is valid and status="success"
 	trans := models.Transaction{
 		ID:          winData.TransactionID,
 		SessionID:   session.ID,
 		Type:        "win",
-		Amount:      winnings,
-		Currency:    session.Currency,
+		Amount:      winnings.Amount,
+		Currency:    winnings.Currency,
 		Platform:    "sokkerpro",
 		Operator:    "SokkerDuel",
 		Client:      session.PlayerName,
@@ -174,12 +167,219 @@ func (m *SokkerDuelModule) HandlePostWin(pgs *postgrescli.PostgresCli, rc *redis
 	// Reset ExtractID in session
 	session.ExtractID = 0
 	if err := rc.AddSession(&session); err != nil {
-		return -1, -1, fmt.Errorf("failed to save session: %v", err)
+		return models.Money{}, models.Money{}, fmt.Errorf("failed to save session: %v", err)
 	}
 	// Parse balance (we know it exists from API contract)
-	fbalance, err := strconv.ParseFloat(winResponse.Data.Balance, 64)
+	balance, err := models.ParseBalance(winResponse.Data.Balance, session.Currency)
+	if err != nil {
+		return models.Money{}, models.Money{}, fmt.Errorf("failed to parse balance: %v", err)
+	}
+	return balance, winnings, nil
+}
+
+// HandlePostRefund gives the player their bet back. SokkerDuel has no refund endpoint, so the bet value is
+// posted as a win against the bet extract, and saved on our side as a refund transaction.
+func (m *SokkerDuelModule) HandlePostRefund(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error) {
+	// Validate input parameters
+	if betValue.Amount <= 0 {
+		return models.Money{}, fmt.Errorf("invalid refund value: %v", betValue)
+	}
+	if gameID == "" {
+		return models.Money{}, fmt.Errorf("empty game ID")
+	}
+	if session.ID == "" {
+		return models.Money{}, fmt.Errorf("invalid session")
+	}
+	refundData := models.SokkerDuelWin{
+		OperatorGameName: session.OperatorIdentifier.GameName,
+		Currency:         session.Currency,
+		Amount:           betValue.Amount,
+		TransactionID:    models.GenerateUUID(),
+		RoundID:          gameID,
+		ExtractID:        session.ExtractID,
+	}
+	if err := openLedgerEntry(pgs, newLedgerEntry(session, refundData.TransactionID, "refund", betValue, gameID, refundData)); err != nil {
+		log.Printf("[SokkerDuel] - failed to save refund %s to the ledger: %v", refundData.TransactionID, err)
+	}
+	refundResponse, err := walletrequests.SokkerDuelPostWin(session, refundData, sessionSigning(rc, pgs, session))
+	closeLedgerEntry(pgs, refundData.TransactionID, refundResponse, err, true)
+	if err != nil {
+		return models.Money{}, err
+	}
+	trans := models.Transaction{
+		ID:          refundData.TransactionID,
+		SessionID:   session.ID,
+		Type:        "refund",
+		Amount:      betValue.Amount,
+		Currency:    betValue.Currency,
+		Platform:    "sokkerpro",
+		Operator:    "SokkerDuel",
+		Client:      session.PlayerName,
+		Game:        session.OperatorIdentifier.GameName,
+		RoundID:     gameID,
+		Timestamp:   time.Now(),
+		Status:      refundResponse.Status,
+		Description: string(mustMarshal(refundResponse)),
+	}
+	go pgs.SaveTransaction(trans)
+	session.ExtractID = 0
+	if err := rc.AddSession(&session); err != nil {
+		return models.Money{}, fmt.Errorf("failed to save session: %v", err)
+	}
+	balance, err := models.ParseBalance(refundResponse.Data.Balance, session.Currency)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to parse balance: %v", err)
+	}
+	return balance, nil
+}
+
+// HandleRollbackBet gives the player back a bet of a game that never started. Like the refund it is posted as
+// a win against the extract of the bet, the ledger bet has the extract in the answer of the operator.
+func (m *SokkerDuelModule) HandleRollbackBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error) {
+	if gameID == "" {
+		return models.Money{}, fmt.Errorf("empty game ID")
+	}
+	if session.ID == "" {
+		return models.Money{}, fmt.Errorf("invalid session")
+	}
+	bet, err := pgs.FetchLedgerBet(session.ID, gameID)
+	if err != nil {
+		return models.Money{}, err
+	}
+	if bet.Amount != betValue.Amount || bet.Currency != betValue.Currency {
+		return models.Money{}, fmt.Errorf("rollback value %v doesn't match the bet %s of %d %s", betValue, bet.TransactionID, bet.Amount, bet.Currency)
+	}
+	if bet.Status == models.LedgerUnknown {
+		// The rollback is a win against the extract of the bet, without the answer of the operator there is no
+		// extract, and a win for a bet that was never taken would pay the player. It is left for the
+		// reconciliation.
+		err := fmt.Errorf("bet %s got no answer of the operator, it can't be rolled back without its extract", bet.TransactionID)
+		if markErr := pgs.MarkLedgerFailed(bet.TransactionID, err, false); markErr != nil {
+			log.Printf("[SokkerDuel] - failed to mark bet %s as failed: %v", bet.TransactionID, markErr)
+		}
+		return models.Money{}, err
+	}
+	extractID := session.ExtractID
+	var betResponse models.SokkerDuelBetResponse
+	if err := json.Unmarshal(bet.Response, &betResponse); err == nil && betResponse.Data.ExtractID != 0 {
+		extractID = betResponse.Data.ExtractID
+	}
+	rollbackData := models.SokkerDuelWin{
+		OperatorGameName: session.OperatorIdentifier.GameName,
+		Currency:         session.Currency,
+		Amount:           bet.Amount,
+		TransactionID:    models.GenerateUUID(),
+		RoundID:          gameID,
+		ExtractID:        extractID,
+	}
+	if err := openLedgerEntry(pgs, newLedgerEntry(session, rollbackData.TransactionID, "rollback", betValue, gameID, rollbackData)); err != nil {
+		log.Printf("[SokkerDuel] - failed to save rollback %s to the ledger: %v", rollbackData.TransactionID, err)
+	}
+	rollbackResponse, err := walletrequests.SokkerDuelPostWin(session, rollbackData, sessionSigning(rc, pgs, session))
+	closeLedgerEntry(pgs, rollbackData.TransactionID, rollbackResponse, err, true)
+	if err != nil {
+		return models.Money{}, err
+	}
+	if err := pgs.MarkLedgerBetRolledBack(session.ID, gameID); err != nil {
+		log.Printf("[SokkerDuel] - failed to mark bet %s as rolled back: %v", bet.TransactionID, err)
+	}
+	trans := models.Transaction{
+		ID:          rollbackData.TransactionID,
+		SessionID:   session.ID,
+		Type:        "rollback",
+		Amount:      bet.Amount,
+		Currency:    bet.Currency,
+		Platform:    "sokkerpro",
+		Operator:    "SokkerDuel",
+		Client:      session.PlayerName,
+		Game:        session.OperatorIdentifier.GameName,
+		RoundID:     gameID,
+		Timestamp:   time.Now(),
+		Status:      rollbackResponse.Status,
+		Description: string(mustMarshal(rollbackResponse)),
+	}
+	go pgs.SaveTransaction(trans)
+	session.ExtractID = 0
+	if err := rc.AddSession(&session); err != nil {
+		return models.Money{}, fmt.Errorf("failed to save session: %v", err)
+	}
+	balance, err := models.ParseBalance(rollbackResponse.Data.Balance, session.Currency)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to parse balance: %v", err)
+	}
+	return balance, nil
+}
+
+// HandleRetryTransaction posts a ledger entry again, with the same transaction ID so the operator doesn't take
+// or pay it twice. Bets are posted to the bet endpoint, wins, refunds and rollbacks to the win one.
+func (m *SokkerDuelModule) HandleRetryTransaction(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, entry models.LedgerEntry) (models.Money, error) {
+	// The session in redis has the latest token, the one saved with the entry is used once it expires.
+	session, err := rc.GetSessionByID(entry.SessionID)
+	live := err == nil && session != nil
+	if !live {
+		session, err = unmarshalLedgerSession(entry)
+		if err != nil {
+			return models.Money{}, err
+		}
+	}
+
+	var status, description, answerBalance string
+	switch entry.Type {
+	case "bet":
+		var betData models.SokkerDuelBet
+		if err := json.Unmarshal(entry.Payload, &betData); err != nil {
+			return models.Money{}, fmt.Errorf("failed to unmarshal bet payload: %v", err)
+		}
+		betResponse, err := walletrequests.SokkerDuelPostBet(*session, betData, sessionSigning(rc, pgs, *session))
+		if err != nil {
+			return models.Money{}, err
+		}
+		session.ExtractID = betResponse.Data.ExtractID
+		status, description, answerBalance = betResponse.Status, string(mustMarshal(betResponse)), betResponse.Data.Balance
+	case "win", "refund", "rollback":
+		var winData models.SokkerDuelWin
+		if err := json.Unmarshal(entry.Payload, &winData); err != nil {
+			return models.Money{}, fmt.Errorf("failed to unmarshal %s payload: %v", entry.Type, err)
+		}
+		winResponse, err := walletrequests.SokkerDuelPostWin(*session, winData, sessionSigning(rc, pgs, *session))
+		if err != nil {
+			return models.Money{}, err
+		}
+		session.ExtractID = 0
+		status, description, answerBalance = winResponse.Status, string(mustMarshal(winResponse)), winResponse.Data.Balance
+		if entry.Type == "rollback" {
+			if err := pgs.MarkLedgerBetRolledBack(entry.SessionID, entry.RoundID); err != nil {
+				log.Printf("[SokkerDuel] - failed to mark bet of round %s as rolled back: %v", entry.RoundID, err)
+			}
+		}
+	default:
+		return models.Money{}, fmt.Errorf("invalid ledger entry type: %s", entry.Type)
+	}
+
+	trans := models.Transaction{
+		ID:          entry.TransactionID,
+		SessionID:   entry.SessionID,
+		Type:        entry.Type,
+		Amount:      entry.Amount,
+		Currency:    entry.Currency,
+		Platform:    "sokkerpro",
+		Operator:    "SokkerDuel",
+		Client:      session.PlayerName,
+		Game:        entry.Game,
+		RoundID:     entry.RoundID,
+		Timestamp:   time.Now(),
+		Status:      status,
+		Description: description,
+	}
+	go pgs.SaveTransaction(trans)
+	if live {
+		if err := rc.AddSession(session); err != nil {
+			return models.Money{}, fmt.Errorf("failed to save session: %v", err)
+		}
+	}
+	balance, err := models.ParseBalance(answerBalance, entry.Currency)
 	if err != nil {
-		return -1, -1, fmt.Errorf("failed to parse balance: %v", err)
+		return models.Money{}, fmt.Errorf("failed to parse balance: %v", err)
 	}
-	return int64(fbalance * 100), winnings, nil
+	return balance, nil
 }
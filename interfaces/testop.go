@@ -40,18 +40,18 @@ func (m *TestModule) HandleGameLaunch(w http.ResponseWriter, r *http.Request, re
 	respondWithJSON(w, http.StatusOK, response)
 }
 
-func (m *TestModule) HandleFetchWalletBalance(s models.Session, rc *redisdb.RedisClient) (int64, error) {
-	return int64(10000), nil
+func (m *TestModule) HandleFetchWalletBalance(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, s models.Session) (models.Money, error) {
+	return models.NewMoney(10000, s.Currency), nil
 }
 
-func (m *TestModule) HandlePostBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue int64, gameID string) (int64, error) {
+func (m *TestModule) HandlePostBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error) {
 
 	trans := models.Transaction{
 		ID:          models.GenerateUUID(),
 		SessionID:   session.ID,
 		Type:        "bet",
-		Amount:      betValue,
-		Currency:    session.Currency,
+		Amount:      betValue.Amount,
+		Currency:    betValue.Currency,
 		Platform:    "sokkerpro",
 		Operator:    "SokkerDuel",
 		Client:      session.PlayerName,
@@ -62,15 +62,16 @@ func (m *TestModule) HandlePostBet(pgs *postgrescli.PostgresCli, rc *redisdb.Red
 		Description: "Mock transaction",
 	}
 	go pgs.SaveTransaction(trans)
-	return 100, nil
+	return models.NewMoney(100, betValue.Currency), nil
 }
-func (m *TestModule) HandlePostWin(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, winValue int64, gameID string) (int64, int64, error) {
+func (m *TestModule) HandlePostWin(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, winValue models.Money, gameID string) (models.Money, models.Money, error) {
+	winnings := CalculateWinAmount(winValue, session.OperatorIdentifier.WinFactor)
 	trans := models.Transaction{
 		ID:          models.GenerateUUID(),
 		SessionID:   session.ID,
 		Type:        "win",
-		Amount:      99,
-		Currency:    session.Currency,
+		Amount:      winnings.Amount,
+		Currency:    winnings.Currency,
 		Platform:    "sokkerpro",
 		Operator:    "SokkerDuel",
 		Client:      session.PlayerName,
@@ -81,5 +82,50 @@ func (m *TestModule) HandlePostWin(pgs *postgrescli.PostgresCli, rc *redisdb.Red
 		Description: "Mock transaction",
 	}
 	go pgs.SaveTransaction(trans)
-	return 199, 99, nil
+	return models.NewMoney(100+winnings.Amount, winnings.Currency), winnings, nil
+}
+
+func (m *TestModule) HandlePostRefund(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error) {
+	trans := models.Transaction{
+		ID:          models.GenerateUUID(),
+		SessionID:   session.ID,
+		Type:        "refund",
+		Amount:      betValue.Amount,
+		Currency:    betValue.Currency,
+		Platform:    "sokkerpro",
+		Operator:    "SokkerDuel",
+		Client:      session.PlayerName,
+		Game:        session.OperatorIdentifier.GameName,
+		RoundID:     gameID,
+		Timestamp:   time.Now(),
+		Status:      "200",
+		Description: "Mock transaction",
+	}
+	go pgs.SaveTransaction(trans)
+	return models.NewMoney(100+betValue.Amount, betValue.Currency), nil
+}
+
+func (m *TestModule) HandleRollbackBet(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, session models.Session, betValue models.Money, gameID string) (models.Money, error) {
+	trans := models.Transaction{
+		ID:          models.GenerateUUID(),
+		SessionID:   session.ID,
+		Type:        "rollback",
+		Amount:      betValue.Amount,
+		Currency:    betValue.Currency,
+		Platform:    "sokkerpro",
+		Operator:    "SokkerDuel",
+		Client:      session.PlayerName,
+		Game:        session.OperatorIdentifier.GameName,
+		RoundID:     gameID,
+		Timestamp:   time.Now(),
+		Status:      "200",
+		Description: "Mock transaction",
+	}
+	go pgs.SaveTransaction(trans)
+	return models.NewMoney(100+betValue.Amount, betValue.Currency), nil
+}
+
+// The test operator posts nothing, so there is nothing to send again.
+func (m *TestModule) HandleRetryTransaction(pgs *postgrescli.PostgresCli, rc *redisdb.RedisClient, entry models.LedgerEntry) (models.Money, error) {
+	return models.NewMoney(100, entry.Currency), nil
 }
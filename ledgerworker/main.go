@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Lavizord/checkers-server/config"
+	"github.com/Lavizord/checkers-server/interfaces"
+	"github.com/Lavizord/checkers-server/logger"
+	"github.com/Lavizord/checkers-server/messages"
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/Lavizord/checkers-server/postgrescli"
+	"github.com/Lavizord/checkers-server/redisdb"
+	"github.com/Lavizord/checkers-server/walletrequests"
+)
+
+// Entries taken from the ledger on each tick.
+const claimLimit = 50
+
+// A bet this old without a saved game belongs to a game that crashed or never started, it is rolled back.
+const orphanBetAge = time.Hour
+
+var redisClient *redisdb.RedisClient
+var postgresClient *postgrescli.PostgresCli
+
+func init() {
+	config.LoadConfig()
+	redisConData := config.Cfg.Redis
+	client, err := redisdb.NewRedisClient(redisConData.Addr, redisConData.User, redisConData.Password, redisConData.Tls)
+	if err != nil {
+		logger.Default.Fatalf("[LedgerWorker][Redis] Error initializing Redis client: %v", err)
+	}
+	redisClient = client
+
+	sqlcliente, err := postgrescli.NewPostgresCli(
+		config.Cfg.Postgres.User,
+		config.Cfg.Postgres.Password,
+		config.Cfg.Postgres.DBName,
+		config.Cfg.Postgres.Host,
+		config.Cfg.Postgres.Port,
+		config.Cfg.Postgres.Ssl,
+	)
+	if err != nil {
+		logger.Default.Fatalf("[LedgerWorker][PostgreSQL] Error initializing POSTGRES client: %v", err)
+	}
+	postgresClient = sqlcliente
+	logger.Default.Infof("ledgerworker initialized...")
+}
+
+func main() {
+	logger.Default.Infof("ledgerworker starting up...")
+	timer := config.Cfg.Services["ledgerworker"].Timer
+	if timer <= 0 {
+		timer = 10
+	}
+	ticker := time.NewTicker(time.Duration(timer) * time.Second)
+	defer func() {
+		ticker.Stop()
+		if redisClient != nil {
+			redisClient.CloseRedisClient()
+		}
+	}()
+
+	for range ticker.C {
+		entries, err := postgresClient.ClaimLedgerRetries(claimLimit)
+		if err != nil {
+			logger.Default.Errorf("failed to claim ledger entries, with err: %v", err)
+			continue
+		}
+		for _, entry := range entries {
+			retryEntry(entry)
+		}
+
+		bets, err := postgresClient.FetchOrphanBets(orphanBetAge, claimLimit)
+		if err != nil {
+			logger.Default.Errorf("failed to fetch orphan bets, with err: %v", err)
+			continue
+		}
+		for _, bet := range bets {
+			rollbackOrphanBet(bet)
+		}
+	}
+}
+
+// rollbackOrphanBet gives back a bet whose game was never saved, unless the game is still going on.
+func rollbackOrphanBet(bet models.LedgerEntry) {
+	if game, err := redisClient.GetGame(bet.RoundID); err == nil && game != nil {
+		return
+	}
+	module := interfaces.GetOperatorModule(bet.Operator)
+	session, err := redisClient.GetSessionByID(bet.SessionID)
+	if err != nil || session == nil {
+		session = &models.Session{}
+		if err := json.Unmarshal(bet.Session, session); err != nil {
+			logger.Default.Errorf("failed to unmarshal the session of orphan bet %s, with err: %v", bet.TransactionID, err)
+			return
+		}
+	}
+	newBalance, err := module.HandleRollbackBet(postgresClient, redisClient, *session, models.NewMoney(bet.Amount, bet.Currency), bet.RoundID)
+	if err != nil {
+		// The rollback is in the ledger now, the retries pick it up from here.
+		logger.Default.Warnf("rollback of orphan bet %s (round %s) failed, with err: %v", bet.TransactionID, bet.RoundID, err)
+		return
+	}
+	logger.Default.Infof("orphan bet %s (round %s) rolled back", bet.TransactionID, bet.RoundID)
+	msg, _ := messages.GenerateBalanceUpdateMessage(newBalance)
+	redisClient.PublishToPlayerID(bet.SessionID, string(msg))
+}
+
+// retryEntry sends the entry again through its operator module and saves the outcome.
+func retryEntry(entry models.LedgerEntry) {
+	module := interfaces.GetOperatorModule(entry.Operator)
+	newBalance, err := module.HandleRetryTransaction(postgresClient, redisClient, entry)
+	if err != nil {
+		// A bet that failed is not sent again, it only gets here when its first post was left hanging.
+		retry := entry.Type != "bet"
+		logger.Default.Warnf("retry %d of ledger entry %s (%s, round %s) failed, with err: %v", entry.Attempts, entry.TransactionID, entry.Type, entry.RoundID, err)
+		if !retry && errors.Is(err, walletrequests.ErrNoAnswer) {
+			// The operator may have taken the bet, the orphan bets sweep rolls it back.
+			if err := postgresClient.MarkLedgerUnknown(entry.TransactionID, err); err != nil {
+				logger.Default.Errorf("failed to mark ledger entry %s as unknown, with err: %v", entry.TransactionID, err)
+			}
+			return
+		}
+		if err := postgresClient.MarkLedgerFailed(entry.TransactionID, err, retry); err != nil {
+			logger.Default.Errorf("failed to mark ledger entry %s as failed, with err: %v", entry.TransactionID, err)
+		}
+		return
+	}
+	if err := postgresClient.MarkLedgerConfirmed(entry.TransactionID, nil); err != nil {
+		logger.Default.Errorf("failed to mark ledger entry %s as confirmed, with err: %v", entry.TransactionID, err)
+	}
+	logger.Default.Infof("ledger entry %s (%s, round %s) confirmed after %d attempts", entry.TransactionID, entry.Type, entry.RoundID, entry.Attempts)
+
+	// The player and the session share the same ID, if the player is online they get the new balance.
+	msg, _ := messages.GenerateBalanceUpdateMessage(newBalance)
+	redisClient.PublishToPlayerID(entry.SessionID, string(msg))
+}
@@ -17,14 +17,34 @@ var validCommands = map[string]CommandInfo{
 	"ready_queue": {Type: ClientCommand}, // This allows the player to issue a ready when in a room. Opponent receives an opponent_ready message
 	"leave_queue": {Type: ClientCommand}, // This allows the player to leave the queue.
 	"leave_room":  {Type: ClientCommand}, // This allows the player to leave the room. The opponent gets placed in the Queue, and received a ready_queue message
-	"leave_game":  {Type: ClientCommand}, // This allows the player to leave the game. The opponent wins the game
+	"leave_game":  {Type: ClientCommand}, // This allows the player to leave the game. The opponent wins the game, or the game is aborted if there are no moves.
 	"ping":        {Type: ClientCommand},
 	"pong":        {Type: ServerCommand},
 	"join_room":   {Type: ClientCommand}, // DEPRECATED
 	"create_room": {Type: ClientCommand}, // DEPRECATED
 
-	"move_piece":   {Type: ClientCommand}, // This is issued by the cliente to trigger the movement of a piece.
-	"invalid_move": {Type: ServerCommand}, // This is issued by the cliente to trigger the movement of a piece.
+	"resign":         {Type: ClientCommand}, // The player asks to resign, the server answers with resign_confirmation.
+	"confirm_resign": {Type: ClientCommand}, // Confirms the resign, the opponent wins the game. If there are no moves yet the game is aborted.
+	"abort_game":     {Type: ClientCommand}, // Aborts a game where no move was made yet, both bets are refunded.
+
+	"spectate":        {Type: ClientCommand}, // Spectator socket only, starts watching the game with the id in the value.
+	"stop_spectating": {Type: ClientCommand}, // Spectator socket only, stops watching the game.
+
+	"move_piece":    {Type: ClientCommand}, // This is issued by the cliente to trigger the movement of a piece.
+	"offer_draw":    {Type: ClientCommand}, // The player offers a draw to the opponent, the offer lasts until answered or the opponent moves.
+	"respond_draw":  {Type: ClientCommand}, // The player answers a draw offer, value true accepts and ends the game as a draw.
+	"invalid_move":  {Type: ServerCommand}, // This is issued by the cliente to trigger the movement of a piece.
+	"move_accepted": {Type: ServerCommand}, // Sent to the player when the move with the seq was applied, or was a resend of an applied move.
+	"move_rejected": {Type: ServerCommand}, // Sent to the player when the move with the seq was refused, with the reason code.
+
+	"request_takeback": {Type: ClientCommand}, // Asks the opponent to take back the last move of the player, only in games without a bet.
+	"accept_takeback":  {Type: ClientCommand}, // Answers a takeback request, value true puts the game back at the start of the last turn of the opponent.
+
+	"premove":           {Type: ClientCommand}, // A move_piece value queued during the opponent's turn, played right after it if still legal. Replaces the previous one.
+	"cancel_premove":    {Type: ClientCommand}, // Drops the queued premove.
+	"premove_set":       {Type: ServerCommand}, // The premove was queued, with the move.
+	"premove_cancelled": {Type: ServerCommand}, // The premove was dropped on request.
+	"premove_dropped":   {Type: ServerCommand}, // The premove was not legal on the new position, or the game ended, with the seq and reason.
 
 	"message":                    {Type: ServerCommand}, // issues when a player connects.
 	"connected":                  {Type: ServerCommand}, // issues when a player connects.
@@ -38,10 +58,17 @@ var validCommands = map[string]CommandInfo{
 	"opponent_disconnected_game": {Type: ServerCommand}, // This is a message sent when a player disconnects from a game.
 	"game_start":                 {Type: ServerCommand}, // This is the message sent with data for the game start.
 	"game_reconnect":             {Type: ServerCommand}, // The same as game_start, but for a reconnect.
+	"spectate_start":             {Type: ServerCommand}, // The same as game_start, sent to a spectator when it starts watching a game.
+	"featured_games":             {Type: ServerCommand}, // Lobby feed with some of the games in progress, see models.FeaturedGames.
 	"game_timer":                 {Type: ServerCommand}, // This is a timer sent to both players in a game.
 	"game_over":                  {Type: ServerCommand}, // Sent when server detects a game over.
 	"turn_switch":                {Type: ServerCommand}, // Sent when the server detects a turn switch.
 	"balance_update":             {Type: ServerCommand}, // Sent when there is a change to a players money.
+	"draw_offered":               {Type: ServerCommand}, // Sent to the opponent of the player that offered a draw.
+	"draw_declined":              {Type: ServerCommand}, // Sent to the player that offered a draw, when the opponent declines it.
+	"takeback_requested":         {Type: ServerCommand}, // Sent to the opponent of the player that asked for a takeback.
+	"takeback_declined":          {Type: ServerCommand}, // Sent to the player that asked for a takeback, when the opponent declines it. On accept both get board_state.
+	"resign_confirmation":        {Type: ServerCommand}, // Asks the player to confirm the resign, with the seconds left to do it.
 
 	"game_info": {Type: BroadcastCommand}, // Sent with generic game info to feed the clientes.
 }
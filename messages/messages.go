@@ -23,10 +23,11 @@ type OpponentReady struct {
 }
 
 type GameConnectedMessage struct {
-	PlayerID   string  `json:"player_id"`
-	PlayerName string  `json:"player_name"`
-	Money      float64 `json:"money"`
-	Status     string  `json:"status"`
+	PlayerID   string      `json:"player_id"`
+	PlayerName string      `json:"player_name"`
+	Money      json.Number `json:"money"`
+	Currency   string      `json:"currency"`
+	Status     string      `json:"status"`
 }
 
 // This one missed the json code, the FE is already working wth this... dont CHANGE the ones that dont have it.
@@ -36,7 +37,8 @@ type GameStartMessage struct {
 	MaxTimer        int `json:"max_timer"`
 	CurrentPlayerID string
 	GamePlayers     []GamePlayerResponse
-	WinFactor       float64 `json:"win_factor"`
+	WinFactor       float64             `json:"win_factor"`
+	TimeControl     *models.TimeControl `json:"time_control,omitempty"`
 }
 
 type GameUpdatetMessage struct {
@@ -50,11 +52,23 @@ type GameTimer struct {
 	CurrentPlayerID string `json:"current_player_id"`
 }
 
+type MoveAccepted struct {
+	Seq       int64 `json:"seq"`
+	Turn      int   `json:"turn"`      // The turn of the game after the move.
+	Duplicate bool  `json:"duplicate"` // The move was already applied, this was a resend.
+}
+
+type MoveRejected struct {
+	Seq    int64  `json:"seq"`
+	Reason string `json:"reason"` // One of the models.MoveReject reasons.
+}
+
 type GameOver struct {
 	Reason   string             `json:"reason"`
 	Winner   GamePlayerResponse `json:"winner"`
+	IsDraw   bool               `json:"is_draw"`
 	Turns    int                `json:"turns"`
-	Winnings float64            `json:"winnings"`
+	Winnings json.Number        `json:"winnings"`
 	GameTime time.Duration      `json:"game_time"`
 }
 
@@ -122,13 +136,19 @@ func ParseMessage(msgBytes []byte) (*Message[json.RawMessage], error) {
 			return nil, fmt.Errorf("[Message Parser] invalid value format for %s: %w", msg.Command, err)
 		}
 
-	case "ready_room":
+	case "ready_room", "accept_takeback":
 		var value bool
 		if err := json.Unmarshal(msg.Value, &value); err != nil {
 			return nil, fmt.Errorf("[Message Parser] invalid value format for %s: %w", msg.Command, err)
 		}
 		return msg, nil
 
+	case "spectate":
+		var value string
+		if err := json.Unmarshal(msg.Value, &value); err != nil || value == "" {
+			return nil, fmt.Errorf("[Message Parser] invalid value format for %s: %v", msg.Command, err)
+		}
+
 	case "game_info":
 		var queueNumbersResponse models.QueueNumbersResponse
 		if err := json.Unmarshal(msg.Value, &queueNumbersResponse); err != nil {
@@ -140,35 +160,39 @@ func ParseMessage(msgBytes []byte) (*Message[json.RawMessage], error) {
 	return msg, nil
 }
 
-func GenerateConnectedMessage(player models.Player, balance int64) ([]byte, error) {
+func GenerateConnectedMessage(player models.Player, balance models.Money) ([]byte, error) {
 	connectInfo := GameConnectedMessage{
 		PlayerID:   player.ID,
 		PlayerName: player.Name,
-		Money:      (float64(balance) / 100.0),
+		Money:      balance.Number(),
+		Currency:   balance.Currency,
 		Status:     string(player.Status),
 	}
 	return NewMessage("connected", connectInfo)
 }
 
-func GeneratePairedMessage(player1, player2 *models.Player, roomID string, color int, winnings int64, timer int) ([]byte, error) {
+func GeneratePairedMessage(player1, player2 *models.Player, roomID string, color int, winnings models.Money, timer int, timeControl models.TimeControl) ([]byte, error) {
 	pairedValue := models.PairedValue{
 		Color:         color,
 		Opponent:      player2.Name,
 		RoomID:        roomID,
-		Winnings:      float64(winnings) / 100,
+		Winnings:      winnings.Number(),
 		Timer:         timer,
 		PlayerReady:   player1.Status == models.StatusInRoomReady,
 		OpponentReady: player2.Status == models.StatusInRoomReady,
 	}
+	if timeControl.IsSet() {
+		pairedValue.TimeControl = &timeControl
+	}
 	return NewMessage("paired", pairedValue)
 }
 
-func GeneratePairedMessageFromP2Name(p2Name, roomID string, playerReady, opponentReady bool, color int, winnings int64, timer int) ([]byte, error) {
+func GeneratePairedMessageFromP2Name(p2Name, roomID string, playerReady, opponentReady bool, color int, winnings models.Money, timer int) ([]byte, error) {
 	pairedValue := models.PairedValue{
 		Color:         color,
 		Opponent:      p2Name,
 		RoomID:        roomID,
-		Winnings:      float64(winnings) / 100,
+		Winnings:      winnings.Number(),
 		Timer:         timer,
 		PlayerReady:   playerReady,
 		OpponentReady: opponentReady,
@@ -181,11 +205,16 @@ func GenerateRoomCreatedMessage(room models.Room) ([]byte, error) {
 		ID:       room.ID,
 		Player:   room.Player1.Name,
 		Currency: room.Currency,
-		BetValue: room.BetValue,
+		BetValue: room.BetValue.Number(),
 	}
 	return NewMessage("room_created", roomValue)
 }
 
+// The balance goes as a number in major units, like it always did, written from the minor units.
+func GenerateBalanceUpdateMessage(balance models.Money) ([]byte, error) {
+	return NewMessage("balance_update", balance.Number())
+}
+
 func GenerateOpponentReadyMessage(isReady bool) ([]byte, error) {
 	opponentReady := OpponentReady{IsReady: isReady}
 	return NewMessage("opponent_ready", opponentReady)
@@ -240,7 +269,10 @@ func GenerateGameStartMessage(game models.Game) ([]byte, error) {
 		MaxTimer:        maxTimer,
 		CurrentPlayerID: game.CurrentPlayerID,
 		GamePlayers:     ConvertGamePlayersToResponse(game.Players),
-		WinFactor:       game.OperatorIdentifier.WinFactor,
+		WinFactor:       game.WinFactor(),
+	}
+	if game.TimeControl.IsSet() {
+		gamestart.TimeControl = &game.TimeControl
 	}
 	return NewMessage("game_start", gamestart)
 }
@@ -253,11 +285,32 @@ func GenerateGameBoardState(game models.Game) ([]byte, error) {
 		MaxTimer:        maxTimer,
 		CurrentPlayerID: game.CurrentPlayerID,
 		GamePlayers:     ConvertGamePlayersToResponse(game.Players),
-		WinFactor:       game.OperatorIdentifier.WinFactor,
+		WinFactor:       game.WinFactor(),
+	}
+	if game.TimeControl.IsSet() {
+		gamestart.TimeControl = &game.TimeControl
 	}
 	return NewMessage("board_state", gamestart)
 }
 
+// GenerateGameSpectateMessage is the board state sent to a spectator, it has no player data beyond what the
+// players see of each other.
+func GenerateGameSpectateMessage(game models.Game) ([]byte, error) {
+	maxTimer, _ := game.CalcGameMaxTimer()
+	gamestart := GameStartMessage{
+		GameID:          game.ID,
+		Board:           game.Board.GetGrid(),
+		MaxTimer:        maxTimer,
+		CurrentPlayerID: game.CurrentPlayerID,
+		GamePlayers:     ConvertGamePlayersToResponse(game.Players),
+		WinFactor:       game.WinFactor(),
+	}
+	if game.TimeControl.IsSet() {
+		gamestart.TimeControl = &game.TimeControl
+	}
+	return NewMessage("spectate_start", gamestart)
+}
+
 func GenerateGameReconnectMessage(game models.Game) ([]byte, error) {
 	maxTimer, _ := game.CalcGameMaxTimer()
 
@@ -267,11 +320,26 @@ func GenerateGameReconnectMessage(game models.Game) ([]byte, error) {
 		MaxTimer:        maxTimer,
 		CurrentPlayerID: game.CurrentPlayerID,
 		GamePlayers:     ConvertGamePlayersToResponse(game.Players),
-		WinFactor:       game.OperatorIdentifier.WinFactor,
+		WinFactor:       game.WinFactor(),
+	}
+	if game.TimeControl.IsSet() {
+		gamestart.TimeControl = &game.TimeControl
 	}
 	return NewMessage("game_reconnect", gamestart)
 }
 
+func GenerateMoveAcceptedMessage(seq int64, turn int, duplicate bool) ([]byte, error) {
+	return NewMessage("move_accepted", MoveAccepted{Seq: seq, Turn: turn, Duplicate: duplicate})
+}
+
+func GenerateMoveRejectedMessage(seq int64, reason string) ([]byte, error) {
+	return NewMessage("move_rejected", MoveRejected{Seq: seq, Reason: reason})
+}
+
+func GeneratePremoveDroppedMessage(seq int64, reason string) ([]byte, error) {
+	return NewMessage("premove_dropped", MoveRejected{Seq: seq, Reason: reason})
+}
+
 func GenerateGameTimerMessage(game models.Game, timer int) ([]byte, error) {
 	gamestart := GameTimer{
 		PlayerTimer:     timer,
@@ -280,18 +348,23 @@ func GenerateGameTimerMessage(game models.Game, timer int) ([]byte, error) {
 	return NewMessage("game_timer", gamestart)
 }
 
-func GenerateGameOverMessage(reason string, game models.Game, winnings int64) ([]byte, error) {
-	winner, err := game.GetGamePlayer(game.Winner)
-	if err != nil {
-		log.Printf("Error retrieving game winner player: %v\n", err)
-	}
-
+// GenerateGameOverMessage builds the game_over message, on a draw or an aborted game there is no winner
+// and winnings is the refunded bet.
+func GenerateGameOverMessage(reason string, game models.Game, winnings models.Money) ([]byte, error) {
 	gameover := GameOver{
 		Reason:   reason,
-		Winner:   ConvertGamePlayerToResponse(*winner),
+		IsDraw:   game.Winner == "" && reason != models.ReasonAborted,
 		Turns:    game.Turn,
 		GameTime: game.EndTime.Sub(game.StartTime),
-		Winnings: float64(winnings) / 100.0,
+		Winnings: winnings.Number(),
+	}
+	if game.Winner != "" {
+		winner, err := game.GetGamePlayer(game.Winner)
+		if err != nil {
+			log.Printf("Error retrieving game winner player: %v\n", err)
+			return nil, err
+		}
+		gameover.Winner = ConvertGamePlayerToResponse(*winner)
 	}
 	return NewMessage("game_over", gameover)
 }
@@ -306,6 +379,17 @@ func MustMarshal(v interface{}) json.RawMessage {
 	return bytes
 }
 
+// Games looked at for the featured feed, the most recent ones.
+const featuredGamesPool = 100
+
+func GenerateFeaturedGamesMessageBytes(redisClient *redisdb.RedisClient, gameName string, count int) ([]byte, error) {
+	page, err := redisClient.GetLiveGames(gameName, "", 0, featuredGamesPool)
+	if err != nil {
+		return nil, err
+	}
+	return NewMessage("featured_games", models.FeaturedGames(page.Games, count))
+}
+
 func GenerateGameInfoMessageBytes(redisClient *redisdb.RedisClient, gameName string) ([]byte, error) {
 	aggregates, err := redisClient.GetQueueNumberResponse(gameName)
 	if err != nil {
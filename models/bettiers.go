@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultBetTiers are the bets, in major units, of the operators with no tiers for the currency. The ones the
+// currency can't hold are left out. There is no free play by default, the operator has to opt in.
+var DefaultBetTiers = []string{"0.5", "1", "3", "5", "10", "25", "50", "100"}
+
+// BetTiers are the bets an operator offers in each currency, in major units like "0.5". Add "0" to offer the
+// free-play queue.
+type BetTiers map[string][]string
+
+// For is the list of bets in the currency.
+func (t BetTiers) For(currency string) []Money {
+	tiers, ok := t[strings.ToUpper(currency)]
+	if !ok {
+		tiers = DefaultBetTiers
+	}
+	bets := make([]Money, 0, len(tiers))
+	for _, tier := range tiers {
+		bet, err := ParseMoney(tier, currency)
+		if err != nil {
+			continue
+		}
+		bets = append(bets, bet)
+	}
+	return bets
+}
+
+// Validate checks every bet can be held by its currency, like no 0.5 bets in JPY.
+func (t BetTiers) Validate() error {
+	for currency, tiers := range t {
+		if len(tiers) == 0 {
+			return fmt.Errorf("no bet tiers for %s", currency)
+		}
+		for _, tier := range tiers {
+			bet, err := ParseMoney(tier, currency)
+			if err != nil {
+				return fmt.Errorf("invalid bet tier %q for %s: %v", tier, currency, err)
+			}
+			if bet.Amount < 0 {
+				return fmt.Errorf("negative bet tier %q for %s", tier, currency)
+			}
+		}
+	}
+	return nil
+}
+
+func (t BetTiers) IsValid(bet Money) bool {
+	for _, tier := range t.For(bet.Currency) {
+		if tier == bet {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFreePlayBet tells if the bet is the one of the free-play queue, whose games never touch the operator wallets.
+func IsFreePlayBet(bet Money) bool {
+	return bet.IsZero()
+}
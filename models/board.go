@@ -2,6 +2,9 @@ package models
 
 import (
 	"fmt"
+	"strconv"
+
+	"github.com/Lavizord/checkers-server/logger"
 )
 
 type Board interface {
@@ -15,20 +18,30 @@ type Board interface {
 	ValidateMove(move MoveInterface, piece PieceInterface) (bool, error)
 	MovePiece(from, to string) error
 	WasPieceKinged(pos string, piece PieceInterface) bool
+	// Standard notation of the position, FEN for chess and PDN FEN for damas, and the Zobrist hash.
+	ToFEN() string
+	LoadFEN(fen, blackID, whiteID string) error
+	Hash() uint64
 }
 
-func NewBoard(blackID, whiteID, boardtype, gameName string) Board {
+// NewBoard builds the board for the game, variant is only used by damas, see DamasVariants.
+// boardtype is one of the named boards, or a position in FEN (chess) or PDN FEN (damas).
+func NewBoard(blackID, whiteID, boardtype, gameName, variant string) Board {
 	switch gameName {
 	case "BatalhaDasDamas":
-		return NewDamasBoard(blackID, whiteID, boardtype)
+		return NewDamasBoard(blackID, whiteID, boardtype, variant)
 	case "BatalhaDoChess":
 		return NewChessBoard(blackID, whiteID, boardtype)
 	}
 	return nil
 }
 
-func NewDamasBoard(blackID, whiteID, boardtype string) Board {
-	board := &DamasBoard{Grid: make(map[string]*DamasPiece)}
+func NewDamasBoard(blackID, whiteID, boardtype, variant string) Board {
+	if _, err := GetDamasVariant(variant); err != nil {
+		logger.Default.Errorf("(NewDamasBoard) - %v, using the classic variant", err)
+		variant = ""
+	}
+	board := &DamasBoard{Grid: make(map[string]*DamasPiece), Variant: variant}
 	switch boardtype {
 	case "std-game":
 		board.GenerateInitialBoard(blackID, whiteID) // Automatically initialize board state
@@ -36,6 +49,11 @@ func NewDamasBoard(blackID, whiteID, boardtype string) Board {
 		board.GenerateEndGameTestBoard(blackID, whiteID) // Automatically initialize board state
 	case "multiple-capture":
 		board.GenerateMultipleCaptureTestBoard(blackID, whiteID) // Automatically initialize board state
+	default:
+		if err := board.LoadFEN(boardtype, blackID, whiteID); err != nil {
+			logger.Default.Errorf("(NewDamasBoard) - failed to load board type: %v, using the initial board, with err: %v", boardtype, err)
+			board.GenerateInitialBoard(blackID, whiteID)
+		}
 	}
 	return board
 }
@@ -49,26 +67,44 @@ func NewChessBoard(blackID, whiteID, boardtype string) Board {
 		board.GenerateEndGameTestBoard(blackID, whiteID)
 	case "multiple-capture":
 		board.GenerateMultipleCaptureTestBoard(blackID, whiteID)
+	default:
+		if err := board.LoadFEN(boardtype, blackID, whiteID); err != nil {
+			logger.Default.Errorf("(NewChessBoard) - failed to load board type: %v, using the initial board, with err: %v", boardtype, err)
+			board.GenerateInitialBoard(blackID, whiteID)
+		}
 	}
 	return board
 }
 
 func isInBounds(row rune, col int) bool {
-	return row >= 'A' && row <= 'H' && col >= 1 && col <= 8
+	return isInBoardBounds(row, col, 8)
+}
+
+func isInBoardBounds(row rune, col, size int) bool {
+	return row >= 'A' && row < 'A'+rune(size) && col >= 1 && col <= size
 }
 
-// parsePosition converts a position string (e.g., "A3") into row (rune) and column (int).
+// parsePosition converts a position string (e.g., "A3") into row (rune) and column (int), on a 8x8 board.
 // Returns an error if the position is invalid.
 func parsePosition(pos string) (rune, int, error) {
-	if len(pos) != 2 {
-		return 0, 0, fmt.Errorf("(Parse Position) - invalid position format: must be 2 characters (e.g., 'A3')")
+	return parseBoardPosition(pos, 8)
+}
+
+// parseBoardPosition converts a position string into row (rune) and column (int), for a board of any size.
+// Columns can have two digits on the bigger boards (e.g., "J10").
+func parseBoardPosition(pos string, size int) (rune, int, error) {
+	if len(pos) < 2 || len(pos) > 3 {
+		return 0, 0, fmt.Errorf("(Parse Position) - invalid position format: must be a row letter and a column number (e.g., 'A3')")
+	}
+	row := rune(pos[0]) // Convert the first character to a rune (e.g., 'A')
+	col, err := strconv.Atoi(pos[1:])
+	if err != nil {
+		return 0, 0, fmt.Errorf("(Parse Position) - invalid column: %v", pos[1:])
 	}
-	row := rune(pos[0])      // Convert the first character to a rune (e.g., 'A')
-	col := int(pos[1] - '0') // Convert the second character to an integer (e.g., '3' → 3)
 
 	// Validate the row and column
-	if row < 'A' || row > 'H' || col < 1 || col > 8 {
-		return 0, 0, fmt.Errorf("(Parse Position) - position is out of bounds: must be between A1 and H8")
+	if !isInBoardBounds(row, col, size) {
+		return 0, 0, fmt.Errorf("(Parse Position) - position is out of bounds: must be between A1 and %c%d", 'A'+rune(size-1), size)
 	}
 	return row, col, nil
 }
@@ -0,0 +1,52 @@
+package models
+
+// The house bot, it plays against a player that waited alone in the queue for too long.
+//
+// The bot is a regular player on redis, so rooms and games work the same, but it has no session and no
+// wallet, its bet is not real money. Games against the bot are flagged on the games and transactions tables,
+// to be accounted apart.
+
+const BotName = "Bot"
+
+// BotGameWinFactor is the win factor of a game against the bot. The house puts no money of its own in the game,
+// so a player that beats the bot only gets its own stake back.
+const BotGameWinFactor = 0.5
+
+// NewBotPlayer creates a bot to play the player, in the same queue, with the same bet and operator.
+func NewBotPlayer(opponent *Player) *Player {
+	return &Player{
+		ID:                 GenerateUUID(),
+		Currency:           opponent.Currency,
+		Status:             StatusInQueue,
+		SelectedBet:        opponent.SelectedBet,
+		Name:               BotName,
+		OperatorIdentifier: opponent.OperatorIdentifier,
+		IsBot:              true,
+	}
+}
+
+// WinFactor is the win factor the winner of the game is paid with, BotGameWinFactor against the bot.
+func (g *Game) WinFactor() float64 {
+	if g.HasBot() {
+		return BotGameWinFactor
+	}
+	return g.OperatorIdentifier.WinFactor
+}
+
+// WinFactor is the win factor the game of the room will be paid with, see Game.WinFactor.
+func (r *Room) WinFactor() float64 {
+	if (r.Player1 != nil && r.Player1.IsBot) || (r.Player2 != nil && r.Player2.IsBot) {
+		return BotGameWinFactor
+	}
+	return r.OperatorIdentifier.WinFactor
+}
+
+// HasBot tells if one of the players of the game is the house bot.
+func (g *Game) HasBot() bool {
+	for _, p := range g.Players {
+		if p.IsBot {
+			return true
+		}
+	}
+	return false
+}
@@ -7,7 +7,13 @@ import (
 )
 
 type ChessBoard struct {
-	Grid map[string]*ChessPiece
+	Grid            map[string]*ChessPiece
+	CastlingRights  string         `json:"castling_rights"`  // Same notation as FEN, "KQkq", uppercase for white.
+	EnPassant       string         `json:"en_passant"`       // Square a pawn can be captured on en passant, empty when there is none.
+	SideToMove      string         `json:"side_to_move"`     // Color of the player to move.
+	HalfmoveClock   int            `json:"halfmove_clock"`   // Half moves since the last capture or pawn move, for the fifty-move rule.
+	FullmoveNumber  int            `json:"fullmove_number"`  // Starts at 1 and goes up after each black move, like on FEN.
+	PositionHistory map[string]int `json:"position_history"` // Times each position was seen, for threefold repetition.
 }
 
 func (b *ChessBoard) GetPieceByID(id string) (PieceInterface, bool) {
@@ -48,14 +54,10 @@ func (b *ChessBoard) RemovePiece(pos string) {
 	b.Grid[pos] = nil
 }
 
+// MovePiece moves the piece and handles the chess special moves, a pawn reaching the last rank is
+// promoted to a queen. Use ApplyMove to promote to a different piece.
 func (b *ChessBoard) MovePiece(from, to string) error {
-	piece, ok := b.Grid[from]
-	if !ok {
-		return fmt.Errorf("no piece at %s", from)
-	}
-	b.Grid[to] = piece
-	b.Grid[from] = nil
-	return nil
+	return b.ApplyMove(&ChessMove{Move: Move{From: from, To: to}})
 }
 
 // GenerateInitialBoard initializes the chess board with starting pieces
@@ -113,6 +115,13 @@ func (b *ChessBoard) GenerateInitialBoard(blackID, whiteID string) {
 			}
 		}
 	}
+	b.CastlingRights = "KQkq"
+	b.EnPassant = ""
+	b.HalfmoveClock = 0
+	b.FullmoveNumber = 1
+	// The room current player gets the black pieces, and is the one that starts the game.
+	b.SideToMove = "b"
+	b.PositionHistory = map[string]int{b.positionKey(): 1}
 }
 
 // GenerateEndGameTestBoard initializes the board with a test configuration
@@ -125,85 +134,50 @@ func (b *ChessBoard) GenerateMultipleCaptureTestBoard(blackID, whiteID string) {
 
 }
 
+// ValidateMove checks the move against the full chess rules, the piece must be the one sitting on the
+// source square and the destination must be one of its legal moves.
 func (b *ChessBoard) ValidateMove(move MoveInterface, piece PieceInterface) (bool, error) {
-	return true, nil
+	current, exists := b.Grid[move.GetFrom()]
+	if !exists || current == nil {
+		return false, fmt.Errorf("(ValidateMove) - no piece at source square %v", move.GetFrom())
+	}
+	if piece == nil || current.GetID() != piece.GetID() {
+		return false, fmt.Errorf("(ValidateMove) - piece at %v does not match the moved piece", move.GetFrom())
+	}
+	return b.IsValidMove(move)
 }
 
 func (b *ChessBoard) IsValidMove(move MoveInterface) (bool, error) {
-	return true, nil
-}
-
-func (b *ChessBoard) IsValidMoveKing(move MoveInterface) (bool, error) {
 	piece, exists := b.Grid[move.GetFrom()]
 	if !exists || piece == nil {
-		return false, fmt.Errorf("(IsValidMoveKing) - piece does not exist at source")
+		return false, fmt.Errorf("(IsValidMove) - piece does not exist at the source square")
 	}
 	if piece.PlayerID != move.GetPlayerID() {
-		return false, fmt.Errorf("(IsValidMoveKing) - piece does not belong to player")
-	}
-	if !piece.IsPieceKinged() {
-		return false, fmt.Errorf("(IsValidMoveKing) - piece is not kinged")
-	}
-
-	fromRow, fromCol, err := parsePosition(move.GetFrom())
-	if err != nil {
-		return false, fmt.Errorf("(IsValidMoveKing) - invalid source: %v", err)
+		return false, fmt.Errorf("(IsValidMove) - piece does not belong to the player")
 	}
-	toRow, toCol, err := parsePosition(move.GetTo())
-	if err != nil {
-		return false, fmt.Errorf("(IsValidMoveKing) - invalid destination: %v", err)
+	if _, err := chessCoords(move.GetTo()); err != nil {
+		return false, fmt.Errorf("(IsValidMove) - invalid destination position: %v", err)
 	}
 
-	if _, ok := b.Grid[move.GetTo()]; !ok {
-		return false, fmt.Errorf("(IsValidMoveKing) - destination does not exist")
-	}
-	if b.Grid[move.GetTo()] != nil {
-		return false, fmt.Errorf("(IsValidMoveKing) - destination not empty")
-	}
-
-	deltaRow := int(toRow - fromRow)
-	deltaCol := int(toCol - fromCol)
-
-	if abs(deltaRow) != abs(deltaCol) {
-		return false, fmt.Errorf("(IsValidMoveKing) - move not diagonal")
-	}
-
-	stepRow := 1
-	if deltaRow < 0 {
-		stepRow = -1
+	legal := false
+	for _, to := range b.LegalMoves(move.GetFrom()) {
+		if to == move.GetTo() {
+			legal = true
+			break
+		}
 	}
-	stepCol := 1
-	if deltaCol < 0 {
-		stepCol = -1
+	if !legal {
+		return false, fmt.Errorf("(IsValidMove) - %v %v cannot move from %v to %v", piece.Color, piece.Type, move.GetFrom(), move.GetTo())
 	}
 
-	enemySeen := false
-	for r, c := fromRow+rune(stepRow), fromCol+stepCol; r != toRow && c != toCol; r, c = r+rune(stepRow), c+stepCol {
-		square := string(r) + string('0'+c)
-		p, exists := b.Grid[square]
-		if !exists {
-			return false, fmt.Errorf("(IsValidMoveKing) - square %v does not exist", square)
-		}
-		if p == nil {
-			continue
+	promotion := promotionPieceOf(move)
+	if b.isPromotionSquare(piece, move.GetTo()) {
+		if promotion != "" && !validPromotionPieces[promotion] {
+			return false, fmt.Errorf("(IsValidMove) - invalid promotion piece: %v", promotion)
 		}
-		if p.PlayerID == move.GetPlayerID() {
-			return false, fmt.Errorf("(IsValidMoveKing) - path blocked by own piece at %v", square)
-		}
-		// This looked sketchi.
-		if enemySeen {
-			return false, fmt.Errorf("(IsValidMoveKing) - multiple captures not supported in one move")
-		}
-		enemySeen = true
-	}
-
-	if enemySeen && !move.IsCaptureMove() {
-		return false, fmt.Errorf("(IsValidMoveKing) - move is a capture but not flagged as capture")
-	}
-	if !enemySeen && move.IsCaptureMove() {
-		return false, fmt.Errorf("(IsValidMoveKing) - flagged as capture but no enemy on path")
+	} else if promotion != "" {
+		return false, fmt.Errorf("(IsValidMove) - promotion piece sent on a move that does not promote")
 	}
-
 	return true, nil
 }
 
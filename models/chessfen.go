@@ -0,0 +1,209 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// FEN import and export for the chess board, and the position hash.
+//
+// FEN files are "a".."h" and our files are "A".."H", ranks are the same, white on ranks 1 and 2.
+
+var chessFENLetters = map[string]rune{
+	"pawn":   'p',
+	"knight": 'n',
+	"bishop": 'b',
+	"rook":   'r',
+	"queen":  'q',
+	"king":   'k',
+}
+
+var chessFENTypes = map[rune]string{
+	'p': "pawn",
+	'n': "knight",
+	'b': "bishop",
+	'r': "rook",
+	'q': "queen",
+	'k': "king",
+}
+
+var chessPieceIndex = map[string]int{"pawn": 0, "knight": 1, "bishop": 2, "rook": 3, "queen": 4, "king": 5}
+
+// ToFEN exports the position in Forsyth-Edwards Notation.
+func (b *ChessBoard) ToFEN() string {
+	var sb strings.Builder
+	for rank := 8; rank >= 1; rank-- {
+		empty := 0
+		for file := 0; file < 8; file++ {
+			p := b.pieceAt(file, rank)
+			if p == nil {
+				empty++
+				continue
+			}
+			if empty > 0 {
+				sb.WriteString(strconv.Itoa(empty))
+				empty = 0
+			}
+			letter := chessFENLetters[p.Type]
+			if p.Color == "w" {
+				letter = unicode.ToUpper(letter)
+			}
+			sb.WriteRune(letter)
+		}
+		if empty > 0 {
+			sb.WriteString(strconv.Itoa(empty))
+		}
+		if rank > 1 {
+			sb.WriteByte('/')
+		}
+	}
+	side := b.SideToMove
+	if side == "" {
+		side = "w"
+	}
+	castling := b.CastlingRights
+	if castling == "" {
+		castling = "-"
+	}
+	enPassant := strings.ToLower(b.EnPassant)
+	if enPassant == "" {
+		enPassant = "-"
+	}
+	fullmove := b.FullmoveNumber
+	if fullmove < 1 {
+		fullmove = 1
+	}
+	fmt.Fprintf(&sb, " %s %s %s %d %d", side, castling, enPassant, b.HalfmoveClock, fullmove)
+	return sb.String()
+}
+
+// LoadFEN replaces the position with the one in the FEN, white pieces go to whiteID and black pieces to blackID.
+// The half move clock and full move number are optional.
+func (b *ChessBoard) LoadFEN(fen, blackID, whiteID string) error {
+	fields := strings.Fields(fen)
+	if len(fields) < 4 {
+		return fmt.Errorf("(LoadFEN) - invalid fen, expected at least 4 fields: %q", fen)
+	}
+	ranks := strings.Split(fields[0], "/")
+	if len(ranks) != 8 {
+		return fmt.Errorf("(LoadFEN) - invalid fen, expected 8 ranks: %q", fields[0])
+	}
+	grid := make(map[string]*ChessPiece, 64)
+	for file := 0; file < 8; file++ {
+		for rank := 1; rank <= 8; rank++ {
+			grid[chessSquare(file, rank)] = nil
+		}
+	}
+	kings := map[string]int{}
+	for i, row := range ranks {
+		rank := 8 - i
+		file := 0
+		for _, ch := range row {
+			if ch >= '1' && ch <= '8' {
+				file += int(ch - '0')
+				continue
+			}
+			pieceType, ok := chessFENTypes[unicode.ToLower(ch)]
+			if !ok || file > 7 {
+				return fmt.Errorf("(LoadFEN) - invalid rank %d: %q", rank, row)
+			}
+			piece := &ChessPiece{Type: pieceType, PieceID: uuid.New().String(), Color: "b", PlayerID: blackID, IsAlive: true}
+			if unicode.IsUpper(ch) {
+				piece.Color = "w"
+				piece.PlayerID = whiteID
+			}
+			if pieceType == "king" {
+				kings[piece.Color]++
+			}
+			grid[chessSquare(file, rank)] = piece
+			file++
+		}
+		if file != 8 {
+			return fmt.Errorf("(LoadFEN) - rank %d does not have 8 squares: %q", rank, row)
+		}
+	}
+	if kings["w"] != 1 || kings["b"] != 1 {
+		return fmt.Errorf("(LoadFEN) - each side needs exactly one king")
+	}
+	if fields[1] != "w" && fields[1] != "b" {
+		return fmt.Errorf("(LoadFEN) - invalid side to move: %q", fields[1])
+	}
+	castling := fields[2]
+	if castling == "-" {
+		castling = ""
+	}
+	for _, ch := range castling {
+		if !strings.ContainsRune("KQkq", ch) {
+			return fmt.Errorf("(LoadFEN) - invalid castling rights: %q", fields[2])
+		}
+	}
+	enPassant := ""
+	if fields[3] != "-" {
+		enPassant = strings.ToUpper(fields[3])
+		if _, err := chessCoords(enPassant); err != nil {
+			return fmt.Errorf("(LoadFEN) - invalid en passant square: %q", fields[3])
+		}
+	}
+	halfmove, fullmove := 0, 1
+	if len(fields) > 4 {
+		n, err := strconv.Atoi(fields[4])
+		if err != nil || n < 0 {
+			return fmt.Errorf("(LoadFEN) - invalid half move clock: %q", fields[4])
+		}
+		halfmove = n
+	}
+	if len(fields) > 5 {
+		n, err := strconv.Atoi(fields[5])
+		if err != nil || n < 1 {
+			return fmt.Errorf("(LoadFEN) - invalid full move number: %q", fields[5])
+		}
+		fullmove = n
+	}
+
+	b.Grid = grid
+	b.SideToMove = fields[1]
+	b.CastlingRights = castling
+	b.EnPassant = enPassant
+	b.HalfmoveClock = halfmove
+	b.FullmoveNumber = fullmove
+	b.PositionHistory = map[string]int{b.positionKey(): 1}
+	return nil
+}
+
+// Hash returns the Zobrist hash of the position, two boards with the same position have the same hash,
+// whatever the piece IDs.
+func (b *ChessBoard) Hash() uint64 {
+	var h uint64
+	for pos, p := range b.Grid {
+		if p == nil {
+			continue
+		}
+		c, err := chessCoords(pos)
+		if err != nil {
+			continue
+		}
+		kind := chessPieceIndex[p.Type]
+		if p.Color == "b" {
+			kind += 6
+		}
+		h ^= zobristKeys[zobristChessPieces+kind*64+(c[1]-1)*8+c[0]]
+	}
+	if b.SideToMove == "w" {
+		h ^= zobristKeys[zobristChessSide]
+	}
+	for i, right := range "KQkq" {
+		if strings.ContainsRune(b.CastlingRights, right) {
+			h ^= zobristKeys[zobristChessCastling+i]
+		}
+	}
+	if b.EnPassant != "" {
+		if c, err := chessCoords(b.EnPassant); err == nil {
+			h ^= zobristKeys[zobristChessEnPassant+c[0]]
+		}
+	}
+	return h
+}
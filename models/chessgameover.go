@@ -0,0 +1,101 @@
+package models
+
+// End of game detection for the chess board.
+//
+// The reasons returned here are the same strings saved on the games table and sent on the game_over message.
+const (
+	ChessReasonCheckmate            = "checkmate"
+	ChessReasonStalemate            = "stalemate"
+	ChessReasonThreefoldRepetition  = "threefold_repetition"
+	ChessReasonFiftyMoveRule        = "fifty_move_rule"
+	ChessReasonInsufficientMaterial = "insufficient_material"
+)
+
+// positionKey identifies a position for the repetition rule, two positions are the same when the
+// pieces, the side to move, the castling rights and the en passant square are the same.
+func (b *ChessBoard) positionKey() string {
+	return hashKey(b.Hash())
+}
+
+// HasLegalMoves tells if the player with the given color can make any move.
+func (b *ChessBoard) HasLegalMoves(color string) bool {
+	for pos, p := range b.Grid {
+		if p != nil && p.Color == color && len(b.LegalMoves(pos)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *ChessBoard) IsCheckmate(color string) bool {
+	return b.IsInCheck(color) && !b.HasLegalMoves(color)
+}
+
+func (b *ChessBoard) IsStalemate(color string) bool {
+	return !b.IsInCheck(color) && !b.HasLegalMoves(color)
+}
+
+// IsThreefoldRepetition tells if the current position has been seen at least three times.
+func (b *ChessBoard) IsThreefoldRepetition() bool {
+	return b.PositionHistory[b.positionKey()] >= 3
+}
+
+// IsFiftyMoveRule tells if fifty moves by each player passed without a capture or a pawn move.
+func (b *ChessBoard) IsFiftyMoveRule() bool {
+	return b.HalfmoveClock >= 100
+}
+
+// IsInsufficientMaterial tells if neither player can ever checkmate, that is king against king,
+// king and a minor piece against king, or kings and bishops all on squares of the same color.
+func (b *ChessBoard) IsInsufficientMaterial() bool {
+	minors := 0
+	bishopSquareColors := map[int]bool{}
+	onlyBishops := true
+	for pos, p := range b.Grid {
+		if p == nil {
+			continue
+		}
+		switch p.Type {
+		case "king":
+			continue
+		case "knight":
+			onlyBishops = false
+		case "bishop":
+			c, err := chessCoords(pos)
+			if err != nil {
+				return false
+			}
+			bishopSquareColors[(c[0]+c[1])%2] = true
+		default:
+			// Any pawn, rook or queen is enough to mate.
+			return false
+		}
+		minors++
+	}
+	if minors <= 1 {
+		return true
+	}
+	return onlyBishops && len(bishopSquareColors) == 1
+}
+
+// GameOverReason checks if the game ended for the side to move, it returns an empty string while the
+// game goes on. Only a checkmate has a winner, the other player, every other reason is a draw.
+func (b *ChessBoard) GameOverReason() string {
+	color := b.SideToMove
+	if !b.HasLegalMoves(color) {
+		if b.IsInCheck(color) {
+			return ChessReasonCheckmate
+		}
+		return ChessReasonStalemate
+	}
+	if b.IsInsufficientMaterial() {
+		return ChessReasonInsufficientMaterial
+	}
+	if b.IsThreefoldRepetition() {
+		return ChessReasonThreefoldRepetition
+	}
+	if b.IsFiftyMoveRule() {
+		return ChessReasonFiftyMoveRule
+	}
+	return ""
+}
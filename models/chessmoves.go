@@ -0,0 +1,441 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Move generation for the chess board.
+//
+// Squares keep the same notation the rest of the board uses, the letter is the file ("A".."H") and the
+// number is the rank (1..8). White starts on ranks 1 and 2 and its pawns move up, black starts on ranks 7
+// and 8 and its pawns move down.
+
+var validPromotionPieces = map[string]bool{
+	"queen":  true,
+	"rook":   true,
+	"bishop": true,
+	"knight": true,
+}
+
+var knightOffsets = [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+var kingOffsets = [][2]int{{1, 1}, {1, 0}, {1, -1}, {0, 1}, {0, -1}, {-1, 1}, {-1, 0}, {-1, -1}}
+var bishopDirections = [][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+var rookDirections = [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+
+// chessCoords converts a square (e.g. "E4") into a file index (0..7) and a rank (1..8).
+func chessCoords(pos string) ([2]int, error) {
+	row, col, err := parsePosition(pos)
+	if err != nil {
+		return [2]int{}, err
+	}
+	return [2]int{int(row - 'A'), col}, nil
+}
+
+func chessSquare(file, rank int) string {
+	return fmt.Sprintf("%c%d", 'A'+file, rank)
+}
+
+func chessInBounds(file, rank int) bool {
+	return file >= 0 && file < 8 && rank >= 1 && rank <= 8
+}
+
+func opponentColor(color string) string {
+	if color == "w" {
+		return "b"
+	}
+	return "w"
+}
+
+func pawnDirection(color string) int {
+	if color == "w" {
+		return 1
+	}
+	return -1
+}
+
+// promotionPieceOf returns the promotion piece of a move, only chess moves carry one.
+func promotionPieceOf(move MoveInterface) string {
+	if pm, ok := move.(interface{ GetPromotionPiece() string }); ok {
+		return pm.GetPromotionPiece()
+	}
+	return ""
+}
+
+func (b *ChessBoard) pieceAt(file, rank int) *ChessPiece {
+	return b.Grid[chessSquare(file, rank)]
+}
+
+func (b *ChessBoard) isPromotionSquare(piece *ChessPiece, to string) bool {
+	if piece.Type != "pawn" {
+		return false
+	}
+	c, err := chessCoords(to)
+	if err != nil {
+		return false
+	}
+	return (piece.Color == "w" && c[1] == 8) || (piece.Color == "b" && c[1] == 1)
+}
+
+// findKing returns the square of the king of the given color.
+func (b *ChessBoard) findKing(color string) (string, bool) {
+	for pos, p := range b.Grid {
+		if p != nil && p.Type == "king" && p.Color == color {
+			return pos, true
+		}
+	}
+	return "", false
+}
+
+// IsInCheck returns true if the king of the given color is attacked.
+func (b *ChessBoard) IsInCheck(color string) bool {
+	kingPos, ok := b.findKing(color)
+	if !ok {
+		return false
+	}
+	c, _ := chessCoords(kingPos)
+	return b.isSquareAttacked(c[0], c[1], opponentColor(color))
+}
+
+// isSquareAttacked returns true if any piece of the color byColor attacks the square.
+func (b *ChessBoard) isSquareAttacked(file, rank int, byColor string) bool {
+	// Pawns attack diagonally forward, so we look one rank "behind" the square from their point of view.
+	pawnRank := rank - pawnDirection(byColor)
+	for _, df := range []int{-1, 1} {
+		if chessInBounds(file+df, pawnRank) {
+			if p := b.pieceAt(file+df, pawnRank); p != nil && p.Color == byColor && p.Type == "pawn" {
+				return true
+			}
+		}
+	}
+	for _, o := range knightOffsets {
+		f, r := file+o[0], rank+o[1]
+		if chessInBounds(f, r) {
+			if p := b.pieceAt(f, r); p != nil && p.Color == byColor && p.Type == "knight" {
+				return true
+			}
+		}
+	}
+	for _, o := range kingOffsets {
+		f, r := file+o[0], rank+o[1]
+		if chessInBounds(f, r) {
+			if p := b.pieceAt(f, r); p != nil && p.Color == byColor && p.Type == "king" {
+				return true
+			}
+		}
+	}
+	if b.slidingAttack(file, rank, byColor, bishopDirections, "bishop") {
+		return true
+	}
+	return b.slidingAttack(file, rank, byColor, rookDirections, "rook")
+}
+
+func (b *ChessBoard) slidingAttack(file, rank int, byColor string, directions [][2]int, pieceType string) bool {
+	for _, d := range directions {
+		f, r := file+d[0], rank+d[1]
+		for chessInBounds(f, r) {
+			p := b.pieceAt(f, r)
+			if p != nil {
+				if p.Color == byColor && (p.Type == pieceType || p.Type == "queen") {
+					return true
+				}
+				break
+			}
+			f, r = f+d[0], r+d[1]
+		}
+	}
+	return false
+}
+
+// pseudoLegalMoves returns the destinations a piece can reach without checking if its own king is left in check.
+func (b *ChessBoard) pseudoLegalMoves(pos string) []string {
+	piece := b.Grid[pos]
+	if piece == nil {
+		return nil
+	}
+	c, err := chessCoords(pos)
+	if err != nil {
+		return nil
+	}
+	file, rank := c[0], c[1]
+	var moves []string
+
+	addIfFreeOrEnemy := func(f, r int) {
+		if !chessInBounds(f, r) {
+			return
+		}
+		target := b.pieceAt(f, r)
+		if target == nil || target.Color != piece.Color {
+			moves = append(moves, chessSquare(f, r))
+		}
+	}
+	slide := func(directions [][2]int) {
+		for _, d := range directions {
+			f, r := file+d[0], rank+d[1]
+			for chessInBounds(f, r) {
+				target := b.pieceAt(f, r)
+				if target == nil {
+					moves = append(moves, chessSquare(f, r))
+				} else {
+					if target.Color != piece.Color {
+						moves = append(moves, chessSquare(f, r))
+					}
+					break
+				}
+				f, r = f+d[0], r+d[1]
+			}
+		}
+	}
+
+	switch piece.Type {
+	case "pawn":
+		dir := pawnDirection(piece.Color)
+		startRank := 2
+		if piece.Color == "b" {
+			startRank = 7
+		}
+		if chessInBounds(file, rank+dir) && b.pieceAt(file, rank+dir) == nil {
+			moves = append(moves, chessSquare(file, rank+dir))
+			if rank == startRank && b.pieceAt(file, rank+2*dir) == nil {
+				moves = append(moves, chessSquare(file, rank+2*dir))
+			}
+		}
+		for _, df := range []int{-1, 1} {
+			f, r := file+df, rank+dir
+			if !chessInBounds(f, r) {
+				continue
+			}
+			target := b.pieceAt(f, r)
+			if (target != nil && target.Color != piece.Color) || chessSquare(f, r) == b.EnPassant {
+				moves = append(moves, chessSquare(f, r))
+			}
+		}
+	case "knight":
+		for _, o := range knightOffsets {
+			addIfFreeOrEnemy(file+o[0], rank+o[1])
+		}
+	case "bishop":
+		slide(bishopDirections)
+	case "rook":
+		slide(rookDirections)
+	case "queen":
+		slide(bishopDirections)
+		slide(rookDirections)
+	case "king":
+		for _, o := range kingOffsets {
+			addIfFreeOrEnemy(file+o[0], rank+o[1])
+		}
+		moves = append(moves, b.castlingMoves(piece, file, rank)...)
+	}
+	return moves
+}
+
+// castlingMoves returns the king destinations for castling. The king can't castle out of, through or
+// into check, and the squares between king and rook have to be empty.
+func (b *ChessBoard) castlingMoves(king *ChessPiece, file, rank int) []string {
+	homeRank := 1
+	kingSide, queenSide := "K", "Q"
+	if king.Color == "b" {
+		homeRank = 8
+		kingSide, queenSide = "k", "q"
+	}
+	if file != 4 || rank != homeRank {
+		return nil
+	}
+	enemy := opponentColor(king.Color)
+	if b.isSquareAttacked(file, rank, enemy) {
+		return nil
+	}
+
+	var moves []string
+	if strings.Contains(b.CastlingRights, kingSide) {
+		rook := b.pieceAt(7, homeRank)
+		if rook != nil && rook.Type == "rook" && rook.Color == king.Color &&
+			b.pieceAt(5, homeRank) == nil && b.pieceAt(6, homeRank) == nil &&
+			!b.isSquareAttacked(5, homeRank, enemy) && !b.isSquareAttacked(6, homeRank, enemy) {
+			moves = append(moves, chessSquare(6, homeRank))
+		}
+	}
+	if strings.Contains(b.CastlingRights, queenSide) {
+		rook := b.pieceAt(0, homeRank)
+		if rook != nil && rook.Type == "rook" && rook.Color == king.Color &&
+			b.pieceAt(1, homeRank) == nil && b.pieceAt(2, homeRank) == nil && b.pieceAt(3, homeRank) == nil &&
+			!b.isSquareAttacked(3, homeRank, enemy) && !b.isSquareAttacked(2, homeRank, enemy) {
+			moves = append(moves, chessSquare(2, homeRank))
+		}
+	}
+	return moves
+}
+
+// LegalMoves returns every destination the piece on pos can legally move to, moves that leave the
+// own king in check are filtered out.
+func (b *ChessBoard) LegalMoves(pos string) []string {
+	piece := b.Grid[pos]
+	if piece == nil {
+		return nil
+	}
+	var legal []string
+	for _, to := range b.pseudoLegalMoves(pos) {
+		sim := b.clone()
+		if err := sim.applyMove(pos, to, ""); err != nil {
+			continue
+		}
+		if !sim.IsInCheck(piece.Color) {
+			legal = append(legal, to)
+		}
+	}
+	return legal
+}
+
+// clone returns a copy of the board, pieces are copied so the copy can be changed freely.
+func (b *ChessBoard) clone() *ChessBoard {
+	grid := make(map[string]*ChessPiece, len(b.Grid))
+	for pos, p := range b.Grid {
+		if p != nil {
+			cp := *p
+			grid[pos] = &cp
+		} else {
+			grid[pos] = nil
+		}
+	}
+	return &ChessBoard{
+		Grid:           grid,
+		CastlingRights: b.CastlingRights,
+		EnPassant:      b.EnPassant,
+		SideToMove:     b.SideToMove,
+		HalfmoveClock:  b.HalfmoveClock,
+		FullmoveNumber: b.FullmoveNumber,
+	}
+}
+
+// ApplyMove moves a piece on the board, handling castling, en passant, promotion and the castling rights.
+// It also keeps the data needed for the draw rules, the half move clock and the position history.
+//
+// The move is expected to be validated before, with ValidateMove.
+func (b *ChessBoard) ApplyMove(move MoveInterface) error {
+	piece := b.Grid[move.GetFrom()]
+	if piece == nil {
+		return fmt.Errorf("no piece at %s", move.GetFrom())
+	}
+	resetsClock := piece.Type == "pawn" || b.Grid[move.GetTo()] != nil
+	if err := b.applyMove(move.GetFrom(), move.GetTo(), promotionPieceOf(move)); err != nil {
+		return err
+	}
+	if resetsClock {
+		b.HalfmoveClock = 0
+	} else {
+		b.HalfmoveClock++
+	}
+	if piece.Color == "b" {
+		b.FullmoveNumber++
+	}
+	b.SideToMove = opponentColor(piece.Color)
+	if b.PositionHistory == nil {
+		b.PositionHistory = make(map[string]int)
+	}
+	b.PositionHistory[b.positionKey()]++
+	return nil
+}
+
+func (b *ChessBoard) applyMove(from, to, promotion string) error {
+	piece, ok := b.Grid[from]
+	if !ok || piece == nil {
+		return fmt.Errorf("no piece at %s", from)
+	}
+	fc, err := chessCoords(from)
+	if err != nil {
+		return err
+	}
+	tc, err := chessCoords(to)
+	if err != nil {
+		return err
+	}
+	captured := b.Grid[to]
+
+	switch piece.Type {
+	case "pawn":
+		// En passant, the pawn moves diagonally to an empty square and takes the pawn beside it.
+		if to == b.EnPassant && fc[0] != tc[0] && captured == nil {
+			b.Grid[chessSquare(tc[0], fc[1])] = nil
+		}
+	case "king":
+		// Castling, the king moves two files and the rook jumps over it.
+		if abs(tc[0]-fc[0]) == 2 {
+			rookFrom, rookTo := chessSquare(7, fc[1]), chessSquare(5, fc[1])
+			if tc[0] < fc[0] {
+				rookFrom, rookTo = chessSquare(0, fc[1]), chessSquare(3, fc[1])
+			}
+			b.Grid[rookTo] = b.Grid[rookFrom]
+			b.Grid[rookFrom] = nil
+		}
+	}
+
+	b.Grid[to] = piece
+	b.Grid[from] = nil
+
+	if b.isPromotionSquare(piece, to) {
+		if promotion == "" {
+			promotion = "queen"
+		}
+		piece.Type = promotion
+	}
+
+	// The en passant square is only available right after a pawn double step.
+	b.EnPassant = ""
+	if piece.Type == "pawn" && abs(tc[1]-fc[1]) == 2 {
+		b.EnPassant = chessSquare(fc[0], (fc[1]+tc[1])/2)
+	}
+
+	b.updateCastlingRights(piece, from, to)
+	return nil
+}
+
+// updateCastlingRights removes the rights lost by moving the king or a rook, or by a rook being captured.
+func (b *ChessBoard) updateCastlingRights(piece *ChessPiece, from, to string) {
+	if b.CastlingRights == "" {
+		return
+	}
+	corners := map[string]string{"H1": "K", "A1": "Q", "H8": "k", "A8": "q"}
+	remove := corners[to] // A rook captured on its corner.
+	if piece.Type == "rook" {
+		remove += corners[from]
+	}
+	if piece.Type == "king" {
+		remove += map[string]string{"w": "KQ", "b": "kq"}[piece.Color]
+	}
+	rights := ""
+	for _, c := range b.CastlingRights {
+		if !strings.Contains(remove, string(c)) {
+			rights += string(c)
+		}
+	}
+	b.CastlingRights = rights
+}
+
+// PlayerMoves lists every legal move of the player, promotions are always to a queen.
+func (b *ChessBoard) PlayerMoves(playerID string) []MoveInterface {
+	var moves []MoveInterface
+	for pos, piece := range b.Grid {
+		if piece == nil || piece.PlayerID != playerID {
+			continue
+		}
+		for _, to := range b.LegalMoves(pos) {
+			move := &ChessMove{Move: Move{PlayerID: playerID, PieceID: piece.PieceID, From: pos, To: to, IsCapture: b.Grid[to] != nil}}
+			if b.isPromotionSquare(piece, to) {
+				move.PromotionPiece = "queen"
+			}
+			moves = append(moves, move)
+		}
+	}
+	return moves
+}
+
+// Clone returns a copy of the board that can be changed freely, with the position history.
+func (b *ChessBoard) Clone() *ChessBoard {
+	clone := b.clone()
+	clone.PositionHistory = make(map[string]int, len(b.PositionHistory))
+	for k, v := range b.PositionHistory {
+		clone.PositionHistory[k] = v
+	}
+	return clone
+}
@@ -0,0 +1,62 @@
+package models
+
+import "testing"
+
+// perft counts the leaf nodes of the move tree down to depth, every promotion piece counts as its own move.
+func perft(b *ChessBoard, depth int) int {
+	if depth == 0 {
+		return 1
+	}
+	nodes := 0
+	for pos, piece := range b.Grid {
+		if piece == nil || piece.Color != b.SideToMove {
+			continue
+		}
+		for _, to := range b.LegalMoves(pos) {
+			promotions := []string{""}
+			if b.isPromotionSquare(piece, to) {
+				promotions = []string{"queen", "rook", "bishop", "knight"}
+			}
+			for _, promotion := range promotions {
+				sim := b.clone()
+				if err := sim.applyMove(pos, to, promotion); err != nil {
+					continue
+				}
+				sim.SideToMove = opponentColor(piece.Color)
+				nodes += perft(sim, depth-1)
+			}
+		}
+	}
+	return nodes
+}
+
+func TestPerft(t *testing.T) {
+	tests := []struct {
+		name  string
+		fen   string
+		nodes []int // Expected nodes for depth 1, 2, ...
+	}{
+		{"start", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", []int{20, 400, 8902, 197281}},
+		{"kiwipete", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", []int{48, 2039, 97862}},
+		{"position 3", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", []int{14, 191, 2812, 43238}},
+		{"position 4", "r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", []int{6, 264, 9467}},
+		{"position 5", "rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", []int{44, 1486, 62379}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &ChessBoard{}
+			if err := b.LoadFEN(tt.fen, "black", "white"); err != nil {
+				t.Fatalf("LoadFEN: %v", err)
+			}
+			for i, want := range tt.nodes {
+				depth := i + 1
+				if testing.Short() && depth > 2 {
+					break
+				}
+				if got := perft(b, depth); got != want {
+					t.Errorf("perft(%d) = %d, want %d", depth, got, want)
+				}
+			}
+		})
+	}
+}
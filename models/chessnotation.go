@@ -0,0 +1,95 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SAN returns the move in Standard Algebraic Notation (e.g. "Nf3", "exd5", "O-O", "e8=Q+"), for the
+// position before the move is applied.
+func (b *ChessBoard) SAN(move MoveInterface) (string, error) {
+	from, to := move.GetFrom(), move.GetTo()
+	piece := b.Grid[from]
+	if piece == nil {
+		return "", fmt.Errorf("(SAN) - no piece at %s", from)
+	}
+	fc, err := chessCoords(from)
+	if err != nil {
+		return "", fmt.Errorf("(SAN) - %v", err)
+	}
+	tc, err := chessCoords(to)
+	if err != nil {
+		return "", fmt.Errorf("(SAN) - %v", err)
+	}
+
+	var san string
+	switch {
+	case piece.Type == "king" && abs(tc[0]-fc[0]) == 2:
+		san = "O-O"
+		if tc[0] < fc[0] {
+			san = "O-O-O"
+		}
+	case piece.Type == "pawn":
+		if fc[0] != tc[0] {
+			// A pawn only changes file when it captures, en passant included.
+			san = strings.ToLower(from[:1]) + "x"
+		}
+		san += strings.ToLower(to)
+		if b.isPromotionSquare(piece, to) {
+			promotion := promotionPieceOf(move)
+			if promotion == "" {
+				promotion = "queen"
+			}
+			san += "=" + string(unicode.ToUpper(chessFENLetters[promotion]))
+		}
+	default:
+		san = string(unicode.ToUpper(chessFENLetters[piece.Type])) + b.sanDisambiguation(piece, from, to)
+		if b.Grid[to] != nil {
+			san += "x"
+		}
+		san += strings.ToLower(to)
+	}
+
+	sim := b.clone()
+	if err := sim.applyMove(from, to, promotionPieceOf(move)); err != nil {
+		return "", fmt.Errorf("(SAN) - %v", err)
+	}
+	opponent := opponentColor(piece.Color)
+	if sim.IsInCheck(opponent) {
+		if sim.HasLegalMoves(opponent) {
+			san += "+"
+		} else {
+			san += "#"
+		}
+	}
+	return san, nil
+}
+
+// sanDisambiguation returns the file, the rank or both of the from square, when another piece of the same
+// type and color can also move to the target square.
+func (b *ChessBoard) sanDisambiguation(piece *ChessPiece, from, to string) string {
+	sameFile, sameRank, others := false, false, false
+	for pos, p := range b.Grid {
+		if pos == from || p == nil || p.Color != piece.Color || p.Type != piece.Type {
+			continue
+		}
+		for _, target := range b.LegalMoves(pos) {
+			if target != to {
+				continue
+			}
+			others = true
+			sameFile = sameFile || pos[0] == from[0]
+			sameRank = sameRank || pos[1:] == from[1:]
+		}
+	}
+	switch {
+	case !others:
+		return ""
+	case !sameFile:
+		return strings.ToLower(from[:1])
+	case !sameRank:
+		return from[1:]
+	}
+	return strings.ToLower(from)
+}
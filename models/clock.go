@@ -0,0 +1,118 @@
+package models
+
+import (
+	"time"
+
+	"github.com/Lavizord/checkers-server/config"
+)
+
+// GameClock is the clock of a running game. It is kept in redis apart from the game, as deadlines, so
+// any gameworker can run it and a restart doesn't lose it. Times are unix milliseconds.
+type GameClock struct {
+	GameID         string           `json:"game_id"`
+	GameName       string           `json:"game_name"`
+	TimerSetting   string           `json:"timer_setting"`
+	TimeControl    TimeControl      `json:"time_control"`
+	TurnTimer      int64            `json:"turn_timer"` // Length of each turn for the "reset" setting.
+	ActivePlayerID string           `json:"active_player_id"`
+	TurnStartedAt  int64            `json:"turn_started_at"`
+	Remaining      map[string]int64 `json:"remaining"` // Time left for each player at the start of the turn.
+	Deadline       int64            `json:"deadline"`
+	FiredDeadline  int64            `json:"fired_deadline"` // Last deadline handled, so a deadline is never handled twice.
+}
+
+// NewGameClock starts the clock of a game, on the turn of the current player. The time left comes from the
+// player timers, so a game saved before the clocks existed gets a clock with the time it had.
+func NewGameClock(game *Game, now time.Time) *GameClock {
+	clock := &GameClock{
+		GameID:         game.ID,
+		GameName:       game.OperatorIdentifier.GameName,
+		TimerSetting:   game.TimerSetting,
+		TimeControl:    game.TimeControl,
+		TurnTimer:      int64(config.Cfg.Services["gameworker"].Timer) * 1000,
+		ActivePlayerID: game.CurrentPlayerID,
+		TurnStartedAt:  now.UnixMilli(),
+		Remaining:      map[string]int64{},
+	}
+	for _, player := range game.Players {
+		clock.Remaining[player.ID] = int64(player.Timer) * 1000
+	}
+	clock.setDeadline()
+	return clock
+}
+
+func (c *GameClock) setDeadline() {
+	switch c.TimerSetting {
+	case "reset":
+		c.Deadline = c.TurnStartedAt + c.TurnTimer
+	case TimerSettingClock:
+		c.Deadline = c.TurnStartedAt + c.Remaining[c.ActivePlayerID]
+		if c.TimeControl.MoveCap > 0 {
+			c.Deadline = min(c.Deadline, c.TurnStartedAt+int64(c.TimeControl.MoveCap)*1000)
+		}
+	default:
+		c.Deadline = c.TurnStartedAt + c.Remaining[c.ActivePlayerID]
+	}
+}
+
+// TimeLeft is the time the player has left right now, in milliseconds.
+func (c *GameClock) TimeLeft(playerID string, now time.Time) int64 {
+	if playerID != c.ActivePlayerID {
+		return c.Remaining[playerID]
+	}
+	if c.TimerSetting == "reset" {
+		return max(c.Deadline-now.UnixMilli(), 0)
+	}
+	return max(c.Remaining[playerID]-(now.UnixMilli()-c.TurnStartedAt), 0)
+}
+
+// Switch charges the active player for the turn, gives back the increment and delay of the time control,
+// and starts the turn of the next player.
+func (c *GameClock) Switch(nextPlayerID string, now time.Time) {
+	elapsed := now.UnixMilli() - c.TurnStartedAt
+	if c.TimerSetting != "reset" {
+		left := max(c.Remaining[c.ActivePlayerID]-elapsed, 0)
+		if c.TimerSetting == TimerSettingClock {
+			left += int64(c.TimeControl.MoveCredit(int(elapsed/1000))) * 1000
+		}
+		c.Remaining[c.ActivePlayerID] = left
+	}
+	c.ActivePlayerID = nextPlayerID
+	c.TurnStartedAt = now.UnixMilli()
+	c.setDeadline()
+}
+
+// Restart charges the active player for the time used and gives the turn to the player, without the increment
+// and delay of Switch, it is used when moves are taken back.
+func (c *GameClock) Restart(playerID string, now time.Time) {
+	if c.TimerSetting != "reset" {
+		c.Remaining[c.ActivePlayerID] = max(c.Remaining[c.ActivePlayerID]-(now.UnixMilli()-c.TurnStartedAt), 0)
+	}
+	c.ActivePlayerID = playerID
+	c.TurnStartedAt = now.UnixMilli()
+	c.setDeadline()
+}
+
+// Due tells if the deadline passed and wasn't handled yet.
+func (c *GameClock) Due(now time.Time) bool {
+	return c.Deadline <= now.UnixMilli() && c.FiredDeadline != c.Deadline
+}
+
+// SyncTimers copies the time the players have left, rounded up to seconds, to the player timers of the game.
+func (c *GameClock) SyncTimers(game *Game, now time.Time) {
+	if c.TimerSetting == "reset" {
+		return
+	}
+	for _, player := range game.Players {
+		game.UpdatePlayerTimer(player.ID, int((c.TimeLeft(player.ID, now)+999)/1000))
+	}
+}
+
+// LosesOnTime tells if the active player ran out of time, and loses the game. When the deadline is only
+// the end of the turn ("reset" setting or the move cap) the player just loses the turn.
+func (c *GameClock) LosesOnTime(now time.Time) bool {
+	if c.TimerSetting == "reset" {
+		return false
+	}
+	return c.TimeLeft(c.ActivePlayerID, now) <= 0
+}
@@ -10,6 +10,29 @@ import (
 
 type DamasBoard struct {
 	Grid map[string]*DamasPiece
+	// CaptureLock is the square of the piece in the middle of a multi-jump, while set only that piece can move
+	// and it must keep capturing. Empty when there is no capture sequence going on.
+	CaptureLock string `json:"capture_lock"`
+	// Variant is the name of the ruleset of the board, see DamasVariants. Empty is the classic variant.
+	Variant string `json:"variant"`
+	// Data for the automatic draws, times each position was seen and king moves in a row without a capture.
+	PositionHistory         map[string]int `json:"position_history"`
+	KingMovesWithoutCapture int            `json:"king_moves_without_capture"`
+	// SideToMove is the color of the player to move, "b" or "w", kept for the position hash and the FEN.
+	SideToMove string `json:"side_to_move"`
+}
+
+// Rules returns the variant rules of the board.
+func (b *DamasBoard) Rules() DamasVariant {
+	variant, err := GetDamasVariant(b.Variant)
+	if err != nil {
+		return DamasVariants[DamasVariantClassic]
+	}
+	return variant
+}
+
+func (b *DamasBoard) parsePosition(pos string) (rune, int, error) {
+	return parseBoardPosition(pos, b.Rules().Size)
 }
 
 func (b *DamasBoard) GetPieceByID(id string) (PieceInterface, bool) {
@@ -60,19 +83,21 @@ func (b *DamasBoard) MovePiece(from, to string) error {
 	return nil
 }
 
-// GenerateInitialBoard initializes the board with starting pieces
+// GenerateInitialBoard initializes the board with starting pieces, the size of the board and the rows
+// of men come from the variant.
 func (b *DamasBoard) GenerateInitialBoard(blackID, whiteID string) {
-	rows := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+	rules := b.Rules()
 
-	for i, row := range rows {
-		for col := 1; col <= 8; col++ {
-			pos := fmt.Sprintf("%s%d", row, col)
+	for i := 0; i < rules.Size; i++ {
+		row := 'A' + rune(i)
+		for col := 1; col <= rules.Size; col++ {
+			pos := fmt.Sprintf("%c%d", row, col)
 			b.Grid[pos] = nil
 			// Only place pieces on dark squares
 			if (i+col)%2 == 1 {
-				if i < 3 { // Top 3 rows for black pieces
+				if i < rules.RowsOfMen { // Top rows for black pieces
 					b.Grid[pos] = &DamasPiece{Type: "b", PieceID: uuid.New().String(), PlayerID: blackID}
-				} else if i > 4 { // Bottom 3 rows for white pieces
+				} else if i >= rules.Size-rules.RowsOfMen { // Bottom rows for white pieces
 					b.Grid[pos] = &DamasPiece{Type: "w", PieceID: uuid.New().String(), PlayerID: whiteID}
 				} else {
 					b.Grid[pos] = nil // Empty middle rows
@@ -80,6 +105,10 @@ func (b *DamasBoard) GenerateInitialBoard(blackID, whiteID string) {
 			}
 		}
 	}
+	// Black starts the game.
+	b.KingMovesWithoutCapture = 0
+	b.SideToMove = "b"
+	b.PositionHistory = map[string]int{b.positionKey("b"): 1}
 }
 
 // GenerateEndGameTestBoard initializes the board with a test configuration
@@ -136,135 +165,158 @@ func (b *DamasBoard) CanPieceCaptureNEW(pos string) bool {
 		log.Printf("(CanPieceCapture) - Piece doesn't exist on the board\n")
 		return false // No piece at this position
 	}
+	return len(b.captureSteps(b.Grid, pos, piece, nil)) > 0
+}
 
-	// Define the possible capture directions
-	var directions []struct{ rowDelta, colDelta int }
-	if piece.IsKinged {
-		// Kings can move in all four diagonal directions
-		directions = []struct{ rowDelta, colDelta int }{
-			{1, 1},   // Diagonal right (down)
-			{1, -1},  // Diagonal left (down)
-			{-1, 1},  // Diagonal right (up)
-			{-1, -1}, // Diagonal left (up)
-		}
-		//log.Printf("(CanPieceCapture) - Piece is king, multiple directions.")
-	} else {
-		//log.Printf("(CanPieceCapture) - Piece is not king, single direction.")
-		// Normal pieces can only move forward
-		var direction = b.GetPieceDirection(*piece)
-		directions = []struct{ rowDelta, colDelta int }{
-			{direction, 1},  // Diagonal right
-			{direction, -1}, // Diagonal left
-		}
-	}
+var damasDirections = [][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+
+// captureStep is one jump of a capture sequence, over is the square of the captured piece.
+type captureStep struct {
+	to   string
+	over string
+}
 
-	// Convert position (e.g., "A3" → row 'A', col 3)
-	fromRow, fromCol, err := parsePosition(pos)
+// captureSteps lists the jumps the piece at pos can make on the grid, following the variant rules.
+// Pieces in taken were already captured earlier in the same sequence, they can't be captured twice and
+// they still block the way, since they are only removed at the end of the sequence.
+func (b *DamasBoard) captureSteps(grid map[string]*DamasPiece, pos string, piece *DamasPiece, taken map[string]bool) []captureStep {
+	rules := b.Rules()
+	row, col, err := parseBoardPosition(pos, rules.Size)
 	if err != nil {
-		log.Println(err)
-		return false
+		return nil
+	}
+	reach := 1
+	if piece.IsKinged && rules.FlyingKings {
+		reach = rules.Size
 	}
-	if piece.IsKinged {
-		return b.canKingCapture(fromRow, fromCol, piece)
-	}
-	for _, dir := range directions {
-		// Compute middle position (opponent's piece)
-		midRow := fromRow + rune(dir.rowDelta)
-		midCol := fromCol + dir.colDelta
-		midPos := fmt.Sprintf("%c%d", midRow, midCol)
-
-		// Compute landing position
-		landRow := fromRow + rune(2*dir.rowDelta)
-		landCol := fromCol + 2*dir.colDelta
-		landPos := fmt.Sprintf("%c%d", landRow, landCol)
-		//log.Printf("(CanPieceCapture) - Checking landPos [%s]\n", landPos)
-		// Ensure middle square has an opponent piece
-		midPiece, midExists := b.Grid[midPos]
-		if !midExists || midPiece == nil || midPiece.PlayerID == piece.PlayerID {
-			//log.Printf("(CanPieceCapture) - Middle piece doesn't exist or is not an opponent!\n")
-			continue // No opponent to jump over
+	var steps []captureStep
+	for _, dir := range damasDirections {
+		if !piece.IsKinged && !rules.MenCaptureBackwards && dir[0] != b.GetPieceDirection(*piece) {
+			continue
 		}
-		//log.Printf("(CanPieceCapture) - Middle piece exists and is an opponent!\n")
-		// Ensure landing square is empty
-		if destPiece, destExists := b.Grid[landPos]; destExists && destPiece == nil {
-			//log.Printf("(CanPieceCapture) - Valid capture move found\n")
-			return true // Valid capture move found!
+		// Walk the empty squares up to the first piece on the diagonal.
+		r, c := row+rune(dir[0]), col+dir[1]
+		for i := 1; i < reach && isInBoardBounds(r, c, rules.Size) && grid[fmt.Sprintf("%c%d", r, c)] == nil; i++ {
+			r, c = r+rune(dir[0]), c+dir[1]
+		}
+		if !isInBoardBounds(r, c, rules.Size) {
+			continue
+		}
+		over := fmt.Sprintf("%c%d", r, c)
+		target := grid[over]
+		if target == nil || target.PlayerID == piece.PlayerID || taken[over] {
+			continue
+		}
+		// Landing squares, right after the captured piece, or any empty square after it for flying kings.
+		r, c = r+rune(dir[0]), c+dir[1]
+		for i := 0; i < reach && isInBoardBounds(r, c, rules.Size); i++ {
+			to := fmt.Sprintf("%c%d", r, c)
+			if grid[to] != nil {
+				break
+			}
+			steps = append(steps, captureStep{to: to, over: over})
+			r, c = r+rune(dir[0]), c+dir[1]
 		}
 	}
-	//log.Printf("(CanPieceCapture) - No captures available\n")
-	return false // No captures available
+	return steps
 }
 
-func (b *DamasBoard) canKingCapture(fromRow rune, fromCol int, piece *DamasPiece) bool {
-	directions := []struct{ rowDelta, colDelta int }{
-		{1, 1}, {1, -1}, {-1, 1}, {-1, -1},
+// longestCapture returns how many pieces the piece at pos can take in its best capture sequence.
+// The grid is changed while searching and restored before returning.
+func (b *DamasBoard) longestCapture(grid map[string]*DamasPiece, pos string, piece *DamasPiece, taken map[string]bool) int {
+	best := 0
+	for _, step := range b.captureSteps(grid, pos, piece, taken) {
+		grid[pos], grid[step.to] = nil, piece
+		taken[step.over] = true
+		if n := 1 + b.longestCapture(grid, step.to, piece, taken); n > best {
+			best = n
+		}
+		delete(taken, step.over)
+		grid[pos], grid[step.to] = piece, nil
 	}
+	return best
+}
 
-	for _, dir := range directions {
-		foundEnemy := false
-		row := fromRow + rune(dir.rowDelta)
-		col := fromCol + dir.colDelta
-
-		for isInBounds(row, col) {
-			pos := fmt.Sprintf("%c%d", row, col)
-			target, exists := b.Grid[pos]
-			if !exists {
-				break
-			}
-			if target == nil {
-				if foundEnemy {
-					return true
-				}
-			} else if target.PlayerID != piece.PlayerID {
-				if foundEnemy {
-					break
-				}
-				foundEnemy = true
-			} else {
-				break
-			}
-
-			row += rune(dir.rowDelta)
-			col += dir.colDelta
+// checkMajorityCapture makes sure the capture is the start of the sequence that takes the most pieces,
+// for the variants with the majority rule. When a sequence is already going on, only the locked piece counts.
+func (b *DamasBoard) checkMajorityCapture(move MoveInterface, piece *DamasPiece) error {
+	grid := make(map[string]*DamasPiece, len(b.Grid))
+	for pos, p := range b.Grid {
+		grid[pos] = p
+	}
+	best := 0
+	for pos, p := range grid {
+		if p == nil || p.PlayerID != piece.PlayerID || (b.CaptureLock != "" && pos != b.CaptureLock) {
+			continue
+		}
+		if n := b.longestCapture(grid, pos, p, map[string]bool{}); n > best {
+			best = n
 		}
 	}
-	return false
+	over := b.CapturedSquare(move.GetFrom(), move.GetTo())
+	grid[move.GetFrom()], grid[move.GetTo()] = nil, piece
+	taken := map[string]bool{over: true}
+	if n := 1 + b.longestCapture(grid, move.GetTo(), piece, taken); n < best {
+		return fmt.Errorf("error: the capture takes %d pieces, but there is a capture that takes %d", n, best)
+	}
+	return nil
 }
 
 func (b *DamasBoard) ValidateMove(move MoveInterface, piece PieceInterface) (bool, error) {
+	if err := b.checkMove(move, piece); err != nil {
+		logger.Default.Errorf(err.Error())
+		return false, err
+	}
+	return true, nil
+}
+
+// checkMove does the checks of ValidateMove without logging, the move generation tries moves that
+// are not valid.
+func (b *DamasBoard) checkMove(move MoveInterface, piece PieceInterface) error {
+	boardPiece, exists := b.Grid[move.GetFrom()]
+	if !exists || boardPiece == nil || boardPiece.GetID() != piece.GetID() {
+		return fmt.Errorf("error: piece %v is not at %v", piece.GetID(), move.GetFrom())
+	}
+	if b.CaptureLock != "" && move.GetFrom() != b.CaptureLock {
+		return fmt.Errorf("error: the piece at %v is in the middle of a capture, must keep capturing with it", b.CaptureLock)
+	}
+	// Capturing is mandatory, if any piece can capture the move must be a capture made by one of those pieces.
 	capturers := b.PiecesThatCanCapture(move.GetPlayerID())
 	if len(capturers) > 0 {
+		if !move.IsCaptureMove() {
+			return fmt.Errorf("error: there are player pieces that can capture, the move must be a capture")
+		}
 		canCapture := false
 		for _, p := range capturers {
-			if p.GetPlayerID() == move.GetPlayerID() {
+			if p.GetID() == piece.GetID() {
 				canCapture = true
 				break
 			}
 		}
 		if !canCapture {
-			errmsg := fmt.Errorf("error: there are player pieces that can capture, must move one of those")
-			logger.Default.Errorf(errmsg.Error())
-			return false, errmsg
+			return fmt.Errorf("error: there are player pieces that can capture, must move one of those")
 		}
 	}
 
 	var valid bool
 	var err error
-	if piece.IsPieceKinged() {
+	if piece.IsPieceKinged() && b.Rules().FlyingKings {
 		valid, err = b.IsValidMoveKing(move)
 	} else {
 		valid, err = b.IsValidMove(move)
 	}
 	if err != nil {
-		logger.Default.Errorf("Error: %v", err)
-		return false, err
+		return fmt.Errorf("Error: %v", err)
 	}
 	if !valid {
-		errmsg := fmt.Errorf("move is not valid.")
-		logger.Default.Errorf(errmsg.Error())
-		return false, errmsg
+		return fmt.Errorf("move is not valid.")
 	}
-	return true, nil
+	if move.IsCaptureMove() && b.Rules().MajorityCapture {
+		if err := b.checkMajorityCapture(move, boardPiece); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (b *DamasBoard) IsValidMove(move MoveInterface) (bool, error) {
@@ -277,11 +329,11 @@ func (b *DamasBoard) IsValidMove(move MoveInterface) (bool, error) {
 	if piece.PlayerID != move.GetPlayerID() {
 		return false, fmt.Errorf("(isValidMove) - piece does not belong to the player")
 	}
-	fromRow, fromCol, err := parsePosition(move.GetFrom())
+	fromRow, fromCol, err := b.parsePosition(move.GetFrom())
 	if err != nil {
 		return false, fmt.Errorf("(isValidMove) - invalid source position: %v", err)
 	}
-	toRow, toCol, err := parsePosition(move.GetTo())
+	toRow, toCol, err := b.parsePosition(move.GetTo())
 	if err != nil {
 		return false, fmt.Errorf("(isValidMove) - invalid destination position: %v", err)
 	}
@@ -296,28 +348,32 @@ func (b *DamasBoard) IsValidMove(move MoveInterface) (bool, error) {
 	// Calculate the difference in rows and columns
 	deltaRow := int(toRow - fromRow)
 	deltaCol := toCol - fromCol
-	// Skip direction validation if the piece is kinged
-	if !piece.IsKinged {
+	// Skip direction validation if the piece is kinged, or for captures on the variants where men capture backwards.
+	isJump := abs(deltaCol) == 2 && abs(deltaRow) == 2
+	if !piece.IsKinged && !(isJump && b.Rules().MenCaptureBackwards) {
 		direction := b.GetPieceDirection(*piece)
 		if deltaRow*direction <= 0 { // Check if the move is in the correct direction
 			return false, fmt.Errorf("(isValidMove) - move is not in the correct direction for the piece type")
 		}
 	}
-	// Check if the move is diagonal
-	if abs(deltaCol) != 1 || abs(deltaRow) != 1 {
-		// If it's not a single diagonal move, check if it's a capture
-		if !move.IsCaptureMove() || abs(deltaCol) != 2 || abs(deltaRow) != 2 {
-			return false, fmt.Errorf("(isValidMove) - move is not diagonal or a valid capture")
-		}
-
-		// For a capture, check if the intermediate square has an opponent's piece
-		captureRow := fromRow + rune(deltaRow/2)
-		captureCol := fromCol + deltaCol/2
-		captureSquare := string(captureRow) + string('0'+captureCol)
-		capturedPiece, exists := b.Grid[captureSquare]
-		if !exists || capturedPiece == nil || capturedPiece.PlayerID == move.GetPlayerID() {
-			return false, fmt.Errorf("(isValidMove) - invalid capture: no opponent's piece to capture")
+	// A single diagonal step, can't be a capture.
+	if abs(deltaCol) == 1 && abs(deltaRow) == 1 {
+		if move.IsCaptureMove() {
+			return false, fmt.Errorf("(isValidMove) - flagged as capture but the move does not jump any piece")
 		}
+		return true, nil
+	}
+	// Otherwise it must be a jump over an opponent's piece.
+	if !isJump {
+		return false, fmt.Errorf("(isValidMove) - move is not diagonal or a valid capture")
+	}
+	captureSquare := fmt.Sprintf("%c%d", fromRow+rune(deltaRow/2), fromCol+deltaCol/2)
+	capturedPiece, exists := b.Grid[captureSquare]
+	if !exists || capturedPiece == nil || capturedPiece.PlayerID == move.GetPlayerID() {
+		return false, fmt.Errorf("(isValidMove) - invalid capture: no opponent's piece to capture")
+	}
+	if !move.IsCaptureMove() {
+		return false, fmt.Errorf("(isValidMove) - move is a capture but not flagged as capture")
 	}
 	// If all checks pass, the move is valid
 	return true, nil
@@ -335,11 +391,11 @@ func (b *DamasBoard) IsValidMoveKing(move MoveInterface) (bool, error) {
 		return false, fmt.Errorf("(IsValidMoveKing) - piece is not kinged")
 	}
 
-	fromRow, fromCol, err := parsePosition(move.GetFrom())
+	fromRow, fromCol, err := b.parsePosition(move.GetFrom())
 	if err != nil {
 		return false, fmt.Errorf("(IsValidMoveKing) - invalid source: %v", err)
 	}
-	toRow, toCol, err := parsePosition(move.GetTo())
+	toRow, toCol, err := b.parsePosition(move.GetTo())
 	if err != nil {
 		return false, fmt.Errorf("(IsValidMoveKing) - invalid destination: %v", err)
 	}
@@ -369,7 +425,7 @@ func (b *DamasBoard) IsValidMoveKing(move MoveInterface) (bool, error) {
 
 	enemySeen := false
 	for r, c := fromRow+rune(stepRow), fromCol+stepCol; r != toRow && c != toCol; r, c = r+rune(stepRow), c+stepCol {
-		square := string(r) + string('0'+c)
+		square := fmt.Sprintf("%c%d", r, c)
 		p, exists := b.Grid[square]
 		if !exists {
 			return false, fmt.Errorf("(IsValidMoveKing) - square %v does not exist", square)
@@ -397,6 +453,33 @@ func (b *DamasBoard) IsValidMoveKing(move MoveInterface) (bool, error) {
 	return true, nil
 }
 
+// CapturedSquare returns the square of the piece jumped by a move from -> to, the first piece on the
+// diagonal between both squares. Returns an empty string if the path is empty.
+func (b *DamasBoard) CapturedSquare(from, to string) string {
+	fromRow, fromCol, err := b.parsePosition(from)
+	if err != nil {
+		return ""
+	}
+	toRow, toCol, err := b.parsePosition(to)
+	if err != nil {
+		return ""
+	}
+	stepRow, stepCol := rune(1), 1
+	if toRow < fromRow {
+		stepRow = -1
+	}
+	if toCol < fromCol {
+		stepCol = -1
+	}
+	for r, c := fromRow+stepRow, fromCol+stepCol; r != toRow && c != toCol; r, c = r+stepRow, c+stepCol {
+		square := fmt.Sprintf("%c%d", r, c)
+		if b.Grid[square] != nil {
+			return square
+		}
+	}
+	return ""
+}
+
 func (b *DamasBoard) WasPieceKinged(pos string, piece PieceInterface) bool {
 	if piece.IsPieceKinged() { // If its alerady a king we just return false.
 		return false
@@ -405,8 +488,8 @@ func (b *DamasBoard) WasPieceKinged(pos string, piece PieceInterface) bool {
 	if len(pos) == 0 {
 		return false
 	}
-	firstChar := pos[0]
-	if piece.GetType() == "b" && firstChar == 'H' {
+	firstChar := rune(pos[0])
+	if piece.GetType() == "b" && firstChar == b.Rules().LastRow() {
 		log.Printf("(WasPieceKinged) - Black piece was kinged!")
 		return true
 	}
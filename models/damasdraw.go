@@ -0,0 +1,38 @@
+package models
+
+// Automatic draws for the damas board, a position repeated three times, or too many king moves without a capture.
+
+// positionKey identifies a position for the repetition rule, the pieces on the board and the color to move.
+func (b *DamasBoard) positionKey(sideToMove string) string {
+	return hashKey(b.hash(sideToMove))
+}
+
+// RecordMove keeps the count of king moves in a row without a capture, any man move or capture resets it.
+func (b *DamasBoard) RecordMove(wasKingMove, isCapture bool) {
+	if wasKingMove && !isCapture {
+		b.KingMovesWithoutCapture++
+		return
+	}
+	b.KingMovesWithoutCapture = 0
+}
+
+// RecordPosition adds the current position to the history, called when the turn passes to sideToMove.
+func (b *DamasBoard) RecordPosition(sideToMove string) {
+	b.SideToMove = sideToMove
+	if b.PositionHistory == nil {
+		b.PositionHistory = make(map[string]int)
+	}
+	b.PositionHistory[b.positionKey(sideToMove)]++
+}
+
+// DrawReason checks the automatic draws for the position with sideToMove to play, it returns an empty
+// string while the game goes on. maxKingMoves is the limit of king moves in a row without a capture.
+func (b *DamasBoard) DrawReason(sideToMove string, maxKingMoves int) string {
+	if b.PositionHistory[b.positionKey(sideToMove)] >= 3 {
+		return DamasReasonThreefoldRepetition
+	}
+	if maxKingMoves > 0 && b.KingMovesWithoutCapture >= maxKingMoves {
+		return DamasReasonKingMovesWithoutCapture
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// PDN FEN import and export for the damas board, and the position hash.
+//
+// The PDN FEN looks like "B:W21,22,K30:B1,2,3-12", the side to move, then the white and the black pieces
+// by square number, kings with a K before the number, ranges only on import. The dark squares are numbered
+// in order from row A, so the black pieces start on the low numbers like on the PDN boards.
+
+// damasSquares returns the dark squares of the board in PDN order, square 1 is damasSquares(size)[0].
+func damasSquares(size int) []string {
+	squares := make([]string, 0, size*size/2)
+	for i := 0; i < size; i++ {
+		for col := 1; col <= size; col++ {
+			if (i+col)%2 == 1 {
+				squares = append(squares, fmt.Sprintf("%c%d", 'A'+rune(i), col))
+			}
+		}
+	}
+	return squares
+}
+
+// ToFEN exports the position in PDN FEN notation.
+func (b *DamasBoard) ToFEN() string {
+	sections := map[string][]string{"w": {}, "b": {}}
+	for n, pos := range damasSquares(b.Rules().Size) {
+		p := b.Grid[pos]
+		if p == nil {
+			continue
+		}
+		square := strconv.Itoa(n + 1)
+		if p.IsKinged {
+			square = "K" + square
+		}
+		sections[p.Type] = append(sections[p.Type], square)
+	}
+	side := b.SideToMove
+	if side == "" {
+		side = "b"
+	}
+	return fmt.Sprintf("%s:W%s:B%s", strings.ToUpper(side), strings.Join(sections["w"], ","), strings.Join(sections["b"], ","))
+}
+
+// LoadFEN replaces the position with the one in the PDN FEN, white pieces go to whiteID and black pieces
+// to blackID. The board size comes from the variant of the board.
+func (b *DamasBoard) LoadFEN(fen, blackID, whiteID string) error {
+	fields := strings.Split(strings.TrimSuffix(strings.TrimSpace(fen), "."), ":")
+	if len(fields) != 3 {
+		return fmt.Errorf("(LoadFEN) - invalid pdn fen, expected 3 fields: %q", fen)
+	}
+	side := strings.ToLower(fields[0])
+	if side != "w" && side != "b" {
+		return fmt.Errorf("(LoadFEN) - invalid side to move: %q", fields[0])
+	}
+	squares := damasSquares(b.Rules().Size)
+	grid := make(map[string]*DamasPiece, len(squares)*2)
+	for i := 0; i < b.Rules().Size; i++ {
+		for col := 1; col <= b.Rules().Size; col++ {
+			grid[fmt.Sprintf("%c%d", 'A'+rune(i), col)] = nil
+		}
+	}
+	for _, section := range fields[1:] {
+		if section == "" {
+			return fmt.Errorf("(LoadFEN) - empty color section: %q", fen)
+		}
+		color := strings.ToLower(section[:1])
+		playerID := blackID
+		switch color {
+		case "w":
+			playerID = whiteID
+		case "b":
+		default:
+			return fmt.Errorf("(LoadFEN) - invalid color section: %q", section)
+		}
+		if len(section) == 1 {
+			continue // No pieces of this color.
+		}
+		for _, square := range strings.Split(section[1:], ",") {
+			kinged := strings.HasPrefix(square, "K")
+			square = strings.TrimPrefix(square, "K")
+			first, last, err := parseSquareRange(square)
+			if err != nil {
+				return fmt.Errorf("(LoadFEN) - %v", err)
+			}
+			for n := first; n <= last; n++ {
+				if n < 1 || n > len(squares) {
+					return fmt.Errorf("(LoadFEN) - square %d is out of the board", n)
+				}
+				pos := squares[n-1]
+				if grid[pos] != nil {
+					return fmt.Errorf("(LoadFEN) - square %d has more than one piece", n)
+				}
+				grid[pos] = &DamasPiece{Type: color, PieceID: uuid.New().String(), PlayerID: playerID, IsKinged: kinged}
+			}
+		}
+	}
+
+	b.Grid = grid
+	b.CaptureLock = ""
+	b.SideToMove = side
+	b.KingMovesWithoutCapture = 0
+	b.PositionHistory = map[string]int{b.positionKey(side): 1}
+	return nil
+}
+
+// parseSquareRange parses a square number, or a range of squares like "1-12".
+func parseSquareRange(s string) (int, int, error) {
+	from, to, isRange := strings.Cut(s, "-")
+	first, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid square: %q", s)
+	}
+	if !isRange {
+		return first, first, nil
+	}
+	last, err := strconv.Atoi(to)
+	if err != nil || last < first {
+		return 0, 0, fmt.Errorf("invalid square range: %q", s)
+	}
+	return first, last, nil
+}
+
+// Hash returns the Zobrist hash of the position with the side to move of the board.
+func (b *DamasBoard) Hash() uint64 {
+	return b.hash(b.SideToMove)
+}
+
+func (b *DamasBoard) hash(sideToMove string) uint64 {
+	var h uint64
+	for n, pos := range damasSquares(b.Rules().Size) {
+		p := b.Grid[pos]
+		if p == nil {
+			continue
+		}
+		kind := 0
+		if p.Type == "w" {
+			kind = 1
+		}
+		if p.IsKinged {
+			kind += 2
+		}
+		h ^= zobristKeys[zobristDamasPieces+kind*100+n]
+	}
+	if sideToMove == "w" {
+		h ^= zobristKeys[zobristDamasSide]
+	}
+	return h
+}
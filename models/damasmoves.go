@@ -0,0 +1,98 @@
+package models
+
+import "fmt"
+
+// Move generation for the damas board, used by the house bot.
+
+// PlayerMoves lists every valid move of the player, following the same rules as ValidateMove. During a
+// capture sequence only the moves of the locked piece are listed.
+func (b *DamasBoard) PlayerMoves(playerID string) []MoveInterface {
+	rules := b.Rules()
+	var moves []MoveInterface
+	for pos, piece := range b.Grid {
+		if piece == nil || piece.PlayerID != playerID || (b.CaptureLock != "" && pos != b.CaptureLock) {
+			continue
+		}
+		var candidates []*Move
+		for _, step := range b.captureSteps(b.Grid, pos, piece, nil) {
+			candidates = append(candidates, &Move{PlayerID: playerID, PieceID: piece.PieceID, From: pos, To: step.to, IsCapture: true})
+		}
+		row, col, err := parseBoardPosition(pos, rules.Size)
+		if err != nil {
+			continue
+		}
+		reach := 1
+		if piece.IsKinged && rules.FlyingKings {
+			reach = rules.Size
+		}
+		for _, dir := range damasDirections {
+			if !piece.IsKinged && dir[0] != b.GetPieceDirection(*piece) {
+				continue
+			}
+			r, c := row+rune(dir[0]), col+dir[1]
+			for i := 0; i < reach && isInBoardBounds(r, c, rules.Size); i++ {
+				to := fmt.Sprintf("%c%d", r, c)
+				if b.Grid[to] != nil {
+					break
+				}
+				candidates = append(candidates, &Move{PlayerID: playerID, PieceID: piece.PieceID, From: pos, To: to})
+				r, c = r+rune(dir[0]), c+dir[1]
+			}
+		}
+		for _, move := range candidates {
+			if b.checkMove(move, piece) == nil {
+				moves = append(moves, move)
+			}
+		}
+	}
+	return moves
+}
+
+// Clone returns a copy of the board, pieces are copied so the copy can be changed freely.
+func (b *DamasBoard) Clone() *DamasBoard {
+	grid := make(map[string]*DamasPiece, len(b.Grid))
+	for pos, p := range b.Grid {
+		if p != nil {
+			cp := *p
+			grid[pos] = &cp
+		} else {
+			grid[pos] = nil
+		}
+	}
+	history := make(map[string]int, len(b.PositionHistory))
+	for k, v := range b.PositionHistory {
+		history[k] = v
+	}
+	clone := *b
+	clone.Grid = grid
+	clone.PositionHistory = history
+	return &clone
+}
+
+// ApplyMove moves the piece, removes the captured piece and crowns the piece when it reaches the last row,
+// like Game.MovePiece and the damas worker do. The move is expected to be validated before.
+func (b *DamasBoard) ApplyMove(move MoveInterface) error {
+	piece := b.Grid[move.GetFrom()]
+	if piece == nil {
+		return fmt.Errorf("no piece at %s", move.GetFrom())
+	}
+	captured := ""
+	if move.IsCaptureMove() {
+		captured = b.CapturedSquare(move.GetFrom(), move.GetTo())
+	}
+	if err := b.MovePiece(move.GetFrom(), move.GetTo()); err != nil {
+		return err
+	}
+	if captured != "" {
+		b.RemovePiece(captured)
+	}
+	// Same as WasPieceKinged, without the logs, the bot applies a lot of moves.
+	lastRow := b.Rules().LastRow()
+	if piece.Type == "w" {
+		lastRow = 'A'
+	}
+	if rune(move.GetTo()[0]) == lastRow {
+		piece.IsKinged = true
+	}
+	return nil
+}
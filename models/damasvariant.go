@@ -0,0 +1,80 @@
+package models
+
+import "fmt"
+
+// DamasVariant holds the rules that change between the draughts variants we support.
+// The board keeps the variant name, and the rules are looked up with GetDamasVariant.
+type DamasVariant struct {
+	Name                string `json:"name"`
+	Size                int    `json:"size"`                   // Squares per side, the board is Size x Size.
+	RowsOfMen           int    `json:"rows_of_men"`            // Rows filled with men for each player at the start.
+	MenCaptureBackwards bool   `json:"men_capture_backwards"`  // Men can capture backwards, they still only move forward.
+	FlyingKings         bool   `json:"flying_kings"`           // Kings move and capture over any distance.
+	MajorityCapture     bool   `json:"majority_capture"`       // The player must take the capture sequence that takes the most pieces.
+	KingedCaptureGoesOn bool   `json:"kinged_capture_goes_on"` // A man crowned in the middle of a capture keeps capturing, as a king.
+}
+
+const (
+	DamasVariantClassic       = "classic"
+	DamasVariantBrazilian     = "brazilian"
+	DamasVariantInternational = "international"
+	DamasVariantAmerican      = "american"
+	DamasVariantRussian       = "russian"
+)
+
+// DamasVariants are the supported variants. Classic is the ruleset the game had before the variants, it is
+// the one used when no variant is set.
+var DamasVariants = map[string]DamasVariant{
+	DamasVariantClassic: {
+		Name:        DamasVariantClassic,
+		Size:        8,
+		RowsOfMen:   3,
+		FlyingKings: true,
+	},
+	DamasVariantBrazilian: {
+		Name:                DamasVariantBrazilian,
+		Size:                8,
+		RowsOfMen:           3,
+		MenCaptureBackwards: true,
+		FlyingKings:         true,
+		MajorityCapture:     true,
+	},
+	DamasVariantInternational: {
+		Name:                DamasVariantInternational,
+		Size:                10,
+		RowsOfMen:           4,
+		MenCaptureBackwards: true,
+		FlyingKings:         true,
+		MajorityCapture:     true,
+	},
+	DamasVariantAmerican: {
+		Name:      DamasVariantAmerican,
+		Size:      8,
+		RowsOfMen: 3,
+	},
+	DamasVariantRussian: {
+		Name:                DamasVariantRussian,
+		Size:                8,
+		RowsOfMen:           3,
+		MenCaptureBackwards: true,
+		FlyingKings:         true,
+		KingedCaptureGoesOn: true,
+	},
+}
+
+// GetDamasVariant returns the rules of a variant, an empty name is the classic variant.
+func GetDamasVariant(name string) (DamasVariant, error) {
+	if name == "" {
+		name = DamasVariantClassic
+	}
+	variant, ok := DamasVariants[name]
+	if !ok {
+		return DamasVariant{}, fmt.Errorf("unknown damas variant: %s", name)
+	}
+	return variant, nil
+}
+
+// LastRow is the letter of the last row of the board, "H" on 8x8 and "J" on 10x10.
+func (v DamasVariant) LastRow() rune {
+	return 'A' + rune(v.Size-1)
+}
@@ -0,0 +1,24 @@
+package models
+
+// Game over reasons shared by all games. Draws and aborted games have no winner, and both bets are refunded.
+const (
+	ReasonResign     = "resign"
+	ReasonAborted    = "aborted" // The game ended before any move was made.
+	ReasonDrawAgreed = "draw_agreed"
+
+	DamasReasonThreefoldRepetition     = "threefold_repetition"
+	DamasReasonKingMovesWithoutCapture = "king_moves_without_capture"
+)
+
+// ResignRequest is what serverws sends over to the gameworker, for the confirm_resign and abort_game commands.
+type ResignRequest struct {
+	PlayerID string `json:"player_id"`
+	Command  string `json:"command"` // "resign" or "abort"
+}
+
+// DrawRequest is what serverws sends over to the gameworker, for the offer_draw and respond_draw commands.
+type DrawRequest struct {
+	PlayerID string `json:"player_id"`
+	Command  string `json:"command"` // "offer_draw" or "respond_draw"
+	Accept   bool   `json:"accept"`  // Only used by respond_draw.
+}
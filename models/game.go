@@ -18,36 +18,51 @@ type GamePlayer struct {
 	Color     string `json:"color"`
 	SessionID string `json:"session_id"`
 	NumPieces int    `json:"num_pieces"`
+	IsBot     bool   `json:"is_bot"`
 }
 
 type Game struct {
-	ID                 string             `json:"id"`
-	Board              Board              `json:"board"`
-	Players            []GamePlayer       `json:"players"`
-	CurrentPlayerID    string             `json:"current_player_id"`
-	Turn               int                `json:"turn"`
-	Moves              []MoveInterface    `json:"moves"`
-	StartTime          time.Time          `json:"start_time"`
-	EndTime            time.Time          `json:"end_time"`
-	Winner             string             `json:"winner"`
-	BetValue           float64            `json:"bet_value"` // Bet amount for the game
-	TimerSetting       string             `json:"timer_settings"`
-	OperatorIdentifier OperatorIdentifier `json:"operator_identifier"`
+	ID                  string             `json:"id"`
+	Board               Board              `json:"board"`
+	Players             []GamePlayer       `json:"players"`
+	CurrentPlayerID     string             `json:"current_player_id"`
+	Turn                int                `json:"turn"`
+	Moves               []MoveInterface    `json:"moves"`
+	StartTime           time.Time          `json:"start_time"`
+	EndTime             time.Time          `json:"end_time"`
+	Winner              string             `json:"winner"`
+	BetValue            Money              `json:"bet_value"` // Bet amount for the game
+	TimerSetting        string             `json:"timer_settings"`
+	OperatorIdentifier  OperatorIdentifier `json:"operator_identifier"`
+	DrawOfferedBy       string             `json:"draw_offered_by"` // Player with a pending draw offer, empty when there is none.
+	TimeControl         TimeControl        `json:"time_control"`
+	Version             int64              `json:"version"`               // Bumped on every save to redis, see RedisClient.UpdateGame.
+	LastMoveSeq         map[string]int64   `json:"last_move_seq"`         // Sequence number of the last accepted move of each player.
+	TakebackRequestedBy string             `json:"takeback_requested_by"` // Player with a pending takeback request, empty when there is none.
+	Snapshots           []GameSnapshot     `json:"snapshots,omitempty"`   // Start of the last turns, only in games without a stake, see SaveSnapshot.
+	FreePlay            bool               `json:"free_play"`             // Practice game, there are no wallet transactions.
 }
 
 type rawGame struct {
-	ID                 string             `json:"id"`
-	Board              json.RawMessage    `json:"board"`
-	OperatorIdentifier OperatorIdentifier `json:"operator_identifier"`
-	Players            []GamePlayer       `json:"players"`
-	CurrentPlayerID    string             `json:"current_player_id"`
-	Turn               int                `json:"turn"`
-	Moves              []json.RawMessage  `json:"moves"` // raw moves
-	StartTime          time.Time          `json:"start_time"`
-	EndTime            time.Time          `json:"end_time"`
-	Winner             string             `json:"winner"`
-	BetValue           float64            `json:"bet_value"`
-	TimerSettings      string             `json:"timer_settings"`
+	ID                  string             `json:"id"`
+	Board               json.RawMessage    `json:"board"`
+	OperatorIdentifier  OperatorIdentifier `json:"operator_identifier"`
+	Players             []GamePlayer       `json:"players"`
+	CurrentPlayerID     string             `json:"current_player_id"`
+	Turn                int                `json:"turn"`
+	Moves               []json.RawMessage  `json:"moves"` // raw moves
+	StartTime           time.Time          `json:"start_time"`
+	EndTime             time.Time          `json:"end_time"`
+	Winner              string             `json:"winner"`
+	BetValue            Money              `json:"bet_value"`
+	TimerSettings       string             `json:"timer_settings"`
+	DrawOfferedBy       string             `json:"draw_offered_by"`
+	TimeControl         TimeControl        `json:"time_control"`
+	Version             int64              `json:"version"`
+	LastMoveSeq         map[string]int64   `json:"last_move_seq"`
+	TakebackRequestedBy string             `json:"takeback_requested_by"`
+	Snapshots           []GameSnapshot     `json:"snapshots,omitempty"`
+	FreePlay            bool               `json:"free_play"`
 }
 
 func UnmarshalGame(data []byte) (*Game, error) {
@@ -56,22 +71,9 @@ func UnmarshalGame(data []byte) (*Game, error) {
 		return nil, err
 	}
 
-	var board Board
-	switch rg.OperatorIdentifier.GameName {
-	case "BatalhaDoChess":
-		var cb ChessBoard
-		if err := json.Unmarshal(rg.Board, &cb); err != nil {
-			return nil, err
-		}
-		board = &cb
-	case "BatalhaDasDamas":
-		var db DamasBoard
-		if err := json.Unmarshal(rg.Board, &db); err != nil {
-			return nil, err
-		}
-		board = &db
-	default:
-		return nil, fmt.Errorf("unknown game type: %s", rg.OperatorIdentifier.GameName)
+	board, err := UnmarshalBoard(rg.OperatorIdentifier.GameName, rg.Board)
+	if err != nil {
+		return nil, err
 	}
 
 	// decode moves
@@ -83,32 +85,59 @@ func UnmarshalGame(data []byte) (*Game, error) {
 			if err := json.Unmarshal(raw, &m); err != nil {
 				return nil, err
 			}
-			moves = append(moves, m)
+			moves = append(moves, &m)
 		case "BatalhaDasDamas":
 			var m Move // plain move
 			if err := json.Unmarshal(raw, &m); err != nil {
 				return nil, err
 			}
-			moves = append(moves, m)
+			moves = append(moves, &m)
 		}
 	}
 
 	return &Game{
-		ID:                 rg.ID,
-		Board:              board,
-		Players:            rg.Players,
-		CurrentPlayerID:    rg.CurrentPlayerID,
-		Turn:               rg.Turn,
-		Moves:              moves,
-		StartTime:          rg.StartTime,
-		EndTime:            rg.EndTime,
-		Winner:             rg.Winner,
-		BetValue:           rg.BetValue,
-		TimerSetting:       rg.TimerSettings,
-		OperatorIdentifier: rg.OperatorIdentifier,
+		ID:                  rg.ID,
+		Board:               board,
+		Players:             rg.Players,
+		CurrentPlayerID:     rg.CurrentPlayerID,
+		Turn:                rg.Turn,
+		Moves:               moves,
+		StartTime:           rg.StartTime,
+		EndTime:             rg.EndTime,
+		Winner:              rg.Winner,
+		BetValue:            rg.BetValue,
+		TimerSetting:        rg.TimerSettings,
+		OperatorIdentifier:  rg.OperatorIdentifier,
+		DrawOfferedBy:       rg.DrawOfferedBy,
+		TimeControl:         rg.TimeControl,
+		Version:             rg.Version,
+		LastMoveSeq:         rg.LastMoveSeq,
+		TakebackRequestedBy: rg.TakebackRequestedBy,
+		Snapshots:           rg.Snapshots,
+		FreePlay:            rg.FreePlay,
 	}, nil
 }
 
+// UnmarshalBoard reads the board of the game type.
+func UnmarshalBoard(gameName string, raw []byte) (Board, error) {
+	switch gameName {
+	case "BatalhaDoChess":
+		var cb ChessBoard
+		if err := json.Unmarshal(raw, &cb); err != nil {
+			return nil, err
+		}
+		return &cb, nil
+	case "BatalhaDasDamas":
+		var db DamasBoard
+		if err := json.Unmarshal(raw, &db); err != nil {
+			return nil, err
+		}
+		return &db, nil
+	default:
+		return nil, fmt.Errorf("unknown game type: %s", gameName)
+	}
+}
+
 // Define the interface
 type MoveInterface interface {
 	GetPlayerID() string
@@ -118,29 +147,37 @@ type MoveInterface interface {
 	IsCaptureMove() bool
 	IsKingedMove() bool
 	SetIsKingedMove(bool)
+	GetSeq() int64
+	GetExpectedTurn() *int
 }
 type Move struct {
-	PlayerID  string `json:"player_id"`
-	PieceID   string `json:"piece_id"`
-	From      string `json:"from"`
-	To        string `json:"to"`
-	IsCapture bool   `json:"is_capture"`
-	IsKinged  bool   `json:"is_kinged"`
+	PlayerID     string `json:"player_id"`
+	PieceID      string `json:"piece_id"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	IsCapture    bool   `json:"is_capture"`
+	IsKinged     bool   `json:"is_kinged"`
+	Seq          int64  `json:"seq,omitempty"`           // Client sequence number, to recognise resends.
+	ExpectedTurn *int   `json:"expected_turn,omitempty"` // The turn the client made the move for.
 }
 
-func (m Move) GetPlayerID() string    { return m.PlayerID }
-func (m Move) GetPieceID() string     { return m.PieceID }
-func (m Move) GetFrom() string        { return m.From }
-func (m Move) GetTo() string          { return m.To }
-func (m Move) IsCaptureMove() bool    { return m.IsCapture }
-func (m Move) SetIsKingedMove(b bool) { m.IsCapture = b }
-func (m Move) IsKingedMove() bool     { return m.IsKinged }
+func (m *Move) GetPlayerID() string    { return m.PlayerID }
+func (m *Move) GetPieceID() string     { return m.PieceID }
+func (m *Move) GetFrom() string        { return m.From }
+func (m *Move) GetTo() string          { return m.To }
+func (m *Move) IsCaptureMove() bool    { return m.IsCapture }
+func (m *Move) SetIsKingedMove(b bool) { m.IsKinged = b }
+func (m *Move) IsKingedMove() bool     { return m.IsKinged }
+func (m *Move) GetSeq() int64          { return m.Seq }
+func (m *Move) GetExpectedTurn() *int  { return m.ExpectedTurn }
 
 type ChessMove struct {
 	Move                  // embed base move
 	PromotionPiece string `json:"promotion_piece"`
 }
 
+func (m *ChessMove) GetPromotionPiece() string { return m.PromotionPiece }
+
 func UnmarshalMove(raw []byte, gameName string) (MoveInterface, error) {
 	switch gameName {
 	case "BatalhaDasDamas":
@@ -148,14 +185,14 @@ func UnmarshalMove(raw []byte, gameName string) (MoveInterface, error) {
 		if err := json.Unmarshal(raw, &m); err != nil {
 			return nil, err
 		}
-		return m, nil
+		return &m, nil
 
 	case "BatalhaDoChess":
 		var m ChessMove
 		if err := json.Unmarshal(raw, &m); err != nil {
 			return nil, err
 		}
-		return m, nil
+		return &m, nil
 
 	default:
 		return nil, fmt.Errorf("unknown game type: %s", gameName)
@@ -173,6 +210,7 @@ func MapPlayerToGamePlayer(player Player) GamePlayer {
 		Token:     player.Token,
 		SessionID: player.SessionID,
 		Timer:     0,
+		IsBot:     player.IsBot,
 	}
 }
 
@@ -193,7 +231,7 @@ func (r *Room) NewGame() *Game {
 	whiteID, _ := r.GetOpponentPlayerID(r.CurrentPlayerID)
 	game := Game{
 		ID:    r.ID,
-		Board: NewBoard(r.CurrentPlayerID, whiteID, "std-game", r.OperatorIdentifier.GameName),
+		Board: NewBoard(r.CurrentPlayerID, whiteID, "std-game", r.OperatorIdentifier.GameName, r.Variant),
 		//Board:           *NewBoard(r.CurrentPlayerID, whiteID, "two-pieces-endgame"),
 		//Board:           *NewBoard(r.CurrentPlayerID, whiteID, "multiple-capture"),
 		Players:            mapPlayers(r),
@@ -205,6 +243,11 @@ func (r *Room) NewGame() *Game {
 		BetValue:           r.BetValue,
 		TimerSetting:       config.Cfg.Services["gameworker"].TimerSetting,
 		OperatorIdentifier: r.OperatorIdentifier,
+		TimeControl:        r.TimeControl,
+		FreePlay:           r.FreePlay,
+	}
+	if r.TimeControl.IsSet() {
+		game.TimerSetting = TimerSettingClock
 	}
 
 	if game.Players[0].ID == whiteID {
@@ -216,6 +259,7 @@ func (r *Room) NewGame() *Game {
 	}
 	game.SetUpPlayerTimers()
 	game.UpdatePlayerPieces() // Set NumPieces for each player
+	game.SaveSnapshot()
 	return &game
 }
 
@@ -231,6 +275,9 @@ func (g *Game) SetUpPlayerTimers() {
 		g.Players[0].Timer = calculatedTimer + 1
 		g.Players[1].Timer = g.Players[0].Timer
 
+	case TimerSettingClock:
+		g.Players[0].Timer = g.TimeControl.Base
+		g.Players[1].Timer = g.Players[0].Timer
 	}
 }
 
@@ -241,6 +288,9 @@ func (g *Game) CalcGameMaxTimer() (int, error) {
 
 	case "cumulative":
 		return config.Cfg.Services["gameworker"].Timer * config.Cfg.Services["gameworker"].PiecesInMatch, nil
+
+	case TimerSettingClock:
+		return g.TimeControl.Base, nil
 	}
 	return 0, nil
 }
@@ -362,25 +412,10 @@ func (g *Game) MovePiece(move MoveInterface) bool {
 	// Handle capture
 	if move.IsCaptureMove() {
 		var capturePos string
-		if piece.IsPieceKinged() {
-			// For kinged pieces, the captured piece is the last square before the landing position
-			fromRow, fromCol := move.GetFrom()[0], move.GetFrom()[1]
-			toRow, toCol := move.GetTo()[0], move.GetTo()[1]
-
-			// Calculate the direction of movement
-			rowStep := 1
-			if toRow < fromRow {
-				rowStep = -1
-			}
-			colStep := 1
-			if toCol < fromCol {
-				colStep = -1
-			}
-
-			// Calculate the position of the captured piece
-			captureRow := toRow - byte(rowStep)
-			captureCol := toCol - byte(colStep)
-			capturePos = fmt.Sprintf("%c%c", captureRow, captureCol)
+		if db, ok := g.Board.(*DamasBoard); ok {
+			// Kings can fly, the captured piece is not always next to the landing square, and bigger boards
+			// have two digit columns, so the damas board finds it.
+			capturePos = db.CapturedSquare(move.GetFrom(), move.GetTo())
 		} else {
 			// For regular pieces, the captured piece is in the middle of the from and to positions
 			midRow := (move.GetFrom()[0] + move.GetTo()[0]) / 2
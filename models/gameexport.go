@@ -0,0 +1,210 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Export of finished games in standard notation, PGN for chess and PDN for damas, so they can be read
+// and replayed by any chess or draughts software.
+//
+// Black moves first on our games, so both exports carry the starting position on the FEN tag.
+
+const (
+	ResultWhiteWins  = "1-0"
+	ResultBlackWins  = "0-1"
+	ResultDraw       = "1/2-1/2"
+	ResultNoDecision = "*" // Aborted games.
+)
+
+// GameResult returns the result of the game in PGN/PDN notation.
+func GameResult(game *Game, reason string) string {
+	if game.Winner == "" {
+		if reason == ReasonAborted {
+			return ResultNoDecision
+		}
+		return ResultDraw
+	}
+	winner, err := game.GetGamePlayer(game.Winner)
+	if err != nil {
+		return ResultNoDecision
+	}
+	if winner.Color == "w" {
+		return ResultWhiteWins
+	}
+	return ResultBlackWins
+}
+
+// GamePlayersByColor returns the black and the white players of the game.
+func GamePlayersByColor(game *Game) (black, white GamePlayer, err error) {
+	for _, p := range game.Players {
+		switch p.Color {
+		case "b":
+			black = p
+		case "w":
+			white = p
+		}
+	}
+	if black.ID == "" || white.ID == "" {
+		return black, white, fmt.Errorf("game with id: %v does not have a black and a white player", game.ID)
+	}
+	return black, white, nil
+}
+
+// exportTags are the tags shared by PGN and PDN, the seven tag roster first and then our own.
+func exportTags(game *Game, reason string, black, white GamePlayer) [][2]string {
+	name := func(p GamePlayer) string {
+		if p.Name == "" {
+			return "?"
+		}
+		return p.Name
+	}
+	date := "????.??.??"
+	if !game.StartTime.IsZero() {
+		date = game.StartTime.UTC().Format("2006.01.02")
+	}
+	return [][2]string{
+		{"Event", game.OperatorIdentifier.GameName},
+		{"Site", game.OperatorIdentifier.OperatorName},
+		{"Date", date},
+		{"Round", "-"},
+		{"White", name(white)},
+		{"Black", name(black)},
+		{"Result", GameResult(game, reason)},
+		{"GameId", game.ID},
+		{"WhiteId", white.ID},
+		{"BlackId", black.ID},
+		{"Operator", game.OperatorIdentifier.OperatorName},
+		{"OperatorGame", game.OperatorIdentifier.OperatorGameName},
+		{"Bet", game.BetValue.String()},
+		{"Currency", game.BetValue.Currency},
+		{"WinFactor", strconv.FormatFloat(game.WinFactor(), 'f', -1, 64)},
+		{"Reason", reason},
+	}
+}
+
+func writeTags(sb *strings.Builder, tags [][2]string) {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	for _, tag := range tags {
+		fmt.Fprintf(sb, "[%s \"%s\"]\n", tag[0], escaper.Replace(tag[1]))
+	}
+	sb.WriteString("\n")
+}
+
+// writeMovetext writes the tokens of the moves and the result, breaking the lines before 80 characters.
+func writeMovetext(sb *strings.Builder, tokens []string, result string) {
+	line := 0
+	for _, token := range append(tokens, result) {
+		if line > 0 && line+1+len(token) > 79 {
+			sb.WriteString("\n")
+			line = 0
+		}
+		if line > 0 {
+			sb.WriteString(" ")
+			line++
+		}
+		sb.WriteString(token)
+		line += len(token)
+	}
+	sb.WriteString("\n")
+}
+
+// ExportPGN writes a chess game in PGN, the moves are replayed from the initial position to get their SAN.
+func ExportPGN(game *Game, reason string) (string, error) {
+	if game.OperatorIdentifier.GameName != "BatalhaDoChess" {
+		return "", fmt.Errorf("(ExportPGN) - pgn is only available for chess games, game with id: %v is %v", game.ID, game.OperatorIdentifier.GameName)
+	}
+	black, white, err := GamePlayersByColor(game)
+	if err != nil {
+		return "", fmt.Errorf("(ExportPGN) - %v", err)
+	}
+	board := &ChessBoard{Grid: make(map[string]*ChessPiece)}
+	board.GenerateInitialBoard(black.ID, white.ID)
+
+	tags := exportTags(game, reason, black, white)
+	tags = append(tags, [2]string{"SetUp", "1"}, [2]string{"FEN", board.ToFEN()})
+
+	var tokens []string
+	for i, move := range game.Moves {
+		piece := board.Grid[move.GetFrom()]
+		if piece == nil {
+			return "", fmt.Errorf("(ExportPGN) - move %d: no piece at %s", i+1, move.GetFrom())
+		}
+		san, err := board.SAN(move)
+		if err != nil {
+			return "", fmt.Errorf("(ExportPGN) - move %d: %v", i+1, err)
+		}
+		if piece.Color == "w" {
+			tokens = append(tokens, fmt.Sprintf("%d.", board.FullmoveNumber))
+		} else if i == 0 {
+			tokens = append(tokens, fmt.Sprintf("%d...", board.FullmoveNumber))
+		}
+		tokens = append(tokens, san)
+		if err := board.ApplyMove(move); err != nil {
+			return "", fmt.Errorf("(ExportPGN) - move %d: %v", i+1, err)
+		}
+	}
+
+	var sb strings.Builder
+	writeTags(&sb, tags)
+	writeMovetext(&sb, tokens, GameResult(game, reason))
+	return sb.String(), nil
+}
+
+// ExportPDN writes a damas game in PDN. Each step of a multi-jump is saved as its own move, here they are
+// joined in a single move like "9x18x27".
+func ExportPDN(game *Game, reason string) (string, error) {
+	if game.OperatorIdentifier.GameName != "BatalhaDasDamas" {
+		return "", fmt.Errorf("(ExportPDN) - pdn is only available for damas games, game with id: %v is %v", game.ID, game.OperatorIdentifier.GameName)
+	}
+	black, white, err := GamePlayersByColor(game)
+	if err != nil {
+		return "", fmt.Errorf("(ExportPDN) - %v", err)
+	}
+	board := &DamasBoard{Grid: make(map[string]*DamasPiece), Variant: game.OperatorIdentifier.Variant}
+	board.GenerateInitialBoard(black.ID, white.ID)
+	rules := board.Rules()
+	squareNumbers := make(map[string]int)
+	for n, pos := range damasSquares(rules.Size) {
+		squareNumbers[pos] = n + 1
+	}
+
+	tags := exportTags(game, reason, black, white)
+	tags = append(tags, [2]string{"Variant", rules.Name}, [2]string{"FEN", board.ToFEN()})
+
+	var tokens []string
+	moveNumber := 0
+	var last MoveInterface
+	for i, move := range game.Moves {
+		from, okFrom := squareNumbers[move.GetFrom()]
+		to, okTo := squareNumbers[move.GetTo()]
+		if !okFrom || !okTo {
+			return "", fmt.Errorf("(ExportPDN) - move %d: invalid squares %s-%s", i+1, move.GetFrom(), move.GetTo())
+		}
+		if last != nil && last.IsCaptureMove() && move.IsCaptureMove() &&
+			last.GetPlayerID() == move.GetPlayerID() && last.GetTo() == move.GetFrom() {
+			tokens[len(tokens)-1] += fmt.Sprintf("x%d", to)
+			last = move
+			continue
+		}
+		if move.GetPlayerID() == black.ID {
+			moveNumber++
+			tokens = append(tokens, fmt.Sprintf("%d.", moveNumber))
+		} else if last == nil {
+			moveNumber++
+			tokens = append(tokens, fmt.Sprintf("%d...", moveNumber))
+		}
+		separator := "-"
+		if move.IsCaptureMove() {
+			separator = "x"
+		}
+		tokens = append(tokens, fmt.Sprintf("%d%s%d", from, separator, to))
+		last = move
+	}
+
+	var sb strings.Builder
+	writeTags(&sb, tags)
+	writeMovetext(&sb, tokens, GameResult(game, reason))
+	return sb.String(), nil
+}
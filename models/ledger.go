@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Status of a wallet transaction in the ledger. An entry is saved as pending before it is posted, sent while
+// the post is in flight, and confirmed or failed with the answer of the operator. A bet that got no answer is
+// unknown, the operator may have taken the money, so it is rolled back like a confirmed one if its game never
+// started. Rolled back bets were given back to the player with a rollback entry.
+const (
+	LedgerPending    = "pending"
+	LedgerSent       = "sent"
+	LedgerConfirmed  = "confirmed"
+	LedgerFailed     = "failed"
+	LedgerUnknown    = "unknown"
+	LedgerRolledBack = "rolled_back"
+)
+
+// A failed post is tried again this many times, then it is left failed for the reconciliation.
+const LedgerMaxAttempts = 10
+
+// LedgerEntry is a wallet transaction posted, or to be posted, to an operator. The TransactionID is the one
+// sent to the operator, every resend uses it again so the operator can tell it is the same transaction.
+type LedgerEntry struct {
+	TransactionID string          `json:"transaction_id"`
+	SessionID     string          `json:"session_id"`
+	Type          string          `json:"type"` // "bet", "win", "refund" or "rollback"
+	Amount        int64           `json:"amount"`
+	Currency      string          `json:"currency"`
+	Operator      string          `json:"operator"`
+	Game          string          `json:"game"`
+	RoundID       string          `json:"round_id"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at,omitempty"` // Nil when it is not going to be tried again.
+	LastError     string          `json:"last_error,omitempty"`
+	Payload       json.RawMessage `json:"payload"`            // Body posted to the operator.
+	Session       json.RawMessage `json:"session"`            // Session at the time, for the resends after the session is gone.
+	Response      json.RawMessage `json:"response,omitempty"` // Answer of the operator, once confirmed.
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// LedgerBackoff is the wait before the next try of an entry that failed after the attempts.
+func LedgerBackoff(attempts int) time.Duration {
+	wait := 30 * time.Second
+	for i := 1; i < attempts && wait < time.Hour; i++ {
+		wait *= 2
+	}
+	return min(wait, time.Hour)
+}
+
+// RoundBalance sums up the ledger of a round, for the reconciliation report. Amounts are in cents and only
+// count confirmed entries.
+type RoundBalance struct {
+	RoundID        string `json:"round_id"`
+	GameOverReason string `json:"game_over_reason"` // Empty when the game was never saved.
+	Winner         string `json:"winner,omitempty"`
+	Bets           int64  `json:"bets"`
+	Wins           int64  `json:"wins"`
+	Refunds        int64  `json:"refunds"`
+	RolledBack     int64  `json:"rolled_back"`
+	Unsettled      int    `json:"unsettled"` // Entries still pending, sent, failed or unknown.
+	Issue          string `json:"issue"`
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// LiveGame is the summary of a game in progress, used by the lobby listings. The ids of the players are their
+// session ids, so only the operator of the game gets them, the public listings and feeds use Anonymous.
+type LiveGame struct {
+	GameID          string           `json:"game_id"`
+	GameName        string           `json:"game_name"`
+	OperatorName    string           `json:"operator_name,omitempty"`
+	BetValue        json.Number      `json:"bet_value"`
+	Currency        string           `json:"currency"`
+	Turn            int              `json:"turn"`
+	CurrentColor    string           `json:"current_color,omitempty"` // Only on the anonymous games.
+	CurrentPlayerID string           `json:"current_player_id,omitempty"`
+	Players         []LiveGamePlayer `json:"players"`
+	StartTime       time.Time        `json:"start_time"`
+	Elapsed         int              `json:"elapsed"` // Seconds since the game started.
+}
+
+type LiveGamePlayer struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Color     string `json:"color"`
+	NumPieces int    `json:"num_pieces"`
+	IsBot     bool   `json:"is_bot"`
+}
+
+type LiveGamesPage struct {
+	Games  []LiveGame `json:"games"`
+	Total  int64      `json:"total"`
+	Offset int64      `json:"offset"`
+	Limit  int64      `json:"limit"`
+}
+
+func NewLiveGame(game *Game, now time.Time) LiveGame {
+	live := LiveGame{
+		GameID:          game.ID,
+		GameName:        game.OperatorIdentifier.GameName,
+		OperatorName:    game.OperatorIdentifier.OperatorName,
+		BetValue:        game.BetValue.Number(),
+		Currency:        game.BetValue.Currency,
+		Turn:            game.Turn,
+		CurrentPlayerID: game.CurrentPlayerID,
+		Players:         make([]LiveGamePlayer, 0, len(game.Players)),
+		StartTime:       game.StartTime,
+		Elapsed:         int(now.Sub(game.StartTime).Seconds()),
+	}
+	for _, player := range game.Players {
+		live.Players = append(live.Players, LiveGamePlayer{
+			ID:        player.ID,
+			Name:      player.Name,
+			Color:     player.Color,
+			NumPieces: game.CountPlayerPieces(player.ID),
+			IsBot:     player.IsBot,
+		})
+	}
+	return live
+}
+
+// Anonymous is the game without the operator and the ids and names of the players, for the feeds every
+// operator gets. The player on turn is told by the color.
+func (g LiveGame) Anonymous() LiveGame {
+	anonymous := g
+	anonymous.OperatorName = ""
+	anonymous.CurrentPlayerID = ""
+	anonymous.Players = make([]LiveGamePlayer, 0, len(g.Players))
+	for _, player := range g.Players {
+		if player.ID == g.CurrentPlayerID {
+			anonymous.CurrentColor = player.Color
+		}
+		player.ID = ""
+		player.Name = ""
+		anonymous.Players = append(anonymous.Players, player)
+	}
+	return anonymous
+}
+
+// FeaturedGames picks the games for the lobby feed, highest bets first and, on the same bet, the ones
+// further along. The feed goes to the players of every operator, so the games are anonymous.
+func FeaturedGames(games []LiveGame, count int) []LiveGame {
+	featured := append([]LiveGame(nil), games...)
+	sort.SliceStable(featured, func(i, j int) bool {
+		// Bets of different currencies are compared by their number only, the feed is not an exchange.
+		betI, _ := featured[i].BetValue.Float64()
+		betJ, _ := featured[j].BetValue.Float64()
+		if betI != betJ {
+			return betI > betJ
+		}
+		return featured[i].Turn > featured[j].Turn
+	})
+	if len(featured) > count {
+		featured = featured[:count]
+	}
+	for i := range featured {
+		featured[i] = featured[i].Anonymous()
+	}
+	return featured
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"log"
 	"time"
 
@@ -65,15 +66,19 @@ type Transaction struct {
 }
 
 type OperatorIdentifier struct {
-	OperatorName     string  `json:"operator_name"`
-	OperatorGameName string  `json:"operator_game_name"`
-	GameName         string  `json:"game_name"`
-	WinFactor        float64 `json:"win_factor"`
+	OperatorName     string   `json:"operator_name"`
+	OperatorGameName string   `json:"operator_game_name"`
+	GameName         string   `json:"game_name"`
+	WinFactor        float64  `json:"win_factor"`
+	Variant          string   `json:"variant"`             // Rule variant the operator uses, empty for the default rules.
+	BotEnabled       bool     `json:"bot_enabled"`         // A house bot joins when a player of the operator waits alone in the queue.
+	BetTiers         BetTiers `json:"bet_tiers,omitempty"` // Bets of the operator per currency, the default ones when empty.
 }
 
 type PlayerCountPerBetValue struct {
-	BetValue    float64 `json:"bet_value"`
-	PlayerCount int64   `json:"player_count"`
+	BetValue    json.Number `json:"bet_value"`
+	Currency    string      `json:"currency"`
+	PlayerCount int64       `json:"player_count"`
 }
 
 type QueueNumbersResponse struct {
@@ -81,10 +86,10 @@ type QueueNumbersResponse struct {
 }
 
 type RoomValue struct {
-	ID       string  `json:"id"`
-	Player   string  `json:"name"`
-	Currency string  `json:"currency"`
-	BetValue float64 `json:"bet_value"`
+	ID       string      `json:"id"`
+	Player   string      `json:"name"`
+	Currency string      `json:"currency"`
+	BetValue json.Number `json:"bet_value"`
 }
 
 type CreateRoomMessage struct {
@@ -93,13 +98,14 @@ type CreateRoomMessage struct {
 }
 
 type PairedValue struct {
-	Color         int     `json:"color"`
-	Opponent      string  `json:"opponent"`
-	RoomID        string  `json:"room_id"`
-	Winnings      float64 `json:"winnings"`
-	Timer         int     `json:"timer"`
-	PlayerReady   bool    `json:"player_ready"`
-	OpponentReady bool    `json:"opponent_ready"`
+	Color         int          `json:"color"`
+	Opponent      string       `json:"opponent"`
+	RoomID        string       `json:"room_id"`
+	Winnings      json.Number  `json:"winnings"`
+	Timer         int          `json:"timer"`
+	PlayerReady   bool         `json:"player_ready"`
+	OpponentReady bool         `json:"opponent_ready"`
+	TimeControl   *TimeControl `json:"time_control,omitempty"` // Only sent when the game has a clock.
 }
 
 type QueueConfirmation struct {
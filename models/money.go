@@ -0,0 +1,168 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in the minor units of its currency, cents for EUR and yen for JPY, so there is no float
+// rounding anywhere money is added up or posted.
+type Money struct {
+	Amount   int64  `json:"amount"`   // Minor units.
+	Currency string `json:"currency"` // ISO-4217 code.
+}
+
+// Minor unit exponents of ISO-4217, the currencies that are not here use 2.
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// CurrencyExponent is the number of decimals of the minor unit of the currency.
+func CurrencyExponent(currency string) int {
+	if exp, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exp
+	}
+	return 2
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// ParseMoney reads a decimal amount in major units, like "1.50", without going through a float. Amounts with
+// more decimals than the currency has are an error.
+func ParseMoney(value string, currency string) (Money, error) {
+	return parseMajor(value, currency, true)
+}
+
+// ParseBalance reads a balance of an operator in major units. Unlike ParseMoney the decimals the currency
+// doesn't have are cut, the money already moved and the balance is only shown to the player.
+func ParseBalance(value string, currency string) (Money, error) {
+	return parseMajor(value, currency, false)
+}
+
+func parseMajor(value string, currency string, exact bool) (Money, error) {
+	value = strings.TrimSpace(value)
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount: %q", value)
+	}
+	return MoneyFromRat(rat, currency, exact)
+}
+
+// MoneyFromRat turns an amount in major units into Money. When it is not exact the decimals the currency
+// doesn't have are cut, otherwise they are an error.
+func MoneyFromRat(major *big.Rat, currency string, exact bool) (Money, error) {
+	exp := CurrencyExponent(currency)
+	minor := new(big.Rat).Mul(major, new(big.Rat).SetInt(pow10(exp)))
+	if exact && !minor.IsInt() {
+		return Money{}, fmt.Errorf("amount %s has more than %d decimals for %s", strings.TrimRight(major.FloatString(12), "0"), exp, currency)
+	}
+	amount := new(big.Int).Quo(minor.Num(), minor.Denom())
+	if !amount.IsInt64() {
+		return Money{}, fmt.Errorf("amount out of range: %s", major.FloatString(exp))
+	}
+	return NewMoney(amount.Int64(), currency), nil
+}
+
+// Rat is the amount in major units.
+func (m Money) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), pow10(CurrencyExponent(m.Currency)))
+}
+
+func pow10(exp int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil)
+}
+
+// String is the amount in major units with all the decimals of the currency, like "1.50".
+func (m Money) String() string {
+	exp := CurrencyExponent(m.Currency)
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// Decimal is the amount in major units without the trailing zeros, like "1.5" or "3".
+func (m Money) Decimal() string {
+	s := m.String()
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+// Number is the amount in major units for the JSON sent to the clients, written from the minor units so the
+// client gets exactly the amount.
+func (m Money) Number() json.Number {
+	return json.Number(m.String())
+}
+
+// Key identifies the amount in the redis keys, like "EUR:150".
+func (m Money) Key() string {
+	return fmt.Sprintf("%s:%d", m.Currency, m.Amount)
+}
+
+// ParseMoneyKey reads back a Key.
+func ParseMoneyKey(key string) (Money, error) {
+	currency, amount, ok := strings.Cut(key, ":")
+	if !ok {
+		return Money{}, fmt.Errorf("invalid money key: %q", key)
+	}
+	minor, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid money key: %q", key)
+	}
+	return NewMoney(minor, currency), nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// Float is only for sorting and display, never for math on the amount.
+func (m Money) Float() float64 {
+	f, _ := strconv.ParseFloat(m.String(), 64)
+	return f
+}
+
+// UnmarshalJSON takes the Money object, and the bare numbers saved before it existed, which were in major
+// units with 2 decimals and no currency.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] != '{' && !bytes.Equal(data, []byte("null")) {
+		legacy, err := ParseMoney(string(data), "")
+		if err != nil {
+			return err
+		}
+		*m = legacy
+		return nil
+	}
+	type plain Money
+	return json.Unmarshal(data, (*plain)(m))
+}
+
+// Payout is twice the bet times the win factor of the operator, cut down to the minor unit. The factor is taken
+// to 4 decimals like in the operators table.
+func (m Money) Payout(winFactor float64) Money {
+	factor, _ := new(big.Rat).SetString(strconv.FormatFloat(winFactor, 'f', 4, 64))
+	payout := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount*2), factor)
+	return Money{Amount: new(big.Int).Quo(payout.Num(), payout.Denom()).Int64(), Currency: m.Currency}
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{"150", "JPY", Money{Amount: 150, Currency: "JPY"}, false},
+		{"0", "JPY", Money{Amount: 0, Currency: "JPY"}, false},
+		{"0.5", "JPY", Money{}, true},
+		{"1.50", "EUR", Money{Amount: 150, Currency: "EUR"}, false},
+		{"1.5", "eur", Money{Amount: 150, Currency: "EUR"}, false},
+		{" 25 ", "EUR", Money{Amount: 2500, Currency: "EUR"}, false},
+		{"0.01", "EUR", Money{Amount: 1, Currency: "EUR"}, false},
+		{"1.001", "EUR", Money{}, true},
+		{"1.250", "KWD", Money{Amount: 1250, Currency: "KWD"}, false},
+		{"0.001", "KWD", Money{Amount: 1, Currency: "KWD"}, false},
+		{"0.0005", "KWD", Money{}, true},
+		{"abc", "EUR", Money{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.value, tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q, %q) = %v, want an error", tt.value, tt.currency, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q, %q) error: %v", tt.value, tt.currency, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q, %q) = %+v, want %+v", tt.value, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{NewMoney(150, "JPY"), "150"},
+		{NewMoney(150, "EUR"), "1.50"},
+		{NewMoney(5, "EUR"), "0.05"},
+		{NewMoney(-5, "EUR"), "-0.05"},
+		{NewMoney(1250, "KWD"), "1.250"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.money, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    Money
+		wantErr bool
+	}{
+		{`{"amount":150,"currency":"EUR"}`, Money{Amount: 150, Currency: "EUR"}, false},
+		{`1.5`, Money{Amount: 150}, false},
+		{`3`, Money{Amount: 300}, false},
+		{`0.25`, Money{Amount: 25}, false},
+		{`0.125`, Money{}, true},
+	}
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.data), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Unmarshal(%s) = %+v, want an error", tt.data, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unmarshal(%s) error: %v", tt.data, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyPayout(t *testing.T) {
+	tests := []struct {
+		bet       Money
+		winFactor float64
+		want      Money
+	}{
+		{NewMoney(150, "EUR"), 0.5, NewMoney(150, "EUR")},
+		{NewMoney(150, "EUR"), 1, NewMoney(300, "EUR")},
+		{NewMoney(150, "EUR"), 0.95, NewMoney(285, "EUR")},
+		{NewMoney(1, "EUR"), 0.95, NewMoney(1, "EUR")},
+		{NewMoney(3, "JPY"), 0.5, NewMoney(3, "JPY")},
+		{NewMoney(3, "JPY"), 1, NewMoney(6, "JPY")},
+		{NewMoney(1250, "KWD"), 0.5, NewMoney(1250, "KWD")},
+		{NewMoney(1250, "KWD"), 1, NewMoney(2500, "KWD")},
+	}
+	for _, tt := range tests {
+		if got := tt.bet.Payout(tt.winFactor); got != tt.want {
+			t.Errorf("%+v.Payout(%v) = %+v, want %+v", tt.bet, tt.winFactor, got, tt.want)
+		}
+	}
+}
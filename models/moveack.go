@@ -0,0 +1,31 @@
+package models
+
+// Reasons sent with move_rejected, so the client knows whether to resend the move, wait or resync the board.
+const (
+	MoveRejectBadRequest  = "bad_request"   // The move could not be read.
+	MoveRejectNoPlayer    = "no_player"     // The player of the move is not known.
+	MoveRejectNoGame      = "no_game"       // The player is not in a game, or the game ended.
+	MoveRejectNotYourTurn = "not_your_turn" // It is the opponent's turn.
+	MoveRejectWrongTurn   = "wrong_turn"    // The move was made for another turn than the current one.
+	MoveRejectNoPiece     = "no_piece"      // There is no such piece on the board.
+	MoveRejectIllegal     = "illegal_move"  // The rules don't allow the move, the board state is sent with invalid_move.
+	MoveRejectConflict    = "conflict"      // The game kept changing while the move was applied, it can be resent.
+	MoveRejectInternal    = "internal"      // The server failed to apply the move.
+)
+
+// IsDuplicateMove tells if the move is a resend of a move the game already accepted. Moves without a
+// sequence number are never duplicates.
+func (g *Game) IsDuplicateMove(move MoveInterface) bool {
+	return move.GetSeq() > 0 && move.GetSeq() <= g.LastMoveSeq[move.GetPlayerID()]
+}
+
+// RecordMoveSeq keeps the sequence number of an accepted move, it is saved with the move.
+func (g *Game) RecordMoveSeq(move MoveInterface) {
+	if move.GetSeq() == 0 {
+		return
+	}
+	if g.LastMoveSeq == nil {
+		g.LastMoveSeq = map[string]int64{}
+	}
+	g.LastMoveSeq[move.GetPlayerID()] = move.GetSeq()
+}
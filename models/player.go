@@ -23,14 +23,13 @@ type Player struct {
 	SessionID          string             `json:"session_id"`
 	Currency           string             `json:"currency"`
 	Status             PlayerStatus       `json:"status"`
-	SelectedBet        float64            `json:"selected_bet"`
+	SelectedBet        Money              `json:"selected_bet"`
 	Name               string             `json:"name"`
 	OperatorIdentifier OperatorIdentifier `json:"operator_identifier"`
 	DisconnectedAt     int64              `json:"disconnected_at"` // Unix timestamp
+	IsBot              bool               `json:"is_bot"`          // The house bot, it has no session and no wallet.
 }
 
-var DamasValidBetAmounts = []float64{0.5, 1, 3, 5, 10, 25, 50, 100}
-
 // This map will hold the valid status transition
 var validStatusTransitions = map[PlayerStatus]map[PlayerStatus]bool{
 	StatusOffline: {
@@ -80,7 +79,7 @@ func (p *Player) SetStatusOnline() {
 	p.Status = StatusOnline
 }
 
-func (p *Player) IsEligibleForQueue(queueBet float64) bool {
+func (p *Player) IsEligibleForQueue(queueBet Money) bool {
 	if p == nil || p.Status != StatusInQueue || p.SelectedBet != queueBet {
 		return false
 	}
@@ -0,0 +1,27 @@
+package models
+
+import "encoding/json"
+
+// PremoveRequest is what serverws sends over to the gameworker, for the premove and cancel_premove commands.
+type PremoveRequest struct {
+	PlayerID string          `json:"player_id"`
+	Command  string          `json:"command"` // "premove" or "cancel_premove"
+	Move     json.RawMessage `json:"move,omitempty"`
+}
+
+// Reasons sent with premove_dropped.
+const (
+	PremoveDroppedIllegal   = "illegal_move" // The move is not legal on the position the opponent left.
+	PremoveDroppedNoPiece   = "no_piece"     // The piece was captured, or is not the player's.
+	PremoveDroppedGameEnded = "game_ended"
+)
+
+// StripExpectedTurn removes the expected turn of a premove, it is made before the turn it is played on is known.
+func StripExpectedTurn(raw json.RawMessage) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "expected_turn")
+	return json.Marshal(fields)
+}
@@ -12,10 +12,13 @@ type Room struct {
 	Player2            *Player            `json:"player_2"`
 	StartDate          time.Time          `json:"start_date"`
 	Currency           string             `json:"currency"`  // Currency for the room
-	BetValue           float64            `json:"bet_value"` // Bet amount for the game
+	BetValue           Money              `json:"bet_value"` // Bet amount for the game
 	CurrentPlayerID    string             `json:"current_player_id"`
 	IsRoomOpen         bool               `json:"is_room_open"`
 	OperatorIdentifier OperatorIdentifier `json:"operator_identifier"`
+	Variant            string             `json:"variant"`      // Rule variant of the game, see DamasVariants.
+	TimeControl        TimeControl        `json:"time_control"` // Clock of the game, not set when the game uses the timer setting.
+	FreePlay           bool               `json:"free_play"`    // Practice room, no bets are posted, see IsFreePlayBet.
 }
 
 func (r *Room) GetOpponentPlayerID(playerID string) (string, error) {
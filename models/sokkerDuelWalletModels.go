@@ -9,6 +9,14 @@ type Operator struct {
 	GameBaseUrl           string  `json:"game_base_url"`
 	OperatorWalletBaseUrl string  `json:"operator_wallet_base_url"`
 	WinFactor             float64 `json:"win_factor"`
+	Variant               string  `json:"variant"`
+	BotEnabled            bool    `json:"bot_enabled"`
+	// Set for the operators on the generic wallet, nil for the ones with a module of their own.
+	WalletConfig *WalletConfig `json:"wallet_config,omitempty"`
+	// Set when the calls with the operator are signed, nil for the unsigned ones.
+	Signing *WalletSigning `json:"signing,omitempty"`
+	// Bets the operator offers per currency, the default ones for the currencies that are not in it.
+	BetTiers BetTiers `json:"bet_tiers,omitempty"`
 }
 
 type WalletResponse struct {
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TakebackRequest is what serverws sends over to the gameworker, for the request_takeback and accept_takeback commands.
+type TakebackRequest struct {
+	PlayerID string `json:"player_id"`
+	Command  string `json:"command"` // "request_takeback" or "accept_takeback"
+	Accept   bool   `json:"accept"`  // Only used by accept_takeback, false declines.
+}
+
+// GameSnapshot is the game as it was at the start of a turn, games without a stake keep the last ones so
+// moves can be taken back.
+type GameSnapshot struct {
+	Turn            int             `json:"turn"`
+	CurrentPlayerID string          `json:"current_player_id"`
+	NumMoves        int             `json:"num_moves"`
+	Board           json.RawMessage `json:"board"`
+}
+
+// A takeback goes back at most to the start of the previous turn of the player, so the current turn and the
+// two before it are enough.
+const maxSnapshots = 3
+
+// TakebacksAllowed tells if moves can be taken back, only in the free-play games where nothing is at stake.
+func (g *Game) TakebacksAllowed() bool {
+	return g.FreePlay
+}
+
+// SaveSnapshot keeps the game as it is at the start of the turn, it is called on every turn change.
+func (g *Game) SaveSnapshot() error {
+	if !g.TakebacksAllowed() {
+		return nil
+	}
+	board, err := json.Marshal(g.Board)
+	if err != nil {
+		return fmt.Errorf("(SaveSnapshot) - failed to serialize board: %v", err)
+	}
+	g.Snapshots = append(g.Snapshots, GameSnapshot{
+		Turn:            g.Turn,
+		CurrentPlayerID: g.CurrentPlayerID,
+		NumMoves:        len(g.Moves),
+		Board:           board,
+	})
+	if len(g.Snapshots) > maxSnapshots {
+		g.Snapshots = g.Snapshots[len(g.Snapshots)-maxSnapshots:]
+	}
+	return nil
+}
+
+// takebackSnapshot finds the start of the last turn the player played, the moves of the opponent after it
+// are taken back too. Returns -1 when there is none.
+func (g *Game) takebackSnapshot(playerID string) int {
+	for i := len(g.Snapshots) - 1; i >= 0; i-- {
+		snapshot := g.Snapshots[i]
+		if snapshot.Turn < g.Turn && snapshot.CurrentPlayerID == playerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// CanTakeback tells if the player has a move that can be taken back.
+func (g *Game) CanTakeback(playerID string) bool {
+	return g.TakebacksAllowed() && g.takebackSnapshot(playerID) >= 0
+}
+
+// Takeback puts the game back at the start of the last turn of the player.
+func (g *Game) Takeback(playerID string) error {
+	if !g.TakebacksAllowed() {
+		return fmt.Errorf("(Takeback) - game with id: %v has a stake", g.ID)
+	}
+	i := g.takebackSnapshot(playerID)
+	if i < 0 {
+		return fmt.Errorf("(Takeback) - no move to take back for player with id: %v", playerID)
+	}
+	snapshot := g.Snapshots[i]
+	board, err := UnmarshalBoard(g.OperatorIdentifier.GameName, snapshot.Board)
+	if err != nil {
+		return err
+	}
+	g.Board = board
+	g.Turn = snapshot.Turn
+	g.CurrentPlayerID = snapshot.CurrentPlayerID
+	if snapshot.NumMoves < len(g.Moves) {
+		g.Moves = g.Moves[:snapshot.NumMoves]
+	}
+	// The restored turn keeps its snapshot, it can be taken back again from there.
+	g.Snapshots = g.Snapshots[:i+1]
+	g.TakebackRequestedBy = ""
+	g.DrawOfferedBy = ""
+	g.UpdatePlayerPieces()
+	return nil
+}
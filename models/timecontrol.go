@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Lavizord/checkers-server/config"
+)
+
+// TimerSettingClock is the timer setting of the games with a time control, the other settings are
+// "reset" and "cumulative" from the gameworker config.
+const TimerSettingClock = "clock"
+
+// TimeControl is the clock of a game. Every player starts with Base seconds, and when the player moves
+// gets back Increment seconds (Fischer) and up to Delay seconds of the time the move took (Bronstein).
+// MoveCap, when set, is the most a single move can take, after it the player loses the turn.
+type TimeControl struct {
+	Name      string `json:"name"` // The spec the time control was parsed from, ex: "3+2".
+	Base      int    `json:"base"`
+	Increment int    `json:"increment"`
+	Delay     int    `json:"delay"`
+	MoveCap   int    `json:"move_cap"`
+}
+
+// IsSet tells if the time control has a clock, a game without one uses the gameworker timer setting.
+func (tc TimeControl) IsSet() bool {
+	return tc.Base > 0
+}
+
+// ParseTimeControl reads a time control spec. The base is in minutes, and the rest in seconds:
+//
+//	"3+2"     3 minutes, 2 seconds increment
+//	"5d3"     5 minutes, 3 seconds Bronstein delay
+//	"3+2/30"  3 minutes, 2 seconds increment, 30 seconds at most for each move
+func ParseTimeControl(spec string) (TimeControl, error) {
+	tc := TimeControl{Name: spec}
+	rest := strings.TrimSpace(spec)
+	if i := strings.Index(rest, "/"); i >= 0 {
+		moveCap, err := strconv.Atoi(rest[i+1:])
+		if err != nil || moveCap <= 0 {
+			return TimeControl{}, fmt.Errorf("(ParseTimeControl) - invalid move cap in time control: %q", spec)
+		}
+		tc.MoveCap = moveCap
+		rest = rest[:i]
+	}
+	base, extra, sep := rest, "0", byte('+')
+	if i := strings.IndexAny(rest, "+d"); i >= 0 {
+		base, extra, sep = rest[:i], rest[i+1:], rest[i]
+	}
+	minutes, err := strconv.ParseFloat(base, 64)
+	if err != nil || minutes <= 0 {
+		return TimeControl{}, fmt.Errorf("(ParseTimeControl) - invalid base time in time control: %q", spec)
+	}
+	seconds, err := strconv.Atoi(extra)
+	if err != nil || seconds < 0 {
+		return TimeControl{}, fmt.Errorf("(ParseTimeControl) - invalid increment or delay in time control: %q", spec)
+	}
+	tc.Base = int(minutes * 60)
+	if sep == 'd' {
+		tc.Delay = seconds
+	} else {
+		tc.Increment = seconds
+	}
+	return tc, nil
+}
+
+// TimeControlForBet returns the time control set for the bet tier in the gameworker config. The entry of the
+// currency, like "EUR:0.5", goes first, then the one of the amount alone, like "0.5", and the "default" entry
+// is used for the bets without one. With no entry the time control is not set.
+func TimeControlForBet(bet Money) (TimeControl, error) {
+	controls := config.Cfg.Services["gameworker"].TimeControls
+	spec, ok := controls[bet.Currency+":"+bet.Decimal()]
+	if !ok {
+		spec, ok = controls[bet.Decimal()]
+	}
+	if !ok {
+		spec, ok = controls["default"]
+	}
+	if !ok {
+		return TimeControl{}, nil
+	}
+	return ParseTimeControl(spec)
+}
+
+// MoveCredit is the time given back to a player after a move that took the given seconds.
+func (tc TimeControl) MoveCredit(elapsed int) int {
+	return tc.Increment + min(tc.Delay, elapsed)
+}
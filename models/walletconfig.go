@@ -0,0 +1,169 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Auth schemes of the generic wallet.
+const (
+	WalletAuthNone   = "none"   // Nothing is sent, the operator trusts the network.
+	WalletAuthBearer = "bearer" // The player token goes in the auth header.
+	WalletAuthHMAC   = "hmac"   // The calls are signed with the auth secret, like WalletSigning, the token goes in the body.
+)
+
+// Amount formats of the generic wallet.
+const (
+	WalletAmountMinor = "minor" // Integer amount in the minor unit of the currency, like cents.
+	WalletAmountMajor = "major" // Decimal amount, like 1.50.
+)
+
+// WalletConfig drives the generic seamless wallet, an operator with it set in the operators table needs no
+// module of its own. It covers the usual authenticate / balance / debit / credit / rollback endpoints.
+type WalletConfig struct {
+	Endpoints WalletEndpoints `json:"endpoints"`
+	Auth      WalletAuth      `json:"auth"`
+	// Operator name of each of our request fields, the missing ones keep our name and "-" leaves it out.
+	// Our fields: token, player, amount, currency, transaction_id, round_id, game_id, reference_id.
+	RequestFields map[string]string `json:"request_fields"`
+	// Dot path of each value we read from the answers, like "data.balance". The missing ones are read from
+	// the root with our name. Our fields: player, balance, currency, status, error.
+	ResponseFields map[string]string `json:"response_fields"`
+	// Value of the status field on a good answer. Empty means any 2xx answer is good.
+	SuccessValue     string         `json:"success_value"`
+	AmountFormat     string         `json:"amount_format"`     // "minor" or "major", minor by default.
+	CurrencyDecimals map[string]int `json:"currency_decimals"` // Minor unit decimals of the operator, when they are not the ISO ones.
+	TimeoutSeconds   int            `json:"timeout_seconds"`
+}
+
+// WalletEndpoints are the paths, joined to the operator wallet base url. Without a rollback path the bets are
+// rolled back with a credit.
+type WalletEndpoints struct {
+	Authenticate string `json:"authenticate"`
+	Balance      string `json:"balance"`
+	Debit        string `json:"debit"`
+	Credit       string `json:"credit"`
+	Rollback     string `json:"rollback"`
+}
+
+type WalletAuth struct {
+	Type            string `json:"type"`             // "none", "bearer" or "hmac".
+	Header          string `json:"header"`           // Header of the bearer token, Authorization by default.
+	Secret          string `json:"secret"`           // HMAC key.
+	SignatureHeader string `json:"signature_header"` // Header of the HMAC signature, X-Signature by default.
+}
+
+// Signing is the request signing of the hmac auth, nil for the other schemes.
+func (a *WalletAuth) Signing() *WalletSigning {
+	if a.Type != WalletAuthHMAC {
+		return nil
+	}
+	return &WalletSigning{Secret: a.Secret, SignatureHeader: a.SignatureHeader}
+}
+
+func (c *WalletConfig) Validate() error {
+	if c.Endpoints.Authenticate == "" || c.Endpoints.Debit == "" || c.Endpoints.Credit == "" {
+		return fmt.Errorf("wallet config needs the authenticate, debit and credit endpoints")
+	}
+	switch c.Auth.Type {
+	case "", WalletAuthNone, WalletAuthBearer:
+	case WalletAuthHMAC:
+		if c.Auth.Secret == "" {
+			return fmt.Errorf("wallet config with hmac auth needs a secret")
+		}
+	default:
+		return fmt.Errorf("invalid wallet auth type: %s", c.Auth.Type)
+	}
+	switch c.AmountFormat {
+	case "", WalletAmountMinor, WalletAmountMajor:
+	default:
+		return fmt.Errorf("invalid wallet amount format: %s", c.AmountFormat)
+	}
+	return nil
+}
+
+// RequestField is the operator name of one of our request fields, empty when it is left out.
+func (c *WalletConfig) RequestField(field string) string {
+	name, ok := c.RequestFields[field]
+	if !ok || name == "" {
+		return field
+	}
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// ResponseValue reads one of our fields from an answer of the operator, nil if it is not there.
+func (c *WalletConfig) ResponseValue(body map[string]interface{}, field string) interface{} {
+	path, ok := c.ResponseFields[field]
+	if !ok || path == "" {
+		path = field
+	}
+	var current interface{} = body
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[key]
+	}
+	return current
+}
+
+func (c *WalletConfig) ResponseString(body map[string]interface{}, field string) string {
+	switch v := c.ResponseValue(body, field).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// decimals of the minor unit of the operator, the ISO ones unless the config says otherwise.
+func (c *WalletConfig) decimals(currency string) int {
+	if d, ok := c.CurrencyDecimals[strings.ToUpper(currency)]; ok {
+		return d
+	}
+	return CurrencyExponent(currency)
+}
+
+// ToOperatorAmount turns our amount into the one the operator expects.
+func (c *WalletConfig) ToOperatorAmount(amount Money) interface{} {
+	if c.AmountFormat == WalletAmountMajor {
+		return amount.Number()
+	}
+	minor := new(big.Rat).Mul(amount.Rat(), new(big.Rat).SetInt(pow10(c.decimals(amount.Currency))))
+	return new(big.Int).Quo(minor.Num(), minor.Denom()).Int64()
+}
+
+// FromOperatorAmount turns an amount of the operator, a number or a string, into Money. The decimals we don't
+// keep for the currency are cut.
+func (c *WalletConfig) FromOperatorAmount(value interface{}, currency string) (Money, error) {
+	var text string
+	switch v := value.(type) {
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		text = v.String()
+	case string:
+		text = strings.TrimSpace(v)
+	default:
+		return Money{}, fmt.Errorf("invalid amount: %v", value)
+	}
+	amount, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount: %s", text)
+	}
+	if c.AmountFormat != WalletAmountMajor {
+		amount.Quo(amount, new(big.Rat).SetInt(pow10(c.decimals(currency))))
+	}
+	return MoneyFromRat(amount, currency, false)
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default headers of the signed wallet calls.
+const (
+	DefaultSignatureHeader = "X-Signature"
+	DefaultTimestampHeader = "X-Timestamp"
+	DefaultNonceHeader     = "X-Nonce"
+)
+
+// WalletSigning is the HMAC signing of the calls between us and an operator wallet, both ways. The signature is
+// the hex HMAC-SHA256 of "timestamp.nonce.body" with the secret, timestamp in unix seconds. A signed answer of the
+// operator signs "timestamp.nonce.requestNonce.body", with the nonce of our request.
+type WalletSigning struct {
+	Secret          string `json:"secret"`
+	SignatureHeader string `json:"signature_header"` // X-Signature by default.
+	TimestampHeader string `json:"timestamp_header"` // X-Timestamp by default.
+	NonceHeader     string `json:"nonce_header"`     // X-Nonce by default.
+	VerifyResponses bool   `json:"verify_responses"` // The answers of the operator have to be signed too.
+	MaxSkewSeconds  int    `json:"max_skew_seconds"` // How old a signed message can be, 300 by default.
+
+	// ClaimNonce records the nonce of a signed answer for the ttl, false if it was already used. Set by the
+	// caller, the answers can't be verified without it.
+	ClaimNonce func(nonce string, ttl time.Duration) (bool, error) `json:"-"`
+}
+
+func (s *WalletSigning) Validate() error {
+	if s.Secret == "" {
+		return fmt.Errorf("wallet signing needs a secret")
+	}
+	return nil
+}
+
+func (s *WalletSigning) SignatureHeaderName() string {
+	return headerOrDefault(s.SignatureHeader, DefaultSignatureHeader)
+}
+
+func (s *WalletSigning) TimestampHeaderName() string {
+	return headerOrDefault(s.TimestampHeader, DefaultTimestampHeader)
+}
+
+func (s *WalletSigning) NonceHeaderName() string {
+	return headerOrDefault(s.NonceHeader, DefaultNonceHeader)
+}
+
+func (s *WalletSigning) MaxSkew() int {
+	if s.MaxSkewSeconds <= 0 {
+		return 300
+	}
+	return s.MaxSkewSeconds
+}
+
+func headerOrDefault(header, def string) string {
+	if header == "" {
+		return def
+	}
+	return header
+}
+
+// Types of the callbacks the operators push to us.
+const (
+	WalletCallbackBalance = "balance_update" // The balance of the player changed on the operator side.
+	WalletCallbackCancel  = "cancel"         // The operator cancelled one of our transactions.
+)
+
+// WalletCallback is the body of a signed callback of an operator.
+type WalletCallback struct {
+	Type          string `json:"type"`
+	Player        string `json:"player"`
+	Currency      string `json:"currency"`
+	Balance       *int64 `json:"balance,omitempty"` // New balance in minor units of the currency, optional on a cancel.
+	TransactionID string `json:"transaction_id,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+func (c *WalletCallback) Validate() error {
+	switch c.Type {
+	case WalletCallbackBalance:
+		if c.Balance == nil {
+			return fmt.Errorf("balance_update callback without balance")
+		}
+	case WalletCallbackCancel:
+		if c.TransactionID == "" {
+			return fmt.Errorf("cancel callback without transaction_id")
+		}
+	default:
+		return fmt.Errorf("invalid callback type: %s", c.Type)
+	}
+	if c.Player == "" || c.Currency == "" {
+		return fmt.Errorf("callback without player or currency")
+	}
+	return nil
+}
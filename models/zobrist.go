@@ -0,0 +1,36 @@
+package models
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// Zobrist hashing, a position hash is the xor of one random key per feature of the position (each piece
+// on its square, the side to move, ...).
+//
+// The keys come from a fixed seed, so every service and every replica computes the same hash for the
+// same position.
+
+const (
+	zobristChessPieces    = 0                          // 12 piece kinds x 64 squares.
+	zobristChessSide      = zobristChessPieces + 12*64 // White to move.
+	zobristChessCastling  = zobristChessSide + 1       // K, Q, k, q.
+	zobristChessEnPassant = zobristChessCastling + 4   // En passant file.
+	zobristDamasPieces    = zobristChessEnPassant + 8  // 4 piece kinds x 100 squares, enough for 10x10.
+	zobristDamasSide      = zobristDamasPieces + 4*100 // White to move.
+	zobristSize           = zobristDamasSide + 1
+)
+
+var zobristKeys = func() []uint64 {
+	r := rand.New(rand.NewSource(20250301))
+	keys := make([]uint64, zobristSize)
+	for i := range keys {
+		keys[i] = r.Uint64()
+	}
+	return keys
+}()
+
+// hashKey is the hash in the format used on the position history maps.
+func hashKey(hash uint64) string {
+	return strconv.FormatUint(hash, 16)
+}
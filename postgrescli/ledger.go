@@ -0,0 +1,317 @@
+package postgrescli
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/lib/pq"
+)
+
+// A pending or sent entry not updated for this long was left behind by a process that died, its outcome is
+// not known so it is sent again.
+const ledgerStaleAfter = 2 * time.Minute
+
+const ledgerColumns = `TransactionID, SessionID, Type, Amount, Currency, Operator, Game, RoundID, Status, Attempts,
+	NextAttemptAt, COALESCE(LastError, ''), Payload, Session, Response, CreatedAt, UpdatedAt`
+
+// CreateLedgerEntry saves the entry before it is posted, saving it again does nothing.
+func (pc *PostgresCli) CreateLedgerEntry(entry models.LedgerEntry) error {
+	query := `
+		INSERT INTO wallet_ledger (
+			TransactionID, SessionID, Type, Amount, Currency, Operator, Game, RoundID, Status, Payload, Session
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (TransactionID) DO NOTHING
+	`
+	_, err := pc.DB.Exec(query,
+		entry.TransactionID,
+		entry.SessionID,
+		entry.Type,
+		entry.Amount,
+		entry.Currency,
+		entry.Operator,
+		entry.Game,
+		entry.RoundID,
+		entry.Status,
+		string(entry.Payload),
+		string(entry.Session),
+	)
+	if err != nil {
+		log.Printf("[PostgresCli] - error saving ledger entry: %v", err)
+		return fmt.Errorf("exec ledger insert: %w", err)
+	}
+	return nil
+}
+
+// MarkLedgerSent is called right before the post, the attempt is counted.
+func (pc *PostgresCli) MarkLedgerSent(transactionID string) error {
+	_, err := pc.DB.Exec(`
+		UPDATE wallet_ledger SET Status = $2, Attempts = Attempts + 1, NextAttemptAt = NULL, UpdatedAt = NOW()
+		WHERE TransactionID = $1`, transactionID, models.LedgerSent)
+	if err != nil {
+		return fmt.Errorf("exec ledger sent update: %w", err)
+	}
+	return nil
+}
+
+// MarkLedgerConfirmed saves the answer of the operator with the entry, a nil response keeps the one it has.
+func (pc *PostgresCli) MarkLedgerConfirmed(transactionID string, response []byte) error {
+	_, err := pc.DB.Exec(`
+		UPDATE wallet_ledger SET Status = $2, NextAttemptAt = NULL, LastError = NULL,
+			Response = COALESCE($3::jsonb, Response), UpdatedAt = NOW()
+		WHERE TransactionID = $1`, transactionID, models.LedgerConfirmed, nullableJSON(response))
+	if err != nil {
+		return fmt.Errorf("exec ledger confirmed update: %w", err)
+	}
+	return nil
+}
+
+// MarkLedgerBetRolledBack marks the confirmed or unknown bet of the player in the round as rolled back, once
+// the rollback was confirmed by the operator.
+func (pc *PostgresCli) MarkLedgerBetRolledBack(sessionID string, roundID string) error {
+	_, err := pc.DB.Exec(`
+		UPDATE wallet_ledger SET Status = $4, UpdatedAt = NOW()
+		WHERE SessionID = $1 AND RoundID = $2 AND Type = 'bet' AND Status IN ($3, $5)`,
+		sessionID, roundID, models.LedgerConfirmed, models.LedgerRolledBack, models.LedgerUnknown)
+	if err != nil {
+		return fmt.Errorf("exec ledger rolled back update: %w", err)
+	}
+	return nil
+}
+
+// CancelLedgerEntry marks a bet of the operator as rolled back, when the operator tells us it cancelled it. It is
+// not sent again. Only bets that are not settled can be cancelled, false when the operator has no such bet.
+func (pc *PostgresCli) CancelLedgerEntry(transactionID string, operator string, reason string) (bool, error) {
+	res, err := pc.DB.Exec(`
+		UPDATE wallet_ledger SET Status = $3, NextAttemptAt = NULL, LastError = $4, UpdatedAt = NOW()
+		WHERE TransactionID = $1 AND Operator = $2 AND Type = 'bet' AND Status IN ($5, $6, $7, $8)`,
+		transactionID, operator, models.LedgerRolledBack, truncate("cancelled by the operator: "+reason, 600),
+		models.LedgerPending, models.LedgerSent, models.LedgerFailed, models.LedgerUnknown)
+	if err != nil {
+		return false, fmt.Errorf("exec ledger cancel update: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("ledger cancel rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// FetchLedgerBet gets the bet of the player in the round the operator took, or may have taken if it is unknown.
+func (pc *PostgresCli) FetchLedgerBet(sessionID string, roundID string) (*models.LedgerEntry, error) {
+	rows, err := pc.DB.Query(`
+		SELECT `+ledgerColumns+`
+		FROM wallet_ledger
+		WHERE SessionID = $1 AND RoundID = $2 AND Type = 'bet' AND Status IN ($3, $4)
+		LIMIT 1`, sessionID, roundID, models.LedgerConfirmed, models.LedgerUnknown)
+	if err != nil {
+		return nil, fmt.Errorf("query ledger bet: %w", err)
+	}
+	entries, err := scanLedgerEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no confirmed bet for session %s in round %s", sessionID, roundID)
+	}
+	return &entries[0], nil
+}
+
+// FetchOrphanBets gets the confirmed or unknown bets older than the given age whose game was never saved, and
+// that have no rollback yet. These are the bets of games that crashed, of rooms that never got to start the
+// game, and of bets that got no answer.
+// A round with a win or a refund was played to the end even if saving the game failed, its bets are settled.
+func (pc *PostgresCli) FetchOrphanBets(olderThan time.Duration, limit int) ([]models.LedgerEntry, error) {
+	rows, err := pc.DB.Query(`
+		SELECT `+ledgerColumns+`
+		FROM wallet_ledger l
+		WHERE l.Type = 'bet' AND l.Status IN ($1, $4) AND l.CreatedAt < NOW() - make_interval(secs => $2)
+		  AND NOT EXISTS (SELECT 1 FROM games g WHERE g.ID = l.RoundID)
+		  AND NOT EXISTS (
+			SELECT 1 FROM wallet_ledger r
+			WHERE r.Type = 'rollback' AND r.SessionID = l.SessionID AND r.RoundID = l.RoundID)
+		  AND NOT EXISTS (
+			SELECT 1 FROM wallet_ledger s
+			WHERE s.Type IN ('win', 'refund') AND s.RoundID = l.RoundID)
+		ORDER BY l.CreatedAt
+		LIMIT $3`, models.LedgerConfirmed, olderThan.Seconds(), limit, models.LedgerUnknown)
+	if err != nil {
+		return nil, fmt.Errorf("query orphan bets: %w", err)
+	}
+	return scanLedgerEntries(rows)
+}
+
+// MarkLedgerFailed saves the error of the post. With retry the entry is tried again after the backoff, until
+// it runs out of attempts.
+func (pc *PostgresCli) MarkLedgerFailed(transactionID string, postErr error, retry bool) error {
+	var attempts int
+	if err := pc.DB.QueryRow(`SELECT Attempts FROM wallet_ledger WHERE TransactionID = $1`, transactionID).Scan(&attempts); err != nil {
+		return fmt.Errorf("error fetching ledger entry %s: %w", transactionID, err)
+	}
+	retry = retry && attempts < models.LedgerMaxAttempts
+	_, err := pc.DB.Exec(`
+		UPDATE wallet_ledger SET Status = $2,
+			NextAttemptAt = CASE WHEN $3 THEN NOW() + make_interval(secs => $4) ELSE NULL END,
+			LastError = $5, UpdatedAt = NOW()
+		WHERE TransactionID = $1`,
+		transactionID, models.LedgerFailed, retry, models.LedgerBackoff(attempts).Seconds(), truncate(postErr.Error(), 600))
+	if err != nil {
+		return fmt.Errorf("exec ledger failed update: %w", err)
+	}
+	return nil
+}
+
+// MarkLedgerUnknown saves the error of a post that got no answer and is not sent again, the operator may have
+// done it anyway.
+func (pc *PostgresCli) MarkLedgerUnknown(transactionID string, postErr error) error {
+	_, err := pc.DB.Exec(`
+		UPDATE wallet_ledger SET Status = $2, NextAttemptAt = NULL, LastError = $3, UpdatedAt = NOW()
+		WHERE TransactionID = $1`,
+		transactionID, models.LedgerUnknown, truncate(postErr.Error(), 600))
+	if err != nil {
+		return fmt.Errorf("exec ledger unknown update: %w", err)
+	}
+	return nil
+}
+
+// ClaimLedgerRetries takes the entries due to be sent again, the failed ones past their backoff and the stale
+// pending or sent ones. They are marked sent in the same transaction, so two workers never take the same one.
+func (pc *PostgresCli) ClaimLedgerRetries(limit int) ([]models.LedgerEntry, error) {
+	tx, err := pc.DB.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin ledger claim: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT `+ledgerColumns+`
+		FROM wallet_ledger
+		WHERE (Status = $1 AND NextAttemptAt <= NOW())
+		   OR (Status IN ($2, $3) AND UpdatedAt < NOW() - make_interval(secs => $4))
+		ORDER BY CreatedAt
+		LIMIT $5
+		FOR UPDATE SKIP LOCKED`,
+		models.LedgerFailed, models.LedgerPending, models.LedgerSent, ledgerStaleAfter.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query ledger retries: %w", err)
+	}
+	entries, err := scanLedgerEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(entries))
+	for i := range entries {
+		ids = append(ids, entries[i].TransactionID)
+		entries[i].Status = models.LedgerSent
+		entries[i].Attempts++
+	}
+	_, err = tx.Exec(`
+		UPDATE wallet_ledger SET Status = $2, Attempts = Attempts + 1, NextAttemptAt = NULL, UpdatedAt = NOW()
+		WHERE TransactionID = ANY($1)`, pq.Array(ids), models.LedgerSent)
+	if err != nil {
+		return nil, fmt.Errorf("exec ledger claim update: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit ledger claim: %w", err)
+	}
+	return entries, nil
+}
+
+// FetchUnbalancedRounds is the reconciliation report, the rounds since the date whose ledger doesn't add up:
+//   - some entry is not settled yet, or a bet got no answer and was not rolled back,
+//   - bets were taken for a game that was never saved, and were not rolled back,
+//   - a game won by a player has no confirmed win,
+//   - a drawn or aborted game didn't refund all the bets.
+func (pc *PostgresCli) FetchUnbalancedRounds(since time.Time) ([]models.RoundBalance, error) {
+	query := `
+		WITH rounds AS (
+			SELECT RoundID,
+				COALESCE(SUM(Amount) FILTER (WHERE Type = 'bet' AND Status = 'confirmed'), 0) AS Bets,
+				COALESCE(SUM(Amount) FILTER (WHERE Type = 'win' AND Status = 'confirmed'), 0) AS Wins,
+				COALESCE(SUM(Amount) FILTER (WHERE Type = 'refund' AND Status = 'confirmed'), 0) AS Refunds,
+				COALESCE(SUM(Amount) FILTER (WHERE Status = 'rolled_back'), 0) AS RolledBack,
+				COUNT(*) FILTER (WHERE Status IN ('pending', 'sent', 'failed', 'unknown')) AS Unsettled,
+				MIN(CreatedAt) AS FirstAt
+			FROM wallet_ledger
+			WHERE CreatedAt >= $1
+			GROUP BY RoundID
+		)
+		SELECT r.RoundID, COALESCE(g.GameOverReason, ''), COALESCE(g.Winner::text, ''),
+			r.Bets, r.Wins, r.Refunds, r.RolledBack, r.Unsettled,
+			CASE
+				WHEN r.Unsettled > 0 THEN 'unsettled'
+				WHEN g.ID IS NULL THEN 'bets_without_game'
+				WHEN g.Winner IS NOT NULL THEN 'win_missing'
+				ELSE 'refund_missing'
+			END
+		FROM rounds r
+		LEFT JOIN games g ON g.ID = r.RoundID
+		WHERE r.Unsettled > 0
+		   OR (g.ID IS NULL AND r.Bets > 0 AND r.FirstAt < NOW() - INTERVAL '1 hour')
+		   OR (g.Winner IS NOT NULL AND r.Wins = 0 AND NOT EXISTS (
+				SELECT 1 FROM jsonb_array_elements(g.GamePlayers) p
+				WHERE p->>'id' = g.Winner::text AND COALESCE((p->>'is_bot')::boolean, false)))
+		   OR (g.ID IS NOT NULL AND g.Winner IS NULL AND r.Refunds < r.Bets)
+		ORDER BY r.FirstAt
+	`
+	rows, err := pc.DB.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("query unbalanced rounds: %w", err)
+	}
+	defer rows.Close()
+
+	rounds := []models.RoundBalance{}
+	for rows.Next() {
+		var round models.RoundBalance
+		if err := rows.Scan(&round.RoundID, &round.GameOverReason, &round.Winner, &round.Bets, &round.Wins,
+			&round.Refunds, &round.RolledBack, &round.Unsettled, &round.Issue); err != nil {
+			return nil, fmt.Errorf("scan unbalanced round: %w", err)
+		}
+		rounds = append(rounds, round)
+	}
+	return rounds, rows.Err()
+}
+
+func scanLedgerEntries(rows *sql.Rows) ([]models.LedgerEntry, error) {
+	defer rows.Close()
+	var entries []models.LedgerEntry
+	for rows.Next() {
+		var entry models.LedgerEntry
+		var next sql.NullTime
+		var payload, session, response []byte
+		err := rows.Scan(&entry.TransactionID, &entry.SessionID, &entry.Type, &entry.Amount, &entry.Currency,
+			&entry.Operator, &entry.Game, &entry.RoundID, &entry.Status, &entry.Attempts, &next, &entry.LastError,
+			&payload, &session, &response, &entry.CreatedAt, &entry.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan ledger entry: %w", err)
+		}
+		if next.Valid {
+			entry.NextAttemptAt = &next.Time
+		}
+		entry.Payload = payload
+		entry.Session = session
+		entry.Response = response
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func nullableJSON(b []byte) interface{} {
+	if len(b) == 0 {
+		return nil
+	}
+	return string(b)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
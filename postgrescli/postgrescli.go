@@ -132,8 +132,8 @@ func (pc *PostgresCli) SaveGame(game models.Game, reason string) error {
 
 	query := `
 		INSERT INTO games (
-			ID, OperatorName, OperatorGameName, GameName, StartDate, EndDate, Moves, BetAmount, Winner, GamePlayers, WinFactor, NumMoves, GameOverReason
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			ID, OperatorName, OperatorGameName, GameName, StartDate, EndDate, Moves, BetAmount, Winner, GamePlayers, WinFactor, NumMoves, GameOverReason, Variant, BotGame, FreePlay, BetMinor, Currency
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 	`
 
 	stmt, err := pc.DB.Prepare(query)
@@ -151,12 +151,17 @@ func (pc *PostgresCli) SaveGame(game models.Game, reason string) error {
 		game.StartTime,
 		game.EndTime,
 		string(movesJSON),
-		game.BetValue,
-		game.Winner,
+		game.BetValue.String(),
+		sql.NullString{String: game.Winner, Valid: game.Winner != ""}, // Draws have no winner.
 		string(playersJSON),
 		game.OperatorIdentifier.WinFactor,
 		len(game.Moves),
 		reason,
+		game.OperatorIdentifier.Variant,
+		game.HasBot(),
+		game.FreePlay,
+		game.BetValue.Amount,
+		game.BetValue.Currency,
 	)
 	if err != nil {
 		log.Printf("[PostgresCli] - error saving game: %v", err)
@@ -166,38 +171,129 @@ func (pc *PostgresCli) SaveGame(game models.Game, reason string) error {
 }
 
 func (pc *PostgresCli) FetchGameMoves(gameID string) ([]models.MoveInterface, error) {
-	query := `SELECT moves FROM games WHERE id = $1`
+	query := `SELECT moves, COALESCE(GameName, '') FROM games WHERE id = $1`
 	log.Printf("Fetching moves for gameID: %s", gameID) // not [%s]
 	parsedUUID, err := uuid.Parse(gameID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid UUID: %w", err)
 	}
-	log.Printf("Fetching moves for gameID: %s", gameID) // not [%s]
 
 	var movesJSON []byte
-	err = pc.DB.QueryRow(query, parsedUUID).Scan(&movesJSON)
+	var gameName string
+	err = pc.DB.QueryRow(query, parsedUUID).Scan(&movesJSON, &gameName)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching moves for game %s: %w", gameID, err)
 	}
 
-	var moves []models.MoveInterface
-	if err := json.Unmarshal(movesJSON, &moves); err != nil {
+	return unmarshalGameMoves(movesJSON, gameName)
+}
+
+// unmarshalGameMoves decodes the moves column, the type of the moves depends on the game.
+func unmarshalGameMoves(movesJSON []byte, gameName string) ([]models.MoveInterface, error) {
+	var rawMoves []json.RawMessage
+	if err := json.Unmarshal(movesJSON, &rawMoves); err != nil {
 		return nil, fmt.Errorf("error unmarshalling moves JSON: %w", err)
 	}
-
+	moves := make([]models.MoveInterface, 0, len(rawMoves))
+	for _, raw := range rawMoves {
+		move, err := models.UnmarshalMove(raw, gameName)
+		if err != nil {
+			return nil, fmt.Errorf("error unmarshalling move JSON: %w", err)
+		}
+		moves = append(moves, move)
+	}
 	return moves, nil
 }
 
+// FetchGame rebuilds a finished game from the games table, with the moves typed for its game, and returns
+// it with the reason the game ended. The board is not saved, so the game has none.
+func (pc *PostgresCli) FetchGame(gameID string) (*models.Game, string, error) {
+	query := `
+		SELECT ID, COALESCE(OperatorName, ''), COALESCE(OperatorGameName, ''), COALESCE(GameName, ''), StartDate, EndDate,
+			Moves, COALESCE(BetAmount, 0)::TEXT, BetMinor, COALESCE(Currency, ''), Winner, GamePlayers, COALESCE(WinFactor, 0), COALESCE(GameOverReason, ''), COALESCE(Variant, '')
+		FROM games
+		WHERE ID = $1
+	`
+	parsedUUID, err := uuid.Parse(gameID)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid UUID: %w", err)
+	}
+
+	var game models.Game
+	var startDate, endDate sql.NullTime
+	var winner sql.NullString
+	var movesJSON, playersJSON []byte
+	var reason string
+	var betAmount, currency string
+	var betMinor sql.NullInt64
+	err = pc.DB.QueryRow(query, parsedUUID).Scan(
+		&game.ID,
+		&game.OperatorIdentifier.OperatorName,
+		&game.OperatorIdentifier.OperatorGameName,
+		&game.OperatorIdentifier.GameName,
+		&startDate,
+		&endDate,
+		&movesJSON,
+		&betAmount,
+		&betMinor,
+		&currency,
+		&winner,
+		&playersJSON,
+		&game.OperatorIdentifier.WinFactor,
+		&reason,
+		&game.OperatorIdentifier.Variant,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", fmt.Errorf("game not found with id=%s", gameID)
+		}
+		return nil, "", fmt.Errorf("error fetching game %s: %w", gameID, err)
+	}
+	game.StartTime = startDate.Time
+	game.EndTime = endDate.Time
+	game.Winner = winner.String
+	if betMinor.Valid {
+		game.BetValue = models.NewMoney(betMinor.Int64, currency)
+	} else {
+		// Games saved before the minor units only have the decimal amount, with 2 decimals.
+		game.BetValue, err = models.ParseMoney(betAmount, currency)
+		if err != nil {
+			return nil, "", fmt.Errorf("error parsing bet of game %s: %w", gameID, err)
+		}
+	}
+
+	if err := json.Unmarshal(playersJSON, &game.Players); err != nil {
+		return nil, "", fmt.Errorf("error unmarshalling players JSON: %w", err)
+	}
+	game.Moves, err = unmarshalGameMoves(movesJSON, game.OperatorIdentifier.GameName)
+	if err != nil {
+		return nil, "", err
+	}
+	return &game, reason, nil
+}
+
+// MarkBotGameTransactions flags the transactions of a game played against the house bot, they are
+// accounted apart.
+func (pc *PostgresCli) MarkBotGameTransactions(gameID string) error {
+	_, err := pc.DB.Exec(`UPDATE transactions SET BotGame = TRUE WHERE RoundID = $1`, gameID)
+	if err != nil {
+		log.Printf("[PostgresCli] - error marking bot game transactions: %v", err)
+		return fmt.Errorf("exec bot game transactions update: %w", err)
+	}
+	return nil
+}
+
 // FetchOperator fetches an operator from the database using OperatorName and OperatorGameName
 func (pc *PostgresCli) FetchOperator(operatorName, operatorGameName string) (*models.Operator, error) {
 	query := `
-		SELECT ID, OperatorName, OperatorGameName, GameName, Active, GameBaseUrl, OperatorWalletBaseUrl, WinFactor
+		SELECT ID, OperatorName, OperatorGameName, GameName, Active, GameBaseUrl, OperatorWalletBaseUrl, WinFactor, COALESCE(Variant, ''), COALESCE(BotEnabled, false), WalletConfig, Signing, BetTiers
 		FROM operators
 		WHERE OperatorName = $1 AND OperatorGameName = $2
 	`
 	row := pc.DB.QueryRow(query, operatorName, operatorGameName)
 
 	var operator models.Operator
+	var walletConfig, signing, betTiers []byte
 	err := row.Scan(
 		&operator.ID,
 		&operator.OperatorName,
@@ -207,6 +303,11 @@ func (pc *PostgresCli) FetchOperator(operatorName, operatorGameName string) (*mo
 		&operator.GameBaseUrl,
 		&operator.OperatorWalletBaseUrl,
 		&operator.WinFactor,
+		&operator.Variant,
+		&operator.BotEnabled,
+		&walletConfig,
+		&signing,
+		&betTiers,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -214,6 +315,29 @@ func (pc *PostgresCli) FetchOperator(operatorName, operatorGameName string) (*mo
 		}
 		return nil, fmt.Errorf("error fetching operator: %w", err)
 	}
+	if len(walletConfig) > 0 {
+		operator.WalletConfig = &models.WalletConfig{}
+		if err := json.Unmarshal(walletConfig, operator.WalletConfig); err != nil {
+			return nil, fmt.Errorf("error parsing wallet config of operator %s: %w", operatorName, err)
+		}
+	}
+	if len(signing) > 0 {
+		operator.Signing = &models.WalletSigning{}
+		if err := json.Unmarshal(signing, operator.Signing); err != nil {
+			return nil, fmt.Errorf("error parsing signing of operator %s: %w", operatorName, err)
+		}
+		if err := operator.Signing.Validate(); err != nil {
+			return nil, fmt.Errorf("operator %s: %w", operatorName, err)
+		}
+	}
+	if len(betTiers) > 0 {
+		if err := json.Unmarshal(betTiers, &operator.BetTiers); err != nil {
+			return nil, fmt.Errorf("error parsing bet tiers of operator %s: %w", operatorName, err)
+		}
+		if err := operator.BetTiers.Validate(); err != nil {
+			return nil, fmt.Errorf("operator %s: %w", operatorName, err)
+		}
+	}
 
 	return &operator, nil
 }
@@ -1,6 +1,10 @@
 package redisdb
 
-import "github.com/Lavizord/checkers-server/models"
+import (
+	"fmt"
+
+	"github.com/Lavizord/checkers-server/models"
+)
 
 func GetPlayerPubSubChannel(player models.Player) string {
 	return "player:" + string(player.ID)
@@ -17,3 +21,14 @@ func GenerateRoomRedisKey(room models.Room) string {
 func GenerateRoomRedisKeyById(roomId string) string {
 	return "room:" + string(roomId)
 }
+
+// Queue of the players waiting on a bet, like "queue:{damas}:EUR:50", or "queue:{damas}:brazilian:EUR:50" for
+// a rule variant. The bet is in minor units, so the name is the same however the client wrote the amount.
+func GenerateQueueName(gameName string, queue BetQueue) string {
+	return fmt.Sprintf("queue:{%s}:%s", gameName, queue.Key())
+}
+
+// Channel with the live updates of a game, for the spectators.
+func GetGameSpectatorChannel(gameID string) string {
+	return "spectate:" + gameID
+}
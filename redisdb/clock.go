@@ -0,0 +1,150 @@
+package redisdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// Takes the clocks with a passed deadline off the deadlines set. The take is atomic, so when several
+// gameworkers poll the set each deadline goes to only one of them.
+var claimDueClocksScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, id in ipairs(ids) do
+	redis.call('ZREM', KEYS[1], id)
+end
+return ids
+`)
+
+// Takes the lock, or extends it when the owner already has it.
+var acquireLockScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+	return 1
+end
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+func GenerateClockKey(gameID string) string {
+	return fmt.Sprintf("game_clock:%s", gameID)
+}
+
+func GenerateClockDeadlinesKey(gameName string) string {
+	return fmt.Sprintf("clock_deadlines:{%s}", gameName)
+}
+
+func (r *RedisClient) SaveClock(clock *models.GameClock) error {
+	data, err := json.Marshal(clock)
+	if err != nil {
+		return fmt.Errorf("[RedisClient] - failed to serialize clock: %v", err)
+	}
+	_, err = r.Client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Set(context.Background(), GenerateClockKey(clock.GameID), data, 0)
+		pipe.ZAdd(context.Background(), GenerateClockDeadlinesKey(clock.GameName), redis.Z{Score: float64(clock.Deadline), Member: clock.GameID})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("[RedisClient] - failed to save clock: %v", err)
+	}
+	return nil
+}
+
+func (r *RedisClient) GetClock(gameID string) (*models.GameClock, error) {
+	data, err := r.Client.Get(context.Background(), GenerateClockKey(gameID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("[RedisClient] - failed to get clock: %w", err)
+	}
+	var clock models.GameClock
+	if err := json.Unmarshal([]byte(data), &clock); err != nil {
+		return nil, fmt.Errorf("[RedisClient] - failed to deserialize clock: %v", err)
+	}
+	return &clock, nil
+}
+
+func (r *RedisClient) RemoveClock(gameName, gameID string) error {
+	_, err := r.Client.TxPipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		pipe.Del(context.Background(), GenerateClockKey(gameID))
+		pipe.ZRem(context.Background(), GenerateClockDeadlinesKey(gameName), gameID)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("[RedisClient] - failed to remove clock: %v", err)
+	}
+	return nil
+}
+
+// UpdateClock changes a clock with update, the change is only saved if no one else changed the clock in the
+// meantime, else update runs again on the new clock. The deadline is set again in the deadlines set, unless
+// it was handled.
+func (r *RedisClient) UpdateClock(gameID string, update func(clock *models.GameClock) error) error {
+	ctx := context.Background()
+	key := GenerateClockKey(gameID)
+	for attempt := 0; attempt < 10; attempt++ {
+		err := r.Client.Watch(ctx, func(tx *redis.Tx) error {
+			data, err := tx.Get(ctx, key).Result()
+			if err != nil {
+				return fmt.Errorf("[RedisClient] - failed to get clock: %w", err)
+			}
+			var clock models.GameClock
+			if err := json.Unmarshal([]byte(data), &clock); err != nil {
+				return fmt.Errorf("[RedisClient] - failed to deserialize clock: %v", err)
+			}
+			if err := update(&clock); err != nil {
+				return err
+			}
+			updated, err := json.Marshal(clock)
+			if err != nil {
+				return fmt.Errorf("[RedisClient] - failed to serialize clock: %v", err)
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, updated, 0)
+				if clock.FiredDeadline == clock.Deadline {
+					pipe.ZRem(ctx, GenerateClockDeadlinesKey(clock.GameName), gameID)
+				} else {
+					pipe.ZAdd(ctx, GenerateClockDeadlinesKey(clock.GameName), redis.Z{Score: float64(clock.Deadline), Member: gameID})
+				}
+				return nil
+			})
+			return err
+		}, key)
+		if err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return fmt.Errorf("[RedisClient] - failed to update clock for game: %s, too many concurrent changes", gameID)
+}
+
+// ClaimDueClocks takes up to limit game ids with a clock deadline before now.
+func (r *RedisClient) ClaimDueClocks(gameName string, now time.Time, limit int) ([]string, error) {
+	result, err := claimDueClocksScript.Run(context.Background(), r.Client, []string{GenerateClockDeadlinesKey(gameName)}, now.UnixMilli(), limit).StringSlice()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("[RedisClient] - failed to claim due clocks: %v", err)
+	}
+	return result, nil
+}
+
+// GetClockGameIDs returns the ids of the games with a running clock.
+func (r *RedisClient) GetClockGameIDs(gameName string) ([]string, error) {
+	return r.Client.ZRange(context.Background(), GenerateClockDeadlinesKey(gameName), 0, -1).Result()
+}
+
+// GetGameIDs returns the ids of all the games in redis.
+func (r *RedisClient) GetGameIDs() ([]string, error) {
+	return r.Client.HKeys(context.Background(), "games").Result()
+}
+
+// AcquireLock takes the lock for owner for ttl, it returns true when owner has the lock.
+func (r *RedisClient) AcquireLock(key, owner string, ttl time.Duration) (bool, error) {
+	result, err := acquireLockScript.Run(context.Background(), r.Client, []string{key}, owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("[RedisClient] - failed to acquire lock: %v", err)
+	}
+	return result == 1, nil
+}
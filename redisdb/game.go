@@ -3,11 +3,13 @@ package redisdb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/Lavizord/checkers-server/models"
+	"github.com/redis/go-redis/v9"
 )
 
 func (r *RedisClient) AddGame(game *models.Game) error {
@@ -19,25 +21,92 @@ func (r *RedisClient) AddGame(game *models.Game) error {
 	if err != nil {
 		return err
 	}
-	betKey := fmt.Sprintf("games:{%s}:bet:%.2f", game.OperatorIdentifier.GameName, game.BetValue)
-	return r.Client.SAdd(context.Background(), betKey, game.ID).Err()
+	betKey := generateGamesByBetKey(game.OperatorIdentifier.GameName, game.BetValue)
+	if err := r.Client.SAdd(context.Background(), betKey, game.ID).Err(); err != nil {
+		return err
+	}
+	return r.indexLiveGame(game)
 }
 
+// ErrGameVersionConflict is returned when the game was changed by someone else since it was read, the
+// change has to be done again on a fresh copy of the game.
+var ErrGameVersionConflict = errors.New("[RedisClient] - game was changed since it was read")
+
+// Saves the game only if the stored game still has the version of the game, the version is then bumped.
+// Returns 1 when saved, 0 on a version conflict and -1 when there is no game.
+var updateGameScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if not current then
+	return -1
+end
+local version = cjson.decode(current)['version'] or 0
+if version ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('HSET', KEYS[1], ARGV[1], ARGV[3])
+return 1
+`)
+
+// Same check as updateGameScript, but removes the game.
+var removeGameScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], ARGV[1])
+if not current then
+	return -1
+end
+local version = cjson.decode(current)['version'] or 0
+if version ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('HDEL', KEYS[1], ARGV[1])
+return 1
+`)
+
+// UpdateGame saves the game with a compare and set on its version, so a stale copy of the game never
+// overwrites a newer one. On a conflict ErrGameVersionConflict is returned and the game is not saved.
 func (r *RedisClient) UpdateGame(game *models.Game) error {
-	exists, err := r.GameExists(game.ID)
+	expected := game.Version
+	game.Version++
+	data, err := json.Marshal(game)
 	if err != nil {
-		return fmt.Errorf("[RedisClient] - failed to check game existence: %v", err)
+		game.Version = expected
+		return fmt.Errorf("[RedisClient] - failed to serialize game: %v", err)
+	}
+	result, err := updateGameScript.Run(context.Background(), r.Client, []string{"games"}, game.ID, expected, data).Int()
+	if err != nil || result != 1 {
+		game.Version = expected
 	}
-	if !exists {
+	switch {
+	case err != nil:
+		return fmt.Errorf("[RedisClient] - failed to update game: %v", err)
+	case result == -1:
 		return fmt.Errorf("[RedisClient] - game with ID %s does not exist", game.ID)
+	case result == 0:
+		return ErrGameVersionConflict
 	}
+	return nil
+}
 
-	data, err := json.Marshal(game)
-	if err != nil {
-		return fmt.Errorf("[RedisClient] - failed to serialize game: %v", err)
+// RemoveGameVersion removes the game only if it wasn't changed since it was read, like UpdateGame. It is
+// used to end the game, so only one of the things that can end a game at the same time does it.
+func (r *RedisClient) RemoveGameVersion(game *models.Game) error {
+	result, err := removeGameScript.Run(context.Background(), r.Client, []string{"games"}, game.ID, game.Version).Int()
+	switch {
+	case err != nil:
+		return fmt.Errorf("[RedisClient] - failed to remove game: %v", err)
+	case result == -1:
+		return fmt.Errorf("[RedisClient] - game with ID %s does not exist", game.ID)
+	case result == 0:
+		return ErrGameVersionConflict
 	}
-
-	return r.Client.HSet(context.Background(), "games", game.ID, data).Err()
+	// The bet set is on another slot, so it is not in the script.
+	betKey := generateGamesByBetKey(game.OperatorIdentifier.GameName, game.BetValue)
+	if err := r.Client.SRem(context.Background(), betKey, game.ID).Err(); err != nil {
+		return fmt.Errorf("[RedisClient] - failed to remove from bet value set: %v", err)
+	}
+	if err := r.unindexLiveGame(game); err != nil {
+		return fmt.Errorf("[RedisClient] - failed to remove from live games: %v", err)
+	}
+	return nil
 }
 
 func (r *RedisClient) GetGame(gameID string) (*models.Game, error) {
@@ -68,10 +137,13 @@ func (r *RedisClient) RemoveGame(gameID string) error {
 		return fmt.Errorf("[RedisClient] - failed to delete game: %v", err)
 	}
 	// Remove from the specific bet value set
-	betKey := fmt.Sprintf("games:{%s}:bet:%.2f", game.OperatorIdentifier.GameName, game.BetValue)
+	betKey := generateGamesByBetKey(game.OperatorIdentifier.GameName, game.BetValue)
 	if err := r.Client.SRem(context.Background(), betKey, gameID).Err(); err != nil {
 		return fmt.Errorf("[RedisClient] - failed to remove from bet value set: %v", err)
 	}
+	if err := r.unindexLiveGame(game); err != nil {
+		return fmt.Errorf("[RedisClient] - failed to remove from live games: %v", err)
+	}
 	return nil
 }
 
@@ -89,9 +161,12 @@ func (r *RedisClient) GetNumberOfGames() int {
 	return int(count)
 }
 
-func (r *RedisClient) CountGamesByBetValue(betValue float64, gameName string) (int64, error) {
-	betKey := fmt.Sprintf("games:{%s}:bet:%.2f", gameName, betValue)
-	return r.Client.SCard(context.Background(), betKey).Result()
+func (r *RedisClient) CountGamesByBetValue(betValue models.Money, gameName string) (int64, error) {
+	return r.Client.SCard(context.Background(), generateGamesByBetKey(gameName, betValue)).Result()
+}
+
+func generateGamesByBetKey(gameName string, betValue models.Money) string {
+	return fmt.Sprintf("games:{%s}:bet:%s", gameName, betValue.Key())
 }
 
 // This should be called when a disconnect happens during a game, it will save the
@@ -0,0 +1,135 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// The games in progress are indexed by start time in a sorted set per game name and another per game name
+// and operator, so the listings can be paged without going over the whole games hash.
+func GenerateLiveGamesKey(gameName, operatorName string) string {
+	if operatorName == "" {
+		return fmt.Sprintf("live_games:{%s}", gameName)
+	}
+	return fmt.Sprintf("live_games:{%s}:op:%s", gameName, operatorName)
+}
+
+// The operators with a live games index for the game name, so a stale game can be taken out of all of them.
+func generateLiveGamesOperatorsKey(gameName string) string {
+	return fmt.Sprintf("live_games:{%s}:ops", gameName)
+}
+
+func (r *RedisClient) indexLiveGame(game *models.Game) error {
+	member := redis.Z{Score: float64(game.StartTime.UnixMilli()), Member: game.ID}
+	pipe := r.Client.TxPipeline()
+	pipe.ZAdd(context.Background(), GenerateLiveGamesKey(game.OperatorIdentifier.GameName, ""), member)
+	pipe.ZAdd(context.Background(), GenerateLiveGamesKey(game.OperatorIdentifier.GameName, game.OperatorIdentifier.OperatorName), member)
+	pipe.SAdd(context.Background(), generateLiveGamesOperatorsKey(game.OperatorIdentifier.GameName), game.OperatorIdentifier.OperatorName)
+	_, err := pipe.Exec(context.Background())
+	return err
+}
+
+func (r *RedisClient) unindexLiveGame(game *models.Game) error {
+	pipe := r.Client.TxPipeline()
+	pipe.ZRem(context.Background(), GenerateLiveGamesKey(game.OperatorIdentifier.GameName, ""), game.ID)
+	pipe.ZRem(context.Background(), GenerateLiveGamesKey(game.OperatorIdentifier.GameName, game.OperatorIdentifier.OperatorName), game.ID)
+	_, err := pipe.Exec(context.Background())
+	return err
+}
+
+// unindexStaleLiveGames takes the games out of every index of the game name, their data is gone so the
+// operator is not known.
+func (r *RedisClient) unindexStaleLiveGames(gameName string, gameIDs []string) error {
+	operators, err := r.Client.SMembers(context.Background(), generateLiveGamesOperatorsKey(gameName)).Result()
+	if err != nil {
+		return err
+	}
+	members := make([]interface{}, len(gameIDs))
+	for i, id := range gameIDs {
+		members[i] = id
+	}
+	pipe := r.Client.TxPipeline()
+	pipe.ZRem(context.Background(), GenerateLiveGamesKey(gameName, ""), members...)
+	for _, operator := range operators {
+		pipe.ZRem(context.Background(), GenerateLiveGamesKey(gameName, operator), members...)
+	}
+	_, err = pipe.Exec(context.Background())
+	return err
+}
+
+// IndexLiveGames adds the games of the game name already in progress to the live games index, for the games
+// started before the index existed. It gives back how many were indexed.
+func (r *RedisClient) IndexLiveGames(gameName string) (int, error) {
+	indexed := 0
+	iter := r.Client.HScan(context.Background(), "games", 0, "", 100).Iterator()
+	for iter.Next(context.Background()) {
+		// The scan gives the field and then its value.
+		if !iter.Next(context.Background()) {
+			break
+		}
+		game, err := models.UnmarshalGame([]byte(iter.Val()))
+		if err != nil || game.OperatorIdentifier.GameName != gameName {
+			continue
+		}
+		if err := r.indexLiveGame(game); err != nil {
+			return indexed, fmt.Errorf("[RedisClient] - failed to index live game %s: %v", game.ID, err)
+		}
+		indexed++
+	}
+	if err := iter.Err(); err != nil {
+		return indexed, fmt.Errorf("[RedisClient] - failed to scan games: %v", err)
+	}
+	return indexed, nil
+}
+
+// GetLiveGames returns a page of the games in progress, newest first, and the total of games for the filter.
+// The operator is optional, the game name is not.
+func (r *RedisClient) GetLiveGames(gameName, operatorName string, offset, limit int64) (*models.LiveGamesPage, error) {
+	key := GenerateLiveGamesKey(gameName, operatorName)
+	total, err := r.Client.ZCard(context.Background(), key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("[RedisClient] - failed to count live games: %v", err)
+	}
+	page := &models.LiveGamesPage{Games: []models.LiveGame{}, Total: total, Offset: offset, Limit: limit}
+	if limit <= 0 || offset >= total {
+		return page, nil
+	}
+
+	ids, err := r.Client.ZRevRange(context.Background(), key, offset, offset+limit-1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("[RedisClient] - failed to get live games: %v", err)
+	}
+	if len(ids) == 0 {
+		return page, nil
+	}
+	values, err := r.Client.HMGet(context.Background(), "games", ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("[RedisClient] - failed to get live games data: %v", err)
+	}
+
+	now := time.Now()
+	stale := []string{}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			// The game ended between the two reads, or was left behind by a worker that died, the indexes
+			// can't keep it.
+			stale = append(stale, ids[i])
+			continue
+		}
+		game, err := models.UnmarshalGame([]byte(data))
+		if err != nil {
+			continue
+		}
+		page.Games = append(page.Games, models.NewLiveGame(game, now))
+	}
+	if len(stale) > 0 {
+		// The next listing tries again if this fails.
+		r.unindexStaleLiveGames(gameName, stale)
+	}
+	return page, nil
+}
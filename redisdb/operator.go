@@ -44,3 +44,14 @@ func (r *RedisClient) GetOperator(operatorName, operatorGameName string) (*model
 
 	return &operator, nil
 }
+
+// ClaimWalletNonce records the nonce of a signed callback of the operator, false if it was already used. The
+// nonce is kept for the ttl, past it the timestamp of the callback is too old anyway.
+func (r *RedisClient) ClaimWalletNonce(operatorName, nonce string, ttl time.Duration) (bool, error) {
+	key := fmt.Sprintf("wallet_nonce:%s:%s", operatorName, nonce)
+	fresh, err := r.Client.SetNX(context.Background(), key, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("[RedisClient] - ClaimWalletNonce failed: %v", err)
+	}
+	return fresh, nil
+}
@@ -0,0 +1,35 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// A premove is kept while the game goes on, the ttl only cleans up after games that were never ended.
+const premoveTTL = time.Hour
+
+func GeneratePremoveKey(gameID, playerID string) string {
+	return fmt.Sprintf("premove:{%s}:%s", gameID, playerID)
+}
+
+// SetPremove keeps the premove of the player, replacing the one there was.
+func (r *RedisClient) SetPremove(gameID, playerID string, move []byte) error {
+	return r.Client.Set(context.Background(), GeneratePremoveKey(gameID, playerID), move, premoveTTL).Err()
+}
+
+// TakePremove gets and removes the premove of the player, so only one caller gets to play it. Returns nil
+// when there is none.
+func (r *RedisClient) TakePremove(gameID, playerID string) ([]byte, error) {
+	move, err := r.Client.GetDel(context.Background(), GeneratePremoveKey(gameID, playerID)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return move, err
+}
+
+func (r *RedisClient) ClearPremove(gameID, playerID string) error {
+	return r.Client.Del(context.Background(), GeneratePremoveKey(gameID, playerID)).Err()
+}
@@ -219,6 +219,9 @@ func (r *RedisClient) PublishToPlayerID(playerID string, message string) error {
 func (r *RedisClient) PublishToGamePlayer(player models.GamePlayer, message string) error {
 	return r.Client.Publish(context.Background(), GetGamePlayerPubSubChannel(player), message).Err()
 }
+func (r *RedisClient) PublishToSpectators(gameID string, message string) error {
+	return r.Client.Publish(context.Background(), GetGameSpectatorChannel(gameID), message).Err()
+}
 func (r *RedisClient) DisconnectPlayer(playerID string) {
 	// Publish a disconnect message to Redis for that player
 	message := fmt.Sprintf("disconnect:%s", playerID)
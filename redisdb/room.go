@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/Lavizord/checkers-server/models"
 
@@ -22,7 +23,7 @@ func (r *RedisClient) AddRoom(gameName string, room *models.Room) error {
 	roomKey := fmt.Sprintf("room:%s", room.ID)
 	err = r.Client.HSet(ctx, roomKey, map[string]interface{}{
 		"id":       room.ID,
-		"BetValue": room.BetValue,
+		"BetValue": room.BetValue.Key(),
 		"data":     string(data), // Store full room JSON
 	}).Err()
 	if err != nil {
@@ -32,7 +33,7 @@ func (r *RedisClient) AddRoom(gameName string, room *models.Room) error {
 	// Add room ID to sorted set, indexed by bid amount, this will help us get the rooms by bid amount
 	zsetKey := fmt.Sprintf("rooms_by_bid:{%s}", gameName)
 	err = r.Client.ZAdd(ctx, zsetKey, redis.Z{
-		Score:  room.BetValue,
+		Score:  float64(room.BetValue.Amount),
 		Member: room.ID,
 	}).Err()
 	if err != nil {
@@ -80,13 +81,13 @@ func (r *RedisClient) GetRoomByID(roomID string) (*models.Room, error) {
 	return &room, nil
 }
 
-func (r *RedisClient) GetRoomsByBetValue(gameName string, BetValue float64) ([]models.Room, error) {
+func (r *RedisClient) GetRoomsByBetValue(gameName string, BetValue models.Money) ([]models.Room, error) {
 	ctx := context.Background()
 	zsetKey := fmt.Sprintf("rooms_by_bid:{%s}", gameName)
 	// Get room IDs in the given bid amount range
 	roomIDs, err := r.Client.ZRangeByScore(ctx, zsetKey, &redis.ZRangeBy{
-		Min: fmt.Sprintf("%f", BetValue),
-		Max: fmt.Sprintf("%f", BetValue),
+		Min: strconv.FormatInt(BetValue.Amount, 10),
+		Max: strconv.FormatInt(BetValue.Amount, 10),
 	}).Result()
 	if err != nil {
 		return nil, fmt.Errorf("[RedisClient] - failed to retrieve rooms: %v", err)
@@ -100,20 +101,21 @@ func (r *RedisClient) GetRoomsByBetValue(gameName string, BetValue float64) ([]m
 			continue // Skip if the room data is missing
 		}
 		var room models.Room
-		if err := json.Unmarshal([]byte(data), &room); err == nil {
+		// The score is the amount only, the same amount in another currency is another bet.
+		if err := json.Unmarshal([]byte(data), &room); err == nil && room.BetValue == BetValue {
 			rooms = append(rooms, room)
 		}
 	}
 	return rooms, nil
 }
 
-func (r *RedisClient) GetEmptyRoomsByBetValue(gameName string, BetValue float64) ([]models.Room, error) {
+func (r *RedisClient) GetEmptyRoomsByBetValue(gameName string, BetValue models.Money) ([]models.Room, error) {
 	ctx := context.Background()
 	zsetKey := fmt.Sprintf("rooms_by_bid:{%s}", gameName)
 	// Get room IDs in the given bid amount range
 	roomIDs, err := r.Client.ZRangeByScore(ctx, zsetKey, &redis.ZRangeBy{
-		Min: fmt.Sprintf("%f", BetValue),
-		Max: fmt.Sprintf("%f", BetValue),
+		Min: strconv.FormatInt(BetValue.Amount, 10),
+		Max: strconv.FormatInt(BetValue.Amount, 10),
 	}).Result()
 	if err != nil {
 		return nil, fmt.Errorf("[RedisClient] - failed to retrieve rooms: %v", err)
@@ -128,7 +130,7 @@ func (r *RedisClient) GetEmptyRoomsByBetValue(gameName string, BetValue float64)
 		}
 
 		var room models.Room
-		if err := json.Unmarshal([]byte(data), &room); err == nil {
+		if err := json.Unmarshal([]byte(data), &room); err == nil && room.BetValue == BetValue {
 			if room.Player2 == nil {
 				rooms = append(rooms, room)
 			}
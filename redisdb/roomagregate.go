@@ -11,32 +11,95 @@ import (
 	"github.com/Lavizord/checkers-server/models"
 )
 
-func (r *RedisClient) CreateQueueCount(gameName string, aggregateValue float64) {
-	key := fmt.Sprintf("queue_count:{%s}:{room}:%f", gameName, aggregateValue) // hash tag {room}
+func generateQueueCountKey(gameName string, aggregateValue models.Money) string {
+	return fmt.Sprintf("queue_count:{%s}:{room}:%s", gameName, aggregateValue.Key()) // hash tag {room}
+}
+
+// BetQueue is a queue of the players waiting on a bet. Players of operators with different rule variants can't
+// play each other, so each variant has its own queues.
+type BetQueue struct {
+	Variant string
+	Bet     models.Money
+}
+
+// PlayerBetQueue is the queue of the bet the player selected, in the variant of its operator.
+func PlayerBetQueue(player *models.Player) BetQueue {
+	return BetQueue{Variant: player.OperatorIdentifier.Variant, Bet: player.SelectedBet}
+}
+
+// Key is "EUR:50" for the default rules and "brazilian:EUR:50" for a variant, the queues of before the
+// variants keep their names.
+func (q BetQueue) Key() string {
+	if q.Variant == "" {
+		return q.Bet.Key()
+	}
+	return q.Variant + ":" + q.Bet.Key()
+}
+
+func ParseBetQueueKey(key string) (BetQueue, error) {
+	if strings.Count(key, ":") < 2 {
+		bet, err := models.ParseMoneyKey(key)
+		return BetQueue{Bet: bet}, err
+	}
+	variant, betKey, _ := strings.Cut(key, ":")
+	bet, err := models.ParseMoneyKey(betKey)
+	return BetQueue{Variant: variant, Bet: bet}, err
+}
+
+// The bet queues with players at some point, the room workers pair the players of each of them. Bets can be
+// any of the tiers of any operator, so they are not known upfront.
+func (r *RedisClient) RegisterBetQueue(gameName string, queue BetQueue) error {
+	key := fmt.Sprintf("bet_queues:{%s}", gameName)
+	if err := r.Client.SAdd(context.Background(), key, queue.Key()).Err(); err != nil {
+		return fmt.Errorf("[RedisClient] - failed to register bet queue: %v", err)
+	}
+	return nil
+}
+
+func (r *RedisClient) GetBetQueues(gameName string) ([]BetQueue, error) {
+	key := fmt.Sprintf("bet_queues:{%s}", gameName)
+	members, err := r.Client.SMembers(context.Background(), key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("[RedisClient] - failed to get bet queues: %v", err)
+	}
+	queues := make([]BetQueue, 0, len(members))
+	for _, member := range members {
+		queue, err := ParseBetQueueKey(member)
+		if err != nil {
+			log.Printf("Skipping malformed bet queue %q: %v", member, err)
+			continue
+		}
+		queues = append(queues, queue)
+	}
+	return queues, nil
+}
+
+func (r *RedisClient) CreateQueueCount(gameName string, aggregateValue models.Money) {
+	key := generateQueueCountKey(gameName, aggregateValue) // hash tag {room}
 	_, err := r.Client.SetNX(context.Background(), key, 1, 0).Result()
 	if err != nil {
 		log.Printf("Error setting room aggregate: %v", err)
 	}
 }
 
-func (r *RedisClient) IncrementQueueCount(gameName string, aggregateValue float64) {
-	key := fmt.Sprintf("queue_count:{%s}:{room}:%f", gameName, aggregateValue)
+func (r *RedisClient) IncrementQueueCount(gameName string, aggregateValue models.Money) {
+	key := generateQueueCountKey(gameName, aggregateValue)
 	_, err := r.Client.Incr(context.Background(), key).Result()
 	if err != nil {
 		log.Printf("Error incrementing room aggregate: %v", err)
 	}
 }
 
-func (r *RedisClient) DecrementQueueCount(gameName string, aggregateValue float64) {
-	key := fmt.Sprintf("queue_count:{%s}:{room}:%f", gameName, aggregateValue)
+func (r *RedisClient) DecrementQueueCount(gameName string, aggregateValue models.Money) {
+	key := generateQueueCountKey(gameName, aggregateValue)
 	_, err := r.Client.Decr(context.Background(), key).Result()
 	if err != nil {
 		log.Printf("Error decrementing room aggregate: %v", err)
 	}
 }
 
-func (r *RedisClient) CheckQueueCountExists(gameName string, aggregateValue float64) (bool, error) {
-	key := fmt.Sprintf("queue_count:{%s}:{room}:%f", gameName, aggregateValue)
+func (r *RedisClient) CheckQueueCountExists(gameName string, aggregateValue models.Money) (bool, error) {
+	key := generateQueueCountKey(gameName, aggregateValue)
 
 	exists, err := r.Client.Exists(context.Background(), key).Result()
 	if err != nil {
@@ -70,13 +133,12 @@ func (r *RedisClient) GetQueueNumberResponse(gameName string) (*models.QueueNumb
 		}
 
 		for i, key := range keys {
-			// Key format: "queue_count:{gameNaME}:{room}:<aggregateValue>"
-			parts := strings.Split(key, ":")
+			// Key format: "queue_count:{gameNaME}:{room}:<currency>:<minor units>"
+			parts := strings.SplitN(key, ":", 4)
 			if len(parts) < 4 {
 				continue // malformed
 			}
-			aggregateValueStr := parts[3]
-			aggregateValue, err := strconv.ParseFloat(aggregateValueStr, 64)
+			aggregateValue, err := models.ParseMoneyKey(parts[3])
 			if err != nil {
 				continue
 			}
@@ -91,7 +153,8 @@ func (r *RedisClient) GetQueueNumberResponse(gameName string) (*models.QueueNumb
 
 			games, _ := r.CountGamesByBetValue(aggregateValue, gameName)
 			playerCount = append(playerCount, models.PlayerCountPerBetValue{
-				BetValue:    aggregateValue,
+				BetValue:    aggregateValue.Number(),
+				Currency:    aggregateValue.Currency,
 				PlayerCount: count + (games * 2),
 			})
 		}
@@ -0,0 +1,102 @@
+package redisdb
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/Lavizord/checkers-server/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Moves are split by game id over a fixed number of shard lists, each owned by one gameworker at a time, so
+// the moves of a game are applied in order by a single worker.
+const defaultMoveShards = 16
+
+// Deletes the lock, only when owner has it.
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// MoveShardCount is the number of move shards, it must be the same for serverws and the gameworkers.
+func MoveShardCount() int {
+	if shards := config.Cfg.Services["gameworker"].MoveShards; shards > 0 {
+		return shards
+	}
+	return defaultMoveShards
+}
+
+// MoveShard returns the shard of the game.
+func MoveShard(gameID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(gameID))
+	return int(h.Sum32() % uint32(MoveShardCount()))
+}
+
+// The shard is in the hash tag, so on a cluster the shards spread over the slots.
+func GenerateMoveShardListKey(gameName string, shard int) string {
+	return fmt.Sprintf("move_piece:{%s:%d}", gameName, shard)
+}
+
+// The single move list of the game, from before the shards. Moves left in it are moved to their shards.
+func GenerateLegacyMoveListKey(gameName string) string {
+	return fmt.Sprintf("move_piece:{%s}", gameName)
+}
+
+// GenerateMoveListKey is the list the moves of a game are pushed to.
+func GenerateMoveListKey(gameName, gameID string) string {
+	return GenerateMoveShardListKey(gameName, MoveShard(gameID))
+}
+
+func GenerateMoveShardLeaseKey(gameName string, shard int) string {
+	return fmt.Sprintf("move_shard_lease:{%s:%d}", gameName, shard)
+}
+
+// ReleaseLock gives up the lock, if owner has it.
+func (r *RedisClient) ReleaseLock(key, owner string) error {
+	err := releaseLockScript.Run(context.Background(), r.Client, []string{key}, owner).Err()
+	if err != nil && err != redis.Nil {
+		return fmt.Errorf("[RedisClient] - failed to release lock: %v", err)
+	}
+	return nil
+}
+
+// HoldsLock tells if owner has the lock.
+func (r *RedisClient) HoldsLock(key, owner string) (bool, error) {
+	value, err := r.Client.Get(context.Background(), key).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("[RedisClient] - failed to check lock: %v", err)
+	}
+	return value == owner, nil
+}
+
+// PopLegacyMove takes the oldest move of the legacy move list, redis.Nil when it is empty.
+func (r *RedisClient) PopLegacyMove(gameName string) (string, error) {
+	return r.Client.LPop(context.Background(), GenerateLegacyMoveListKey(gameName)).Result()
+}
+
+// HeartbeatWorker marks the worker alive, and returns the number of workers of the game that sent a
+// heartbeat within ttl.
+func (r *RedisClient) HeartbeatWorker(gameName, workerID string, ttl time.Duration) (int, error) {
+	ctx := context.Background()
+	key := fmt.Sprintf("gameworkers:{%s}", gameName)
+	now := time.Now()
+	var count *redis.IntCmd
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixMilli()), Member: workerID})
+		pipe.ZRemRangeByScore(ctx, key, "-inf", fmt.Sprintf("%d", now.Add(-ttl).UnixMilli()))
+		count = pipe.ZCard(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("[RedisClient] - failed to send worker heartbeat: %v", err)
+	}
+	return int(count.Val()), nil
+}
@@ -0,0 +1,251 @@
+package replay
+
+import (
+	"fmt"
+
+	"github.com/Lavizord/checkers-server/config"
+	"github.com/Lavizord/checkers-server/models"
+)
+
+// Deterministic replay of a finished game, for dispute resolution.
+//
+// The games table only keeps the moves, so the game is played again from the initial board through the
+// same board logic the game workers use (ValidateMove, MovePiece / ApplyMove and the end of game rules).
+// The board after each move is reported in FEN, with the first illegal move, and the replayed result is
+// checked against the one saved.
+
+// Step is one replayed move and the board after it.
+type Step struct {
+	Number   int    `json:"number"`
+	PlayerID string `json:"player_id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Capture  bool   `json:"capture"`
+	Kinged   bool   `json:"kinged"`
+	FEN      string `json:"fen"`             // Board after the move, FEN for chess and PDN FEN for damas.
+	Hash     string `json:"hash"`            // Zobrist hash of the board after the move.
+	Error    string `json:"error,omitempty"` // Why the move is illegal, the replay stops on it.
+}
+
+// Report is the result of a replay.
+type Report struct {
+	GameID         string   `json:"game_id"`
+	GameName       string   `json:"game_name"`
+	Variant        string   `json:"variant,omitempty"`
+	InitialFEN     string   `json:"initial_fen"`
+	Steps          []Step   `json:"steps"`
+	IllegalMove    int      `json:"illegal_move"` // Number of the first illegal move, 0 when every move is legal.
+	RecordedWinner string   `json:"recorded_winner"`
+	RecordedReason string   `json:"recorded_reason"`
+	ReplayWinner   string   `json:"replay_winner"`
+	ReplayReason   string   `json:"replay_reason"` // Empty when the moves do not end the game on the board.
+	WinnerMatches  bool     `json:"winner_matches"`
+	Notes          []string `json:"notes"`
+}
+
+// boardReasons are the reasons decided by the board, a replay has to reach them. The other reasons (resign,
+// timeout, player left, draw agreed, aborted) happen off the board and can't be checked from the moves.
+var boardReasons = map[string]bool{
+	models.ChessReasonCheckmate:               true,
+	models.ChessReasonStalemate:               true,
+	models.ChessReasonThreefoldRepetition:     true, // Same string as DamasReasonThreefoldRepetition.
+	models.ChessReasonFiftyMoveRule:           true,
+	models.ChessReasonInsufficientMaterial:    true,
+	models.DamasReasonKingMovesWithoutCapture: true,
+	"winner": true, // Damas, the opponent has no pieces left.
+}
+
+// replayer plays the moves of one kind of game, it mirrors the HandleMove of its game worker.
+type replayer interface {
+	// play validates and applies the move, it returns the end of game reason and winner when the move ends it.
+	play(game *models.Game, move models.MoveInterface, piece models.PieceInterface) (reason, winner string, err error)
+}
+
+// Game replays a game rebuilt from the games table, see postgrescli.FetchGame, reason is the saved
+// GameOverReason.
+func Game(record *models.Game, reason string) (*Report, error) {
+	black, white, err := models.GamePlayersByColor(record)
+	if err != nil {
+		return nil, fmt.Errorf("(Replay) - %v", err)
+	}
+	gameName := record.OperatorIdentifier.GameName
+
+	var r replayer
+	switch gameName {
+	case "BatalhaDoChess":
+		r = chessReplayer{}
+	case "BatalhaDasDamas":
+		r = damasReplayer{maxKingMoves: config.Cfg.Services["gameworker"].DrawKingMoves}
+	default:
+		return nil, fmt.Errorf("(Replay) - unknown game type: %s", gameName)
+	}
+
+	game := &models.Game{
+		ID:                 record.ID,
+		Board:              models.NewBoard(black.ID, white.ID, "std-game", gameName, record.OperatorIdentifier.Variant),
+		Players:            append([]models.GamePlayer(nil), record.Players...),
+		CurrentPlayerID:    black.ID, // Black starts the game.
+		OperatorIdentifier: record.OperatorIdentifier,
+	}
+	game.UpdatePlayerPieces()
+
+	report := &Report{
+		GameID:         record.ID,
+		GameName:       gameName,
+		Variant:        record.OperatorIdentifier.Variant,
+		InitialFEN:     game.Board.ToFEN(),
+		Steps:          []Step{},
+		RecordedWinner: record.Winner,
+		RecordedReason: reason,
+		Notes:          []string{},
+	}
+
+	// The replay board has its own piece ids, each one takes the id of the recorded piece on its first move.
+	renamed := map[string]bool{}
+	for i, move := range record.Moves {
+		step := Step{Number: i + 1, PlayerID: move.GetPlayerID(), From: move.GetFrom(), To: move.GetTo(), Capture: move.IsCaptureMove()}
+		if report.ReplayReason != "" {
+			step.Error = fmt.Sprintf("move after the game ended by %s", report.ReplayReason)
+		} else if err := checkMove(game, move, renamed); err != nil {
+			step.Error = err.Error()
+		} else {
+			piece, _ := game.Board.GetPiece(move.GetFrom())
+			end, winner, err := r.play(game, move, piece)
+			if err != nil {
+				step.Error = err.Error()
+			}
+			report.ReplayReason, report.ReplayWinner = end, winner
+			step.Kinged = move.IsKingedMove()
+		}
+		step.FEN = game.Board.ToFEN()
+		step.Hash = fmt.Sprintf("%016x", game.Board.Hash())
+		report.Steps = append(report.Steps, step)
+		if step.Error != "" {
+			report.IllegalMove = step.Number
+			report.Notes = append(report.Notes, fmt.Sprintf("move %d is illegal: %s", step.Number, step.Error))
+			break
+		}
+	}
+
+	report.WinnerMatches = compareResult(report)
+	return report, nil
+}
+
+// checkMove does the checks the moves loop of the game worker does before HandleMove, the turn and the
+// piece, and gives the replay piece the recorded id.
+func checkMove(game *models.Game, move models.MoveInterface, renamed map[string]bool) error {
+	if game.CurrentPlayerID != move.GetPlayerID() {
+		return fmt.Errorf("not the turn of player %s", move.GetPlayerID())
+	}
+	piece, _ := game.Board.GetPiece(move.GetFrom())
+	if piece == nil {
+		return fmt.Errorf("no piece at %s", move.GetFrom())
+	}
+	if piece.GetID() == move.GetPieceID() {
+		return nil
+	}
+	if renamed[piece.GetID()] || renamed[move.GetPieceID()] {
+		return fmt.Errorf("piece %s is not the piece at %s", move.GetPieceID(), move.GetFrom())
+	}
+	switch p := piece.(type) {
+	case *models.ChessPiece:
+		p.PieceID = move.GetPieceID()
+	case *models.DamasPiece:
+		p.PieceID = move.GetPieceID()
+	}
+	renamed[move.GetPieceID()] = true
+	return nil
+}
+
+// compareResult checks the recorded result against the replay and leaves a note explaining it.
+func compareResult(report *Report) bool {
+	switch {
+	case report.IllegalMove > 0:
+		return false
+	case report.ReplayReason != "":
+		if report.ReplayWinner != report.RecordedWinner || report.ReplayReason != report.RecordedReason {
+			report.Notes = append(report.Notes, fmt.Sprintf("the moves end the game by %q with winner %q, recorded %q with winner %q",
+				report.ReplayReason, report.ReplayWinner, report.RecordedReason, report.RecordedWinner))
+			return false
+		}
+		return true
+	case boardReasons[report.RecordedReason]:
+		report.Notes = append(report.Notes, fmt.Sprintf("the game is recorded as ended by %q, but the moves do not end it", report.RecordedReason))
+		return false
+	}
+	report.Notes = append(report.Notes, fmt.Sprintf("the game ended off the board by %q, the winner can't be checked from the moves", report.RecordedReason))
+	return true
+}
+
+type chessReplayer struct{}
+
+// play mirrors ChessWorker.HandleMove.
+func (chessReplayer) play(game *models.Game, move models.MoveInterface, piece models.PieceInterface) (string, string, error) {
+	board := game.Board.(*models.ChessBoard)
+	if _, err := board.ValidateMove(move, piece); err != nil {
+		return "", "", err
+	}
+	game.Moves = append(game.Moves, move)
+	if err := board.ApplyMove(move); err != nil {
+		return "", "", err
+	}
+	game.UpdatePlayerPieces()
+	if reason := board.GameOverReason(); reason != "" {
+		winner := ""
+		if reason == models.ChessReasonCheckmate {
+			winner = move.GetPlayerID()
+		}
+		return reason, winner, nil
+	}
+	game.NextPlayer()
+	return "", "", nil
+}
+
+type damasReplayer struct {
+	maxKingMoves int
+}
+
+// play mirrors DamasWorker.HandleMove, the recorded kinged flag is replaced by the one of the replay.
+func (r damasReplayer) play(game *models.Game, move models.MoveInterface, piece models.PieceInterface) (string, string, error) {
+	board := game.Board.(*models.DamasBoard)
+	if _, err := board.ValidateMove(move, piece); err != nil {
+		return "", "", err
+	}
+	wasKingMove := piece.IsPieceKinged()
+	if !game.MovePiece(move) {
+		return "", "", fmt.Errorf("board missmatch moving %s to %s", move.GetFrom(), move.GetTo())
+	}
+	game.UpdatePlayerPieces()
+	move.SetIsKingedMove(game.Board.WasPieceKinged(move.GetTo(), piece))
+	if move.IsKingedMove() {
+		piece.SetIsPieceKinged(true)
+	}
+	game.Moves = append(game.Moves, move)
+	board.RecordMove(wasKingMove, move.IsCaptureMove())
+
+	if game.CheckGameOver() {
+		return "winner", move.GetPlayerID(), nil
+	}
+	if !move.IsCaptureMove() || !game.Board.CanPieceCaptureNEW(move.GetTo()) ||
+		(move.IsKingedMove() && !board.Rules().KingedCaptureGoesOn) {
+		return r.turnChange(game, board)
+	}
+	// Same piece keeps capturing, same player.
+	board.CaptureLock = move.GetTo()
+	return "", "", nil
+}
+
+// turnChange mirrors DamasWorker.handleTurnChange.
+func (r damasReplayer) turnChange(game *models.Game, board *models.DamasBoard) (string, string, error) {
+	board.CaptureLock = ""
+	opponent, err := game.GetOpponentGamePlayer(game.CurrentPlayerID)
+	if err != nil {
+		return "", "", err
+	}
+	board.RecordPosition(opponent.Color)
+	if reason := board.DrawReason(opponent.Color, r.maxKingMoves); reason != "" {
+		return reason, "", nil
+	}
+	game.NextPlayer()
+	return "", "", nil
+}
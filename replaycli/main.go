@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Lavizord/checkers-server/config"
+	"github.com/Lavizord/checkers-server/models"
+	"github.com/Lavizord/checkers-server/postgrescli"
+	"github.com/Lavizord/checkers-server/replay"
+)
+
+// Replays a finished game to check a disputed result, from the games table or from a file with the json
+// export of the game (/api/game/{id}/export?format=json).
+//
+//	CONFIG_PATH=config/config.json go run ./replaycli -game <game id>
+//	go run ./replaycli -file game.json -json
+func main() {
+	gameID := flag.String("game", "", "id of the game to fetch from postgres")
+	file := flag.String("file", "", "json export of the game, instead of fetching it")
+	asJSON := flag.Bool("json", false, "print the report as json")
+	flag.Parse()
+
+	if (*gameID == "") == (*file == "") {
+		fmt.Fprintln(os.Stderr, "usage: replaycli -game <id> | -file <export.json> [-json]")
+		os.Exit(2)
+	}
+
+	// The config has the damas draw rules, a file can be replayed without it.
+	if *gameID != "" || os.Getenv("CONFIG_PATH") != "" {
+		config.LoadConfig()
+	} else {
+		log.Println("[replaycli] - CONFIG_PATH not set, replaying without the king moves draw rule")
+	}
+
+	var game *models.Game
+	var reason string
+	var err error
+	if *gameID != "" {
+		game, reason, err = fetchGame(*gameID)
+	} else {
+		game, reason, err = readGame(*file)
+	}
+	if err != nil {
+		log.Fatalf("[replaycli] - %v", err)
+	}
+
+	report, err := replay.Game(game, reason)
+	if err != nil {
+		log.Fatalf("[replaycli] - %v", err)
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printReport(report)
+	}
+	if !report.WinnerMatches {
+		os.Exit(1)
+	}
+}
+
+func fetchGame(gameID string) (*models.Game, string, error) {
+	pc, err := postgrescli.NewPostgresCli(
+		config.Cfg.Postgres.User,
+		config.Cfg.Postgres.Password,
+		config.Cfg.Postgres.DBName,
+		config.Cfg.Postgres.Host,
+		config.Cfg.Postgres.Port,
+		config.Cfg.Postgres.Ssl,
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	defer pc.Close()
+	return pc.FetchGame(gameID)
+}
+
+func readGame(path string) (*models.Game, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	var export struct {
+		Game   json.RawMessage `json:"game"`
+		Reason string          `json:"reason"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, "", fmt.Errorf("invalid export file: %w", err)
+	}
+	game, err := models.UnmarshalGame(export.Game)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid game on export file: %w", err)
+	}
+	return game, export.Reason, nil
+}
+
+func printReport(report *replay.Report) {
+	fmt.Printf("game %s (%s", report.GameID, report.GameName)
+	if report.Variant != "" {
+		fmt.Printf(", %s", report.Variant)
+	}
+	fmt.Printf(")\nstart: %s\n", report.InitialFEN)
+	for _, step := range report.Steps {
+		sep := "-"
+		if step.Capture {
+			sep = "x"
+		}
+		fmt.Printf("%4d. %s%s%s  %s\n", step.Number, step.From, sep, step.To, step.FEN)
+		if step.Error != "" {
+			fmt.Printf("      illegal: %s\n", step.Error)
+		}
+	}
+	fmt.Printf("recorded: %q winner %q\n", report.RecordedReason, report.RecordedWinner)
+	fmt.Printf("replayed: %q winner %q\n", report.ReplayReason, report.ReplayWinner)
+	for _, note := range report.Notes {
+		fmt.Printf("note: %s\n", note)
+	}
+	fmt.Printf("winner matches: %v\n", report.WinnerMatches)
+}
@@ -1,16 +1,25 @@
 package main
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/Lavizord/checkers-server/config"
 	"github.com/Lavizord/checkers-server/interfaces"
+	"github.com/Lavizord/checkers-server/messages"
 	"github.com/Lavizord/checkers-server/models"
 	"github.com/Lavizord/checkers-server/postgrescli"
 	"github.com/Lavizord/checkers-server/redisdb"
+	"github.com/Lavizord/checkers-server/replay"
+	"github.com/Lavizord/checkers-server/walletrequests"
 
 	"github.com/gorilla/mux"
 )
@@ -19,6 +28,9 @@ var postgresClient *postgrescli.PostgresCli
 var redisClient *redisdb.RedisClient
 var name = "restapi"
 
+// Token of the admin endpoints, from ADMIN_API_TOKEN. Without it the admin endpoints are off.
+var adminToken string
+
 func init() {
 	config.LoadConfig()
 
@@ -41,6 +53,29 @@ func init() {
 		log.Fatalf("[PostgreSQL] Error initializing POSTGRES client: %v\n", err)
 	}
 	postgresClient = sqlcliente
+	adminToken = os.Getenv("ADMIN_API_TOKEN")
+}
+
+// requireAdmin lets through only the requests with the admin token, as "Authorization: Bearer <token>".
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" {
+			respondWithJSON(w, http.StatusForbidden, map[string]interface{}{
+				"success": false,
+				"message": "Admin endpoints are disabled",
+			})
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			respondWithJSON(w, http.StatusUnauthorized, map[string]interface{}{
+				"success": false,
+				"message": "Unauthorized",
+			})
+			return
+		}
+		next(w, r)
+	}
 }
 
 func gameLaunchHandler(w http.ResponseWriter, r *http.Request) {
@@ -92,8 +127,9 @@ func handleGameLaunchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	module, exists := interfaces.OperatorModules[req.OperatorName]
-	if !exists {
+	// Operators without a module of their own need the generic wallet config.
+	_, exists := interfaces.OperatorModules[req.OperatorName]
+	if !exists && operator.WalletConfig == nil {
 		respondWithJSON(w, http.StatusBadRequest, models.GameLaunchResponse{
 			Success: false,
 			Message: fmt.Sprintf("Unsupported operator: %s", req.OperatorName),
@@ -102,6 +138,7 @@ func handleGameLaunchHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Delegate the request to the module
+	module := interfaces.GetOperatorModule(req.OperatorName)
 	module.HandleGameLaunch(w, r, req, *operator, redisClient, postgresClient)
 }
 
@@ -132,6 +169,307 @@ func gameMovesHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// gameExportHandler returns a finished game in standard notation, pgn for chess, pdn for damas, or json
+// with the typed game. Without a format the notation of the game is used.
+func gameExportHandler(w http.ResponseWriter, r *http.Request) {
+	gameID := mux.Vars(r)["id"]
+	format := r.URL.Query().Get("format")
+
+	log.Printf("Exporting gameID: [%s], format: [%s]", gameID, format)
+	game, reason, err := postgresClient.FetchGame(gameID)
+	if err != nil {
+		respondWithJSON(w, http.StatusNotFound, map[string]interface{}{
+			"success": false,
+			"message": "Failed to fetch game :" + err.Error(),
+		})
+		return
+	}
+	if format == "" {
+		format = "pgn"
+		if game.OperatorIdentifier.GameName == "BatalhaDasDamas" {
+			format = "pdn"
+		}
+	}
+
+	var export string
+	switch format {
+	case "json":
+		respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"game":    game,
+			"reason":  reason,
+			"result":  models.GameResult(game, reason),
+		})
+		return
+	case "pgn":
+		export, err = models.ExportPGN(game, reason)
+	case "pdn":
+		export, err = models.ExportPDN(game, reason)
+	default:
+		respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"message": "Invalid format, expected pgn, pdn or json",
+		})
+		return
+	}
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"message": "Failed to export game :" + err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", game.ID+"."+format))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(export))
+}
+
+// gameReplayHandler replays a finished game from the archive, for disputes, see the replay package.
+func gameReplayHandler(w http.ResponseWriter, r *http.Request) {
+	gameID := mux.Vars(r)["id"]
+
+	log.Printf("Replaying gameID: [%s]", gameID)
+	game, reason, err := postgresClient.FetchGame(gameID)
+	if err != nil {
+		respondWithJSON(w, http.StatusNotFound, map[string]interface{}{
+			"success": false,
+			"message": "Failed to fetch game :" + err.Error(),
+		})
+		return
+	}
+	report, err := replay.Game(game, reason)
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"message": "Failed to replay game :" + err.Error(),
+		})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"report":  report,
+	})
+}
+
+// Page size of the live games listing when none or a too big one is asked.
+const liveGamesMaxLimit = 100
+
+// liveGamesHandler lists the games in progress for the operator lobbies, newest first. game_name is needed,
+// operator_name, offset and limit are optional. Anyone can call it, so the games are anonymous.
+func liveGamesHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	gameName := query.Get("game_name")
+	if gameName == "" {
+		respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"message": "Missing game_name",
+		})
+		return
+	}
+	respondWithLiveGames(w, r, gameName, query.Get("operator_name"), true)
+}
+
+// operatorLiveGamesHandler lists the games in progress of the operator, with its players, for a request signed
+// by the operator.
+func operatorLiveGamesHandler(w http.ResponseWriter, r *http.Request) {
+	operator, _, ok := signedOperatorRequest(w, r)
+	if !ok {
+		return
+	}
+	respondWithLiveGames(w, r, operator.GameName, operator.OperatorName, false)
+}
+
+// respondWithLiveGames answers with the page of live games of the offset and limit of the query.
+func respondWithLiveGames(w http.ResponseWriter, r *http.Request, gameName, operatorName string, anonymous bool) {
+	query := r.URL.Query()
+	offset, err := strconv.ParseInt(query.Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	limit, err := strconv.ParseInt(query.Get("limit"), 10, 64)
+	if err != nil || limit <= 0 || limit > liveGamesMaxLimit {
+		limit = liveGamesMaxLimit
+	}
+
+	page, err := redisClient.GetLiveGames(gameName, operatorName, offset, limit)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"success": false,
+			"message": "Failed to fetch live games :" + err.Error(),
+		})
+		return
+	}
+	if anonymous {
+		for i := range page.Games {
+			page.Games[i] = page.Games[i].Anonymous()
+		}
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"games":   page.Games,
+		"total":   page.Total,
+		"offset":  page.Offset,
+		"limit":   page.Limit,
+	})
+}
+
+// reconciliationHandler lists the rounds whose bets and wins don't balance in the wallet ledger. since is an
+// RFC3339 date, by default the last week. Admin only, it has the rounds of all the operators.
+func reconciliationHandler(w http.ResponseWriter, r *http.Request) {
+	since := time.Now().Add(-7 * 24 * time.Hour)
+	if value := r.URL.Query().Get("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"success": false,
+				"message": "Invalid since, expected an RFC3339 date",
+			})
+			return
+		}
+		since = parsed
+	}
+	rounds, err := postgresClient.FetchUnbalancedRounds(since)
+	if err != nil {
+		log.Printf("[Reconciliation] - failed to fetch the unbalanced rounds: %v", err)
+		respondWithJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"success": false,
+			"message": "Failed to fetch the reconciliation",
+		})
+		return
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"since":   since,
+		"rounds":  rounds,
+	})
+}
+
+// Biggest body of an operator callback.
+const walletCallbackMaxBody = 1 << 16
+
+// signedOperatorRequest checks a request of the operator of the url, signed like our wallet calls to them, and
+// gives back the operator and the body. When it fails the answer is already sent.
+func signedOperatorRequest(w http.ResponseWriter, r *http.Request) (*models.Operator, []byte, bool) {
+	vars := mux.Vars(r)
+	operatorName, gameID := vars["operator"], vars["game"]
+	operator, err := redisClient.GetOperator(operatorName, gameID)
+	if err != nil {
+		operator, err = postgresClient.FetchOperator(operatorName, gameID)
+		if err != nil {
+			respondWithJSON(w, http.StatusNotFound, map[string]interface{}{
+				"success": false,
+				"message": "Invalid operator / gameID",
+			})
+			return nil, nil, false
+		}
+		redisClient.AddOperator(operator)
+	}
+	signing := operator.Signing
+	if signing == nil && operator.WalletConfig != nil {
+		signing = operator.WalletConfig.Auth.Signing()
+	}
+	if signing == nil {
+		respondWithJSON(w, http.StatusForbidden, map[string]interface{}{
+			"success": false,
+			"message": "Operator requests need the operator signing to be set up",
+		})
+		return nil, nil, false
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, walletCallbackMaxBody))
+	if err != nil {
+		respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return nil, nil, false
+	}
+	nonce, err := walletrequests.VerifySignature(r.Header, body, signing, time.Now())
+	if err != nil {
+		log.Printf("[OperatorRequest] - rejected request of operator %s: %v", operatorName, err)
+		respondWithJSON(w, http.StatusUnauthorized, map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return nil, nil, false
+	}
+	fresh, err := redisClient.ClaimWalletNonce(operatorName, nonce, 2*time.Duration(signing.MaxSkew())*time.Second)
+	if err != nil {
+		respondWithJSON(w, http.StatusInternalServerError, map[string]interface{}{
+			"success": false,
+			"message": "Failed to check the nonce :" + err.Error(),
+		})
+		return nil, nil, false
+	}
+	if !fresh {
+		respondWithJSON(w, http.StatusConflict, map[string]interface{}{
+			"success": false,
+			"message": "Nonce already used",
+		})
+		return nil, nil, false
+	}
+	return operator, body, true
+}
+
+// walletCallbackHandler takes the signed callbacks of the operators, signed like our wallet calls to them. A
+// balance_update is sent to the player if online, a cancel rolls back the transaction in our ledger.
+func walletCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	operator, body, ok := signedOperatorRequest(w, r)
+	if !ok {
+		return
+	}
+	operatorName := operator.OperatorName
+
+	var callback models.WalletCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"message": "Invalid request body",
+		})
+		return
+	}
+	if err := callback.Validate(); err != nil {
+		respondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if callback.Type == models.WalletCallbackCancel {
+		found, err := postgresClient.CancelLedgerEntry(callback.TransactionID, operatorName, callback.Reason)
+		if err != nil {
+			respondWithJSON(w, http.StatusInternalServerError, map[string]interface{}{
+				"success": false,
+				"message": "Failed to cancel the transaction :" + err.Error(),
+			})
+			return
+		}
+		if !found {
+			respondWithJSON(w, http.StatusNotFound, map[string]interface{}{
+				"success": false,
+				"message": "No pending bet with this transaction",
+			})
+			return
+		}
+		log.Printf("[WalletCallback] - operator %s cancelled transaction %s: %s", operatorName, callback.TransactionID, callback.Reason)
+	}
+
+	// The player and the session share the same ID, an offline player just misses the update.
+	if callback.Balance != nil {
+		session, err := redisClient.GetSessionByOperatorPlayerCurrency(operatorName, callback.Player, callback.Currency)
+		if err == nil && session != nil {
+			msg, _ := messages.GenerateBalanceUpdateMessage(models.NewMoney(*callback.Balance, session.Currency))
+			redisClient.PublishToPlayerID(session.ID, string(msg))
+		}
+	}
+	respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+	})
+}
+
 // Utility function to respond with JSON
 func respondWithJSON(w http.ResponseWriter, status int, payload interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -142,6 +480,12 @@ func respondWithJSON(w http.ResponseWriter, status int, payload interface{}) {
 func registerRoutes(r *mux.Router) {
 	r.HandleFunc("/api/gamelaunch", gameLaunchHandler).Methods("POST")
 	r.HandleFunc("/api/game/moves", gameMovesHandler).Methods("POST")
+	r.HandleFunc("/api/game/{id}/export", gameExportHandler).Methods("GET")
+	r.HandleFunc("/api/game/{id}/replay", gameReplayHandler).Methods("GET")
+	r.HandleFunc("/api/games/live", liveGamesHandler).Methods("GET")
+	r.HandleFunc("/api/ledger/reconciliation", requireAdmin(reconciliationHandler)).Methods("GET")
+	r.HandleFunc("/api/wallet/{operator}/{game}/callback", walletCallbackHandler).Methods("POST")
+	r.HandleFunc("/api/operator/{operator}/{game}/games/live", operatorLiveGamesHandler).Methods("GET")
 
 	healthHandler := func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
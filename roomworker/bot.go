@@ -0,0 +1,40 @@
+package main
+
+import (
+	"time"
+
+	"github.com/Lavizord/checkers-server/config"
+	"github.com/Lavizord/checkers-server/logger"
+	"github.com/Lavizord/checkers-server/models"
+)
+
+// botCanJoin tells if the house bot can play the player that is waiting alone since the given time.
+// The bot needs to be enabled on the config (bot_wait) and on the operator of the player.
+func (rw *RoomWorker) botCanJoin(player *models.Player, disconnected bool, since time.Time) bool {
+	wait := config.Cfg.Services["roomworker"].BotWait
+	if wait <= 0 || disconnected || !player.OperatorIdentifier.BotEnabled || since.IsZero() {
+		return false
+	}
+	return time.Since(since) >= time.Duration(wait)*time.Second
+}
+
+// HandleBotPaired creates a bot for the player and pairs them, from here on the room works like any other,
+// the bot is always ready and the game starts when the player is ready.
+func (rw *RoomWorker) HandleBotPaired(player *models.Player) {
+	bot := models.NewBotPlayer(player)
+	if err := rw.RedisClient.AddPlayer(bot); err != nil {
+		logger.Default.Errorf("failed to add the bot to redis, re-queueing player with id: %v, with err: %v", player.ID, err)
+		rw.AddPlayerToQueue(player, false, false)
+		return
+	}
+	rw.HandleQueuePaired(player, bot, false, false)
+}
+
+// pruneWaiting drops the players that are waiting for too long, they already left the queue.
+func pruneWaiting(waitingSince map[string]time.Time) {
+	for id, since := range waitingSince {
+		if time.Since(since) > time.Hour {
+			delete(waitingSince, id)
+		}
+	}
+}
@@ -23,12 +23,24 @@ func (rw *RoomWorker) HandleQueuePaired(player1, player2 *models.Player, p1disc,
 		Currency:           player1.Currency,
 		BetValue:           player1.SelectedBet,
 		OperatorIdentifier: player1.OperatorIdentifier,
+		Variant:            player1.OperatorIdentifier.Variant,
+		FreePlay:           models.IsFreePlayBet(player1.SelectedBet),
 	}
+	timeControl, err := models.TimeControlForBet(room.BetValue)
+	if err != nil {
+		// A bad spec in the config shouldn't stop the players, the game falls back to the timer setting.
+		log.Printf("Failed to get time control for bet: %v, with err: %v\n", room.BetValue, err)
+	}
+	room.TimeControl = timeControl
 
 	player1.RoomID = room.ID
 	player2.RoomID = room.ID
 	player1.Status = models.StatusInRoom
 	player2.Status = models.StatusInRoom
+	// The bot is always ready, the game starts as soon as the player is.
+	if player2.IsBot {
+		player2.Status = models.StatusInRoomReady
+	}
 
 	if p1disc == true {
 		rw.RedisClient.SaveDisconnectInQueuePlayerData(player1)
@@ -59,8 +71,11 @@ func (rw *RoomWorker) HandleQueuePaired(player1, player2 *models.Player, p1disc,
 				rw.RedisClient.UpdatePlayer(player2)
 			}
 			rw.RedisClient.RemoveRoom(redisdb.GenerateRoomRedisKeyById(room.ID))
-			rw.RedisClient.DecrementQueueCount(rw.GameName, player1.SelectedBet)
-			rw.RedisClient.DecrementQueueCount(rw.GameName, player2.SelectedBet)
+			rw.decrementQueueCount(player1)
+			rw.decrementQueueCount(player2)
+			if player2.IsBot {
+				rw.RedisClient.RemovePlayer(player2.ID)
+			}
 			msg, _ := messages.GenerateGenericMessage("error", "failed to handle queue paired.")
 			rw.RedisClient.PublishToPlayer(*player1, string(msg))
 			rw.RedisClient.PublishToPlayer(*player2, string(msg))
@@ -76,19 +91,19 @@ func (rw *RoomWorker) HandleQueuePaired(player1, player2 *models.Player, p1disc,
 		room.CurrentPlayerID = player2.ID
 	}
 
-	err := rw.RedisClient.AddRoom(rw.GameName, room)
+	err = rw.RedisClient.AddRoom(rw.GameName, room)
 	if err != nil {
 		log.Printf("Failed to add room to Redis: %v\n", err)
 		return
 	}
 
-	message1, err := messages.GeneratePairedMessage(player1, player2, room.ID, colorp1, interfaces.CalculateWinAmount(int64(room.BetValue*100), room.OperatorIdentifier.WinFactor), 30)
+	message1, err := messages.GeneratePairedMessage(player1, player2, room.ID, colorp1, interfaces.CalculateWinAmount(room.BetValue, room.WinFactor()), 30, room.TimeControl)
 	if err != nil {
 		log.Printf("Error generating message for player1: %v\n", err)
 		return
 	}
 
-	message2, err := messages.GeneratePairedMessage(player2, player1, room.ID, colorp2, interfaces.CalculateWinAmount(int64(room.BetValue*100), room.OperatorIdentifier.WinFactor), 30)
+	message2, err := messages.GeneratePairedMessage(player2, player1, room.ID, colorp2, interfaces.CalculateWinAmount(room.BetValue, room.WinFactor()), 30, room.TimeControl)
 	if err != nil {
 		log.Printf("Error generating message for player2: %v\n", err)
 		return
@@ -109,8 +124,16 @@ func (rw *RoomWorker) HandleQueuePaired(player1, player2 *models.Player, p1disc,
 	rw.ListenRoom(context.Background(), redisClient, room)
 
 	cleanup = false
-	rw.RedisClient.DecrementQueueCount(rw.GameName, player1.SelectedBet)
-	rw.RedisClient.DecrementQueueCount(rw.GameName, player2.SelectedBet)
+	rw.decrementQueueCount(player1)
+	rw.decrementQueueCount(player2)
+}
+
+// decrementQueueCount takes the player out of the queue count, the bot was never counted.
+func (rw *RoomWorker) decrementQueueCount(player *models.Player) {
+	if player.IsBot {
+		return
+	}
+	rw.RedisClient.DecrementQueueCount(rw.GameName, player.SelectedBet)
 }
 
 func (rw *RoomWorker) HandleReadyRoomNew(player *models.Player, proom *models.Room) {
@@ -143,25 +166,32 @@ func (rw *RoomWorker) HandleReadyRoomNew(player *models.Player, proom *models.Ro
 		return
 	}
 	// Now! If both players are ready...!!
-	// Before we start the game, we will need to post to the wallet api of the bet, we will use our api interface for that.
-	module, exists := interfaces.OperatorModules[proom.OperatorIdentifier.OperatorName]
-	if !exists {
-		log.Printf("[RoomWorker-%d] - Error handleReadyRoom getting getting interfaces.OperatorModules[%v]:%s\n", pid, proom.OperatorIdentifier.OperatorName, err)
+	// Free-play games have no bets, they just start.
+	if proom.FreePlay {
+		rw.RedisClient.UpdatePlayer(player)
+		rw.RedisClient.UpdatePlayer(player2)
+		rw.startRoomGame(proom)
 		return
 	}
+	// Before we start the game, we will need to post to the wallet api of the bet, we will use our api interface for that.
+	module := interfaces.GetOperatorModule(proom.OperatorIdentifier.OperatorName)
 
 	session1, err := rw.RedisClient.GetSessionByID(player.SessionID)
 	if err != nil {
 		log.Printf("[RoomWorker-%d] - Error handleReadyRoom fetching player1 sessionID:%s\n", pid, err)
 		return
 	}
-	session2, err := rw.RedisClient.GetSessionByID(player2.SessionID)
-	if err != nil {
-		log.Printf("[RoomWorker-%d] - Error handleReadyRoom fetching player2 sessionID:%s\n", pid, err)
-		return
+	// The bot has no session, its bet is not taken from any wallet.
+	var session2 *models.Session
+	if !player2.IsBot {
+		session2, err = rw.RedisClient.GetSessionByID(player2.SessionID)
+		if err != nil {
+			log.Printf("[RoomWorker-%d] - Error handleReadyRoom fetching player2 sessionID:%s\n", pid, err)
+			return
+		}
 	}
 
-	newBalance1, err := module.HandlePostBet(postgresClient, redisClient, *session1, int64(proom.BetValue*100), proom.ID)
+	newBalance1, err := module.HandlePostBet(postgresClient, redisClient, *session1, proom.BetValue, proom.ID)
 	if err != nil {
 		log.Printf("[RoomWorker-%d] - Error HandlePostBet failed to bet:%s for sessionid:[%s]\n", pid, err, session1.ID)
 		player.SetStatusOnline()
@@ -175,26 +205,38 @@ func (rw *RoomWorker) HandleReadyRoomNew(player *models.Player, proom *models.Ro
 
 		// since the first player failed the api check, we will queue up the second plyer.
 		rw.AddPlayerToQueue(player2, true, true)
-		// TODO: CREDITAR VALOR A JOGADOR.
 		return
 	}
-	newBalance2, err := module.HandlePostBet(postgresClient, redisClient, *session2, int64(proom.BetValue*100), proom.ID)
+	var newBalance2 models.Money
+	if session2 != nil {
+		newBalance2, err = module.HandlePostBet(postgresClient, redisClient, *session2, proom.BetValue, proom.ID)
+	}
 	if err != nil {
-		log.Printf("[RoomWorker-%d] - Error HandlePostBet failed to bet:%s for sessionid:[%s]\n", pid, err, session1.ID)
+		log.Printf("[RoomWorker-%d] - Error HandlePostBet failed to bet:%s for sessionid:[%s]\n", pid, err, session2.ID)
 		player2.SetStatusOnline()
 		rw.RedisClient.UpdatePlayer(player2)
 		msg, _ := messages.GenerateGenericMessage("error", err.Error())
 		rw.RedisClient.PublishPlayerEvent(player2, string(msg))
 		rw.RedisClient.RemoveRoom(redisdb.GenerateRoomRedisKeyById(proom.ID))
 
+		// The first player already bet, the bet is given back. If it fails the ledgerworker keeps trying.
+		rolledBalance, err := module.HandleRollbackBet(postgresClient, redisClient, *session1, proom.BetValue, proom.ID)
+		if err != nil {
+			log.Printf("[RoomWorker-%d] - Error HandleRollbackBet failed to rollback:%s for sessionid:[%s]\n", pid, err, session1.ID)
+		} else {
+			msg, _ = messages.GenerateBalanceUpdateMessage(rolledBalance)
+			rw.RedisClient.PublishPlayerEvent(player, string(msg))
+		}
+		msg, _ = messages.NewMessage("opponent_left_room", true)
+		rw.RedisClient.PublishPlayerEvent(player, string(msg))
+
 		// since the second player failed the api check, we will queue up the first player.
-		rw.AddPlayerToQueue(player2, true, true)
-		// TODO: CREDITAR VALOR A JOGADOR.
+		rw.AddPlayerToQueue(player, true, true)
 		return
 	}
 	// Now that everything is OK, we will start up the game
-	msgP1, _ := messages.NewMessage("balance_update", float64(newBalance1)/100)
-	msgP2, _ := messages.NewMessage("balance_update", float64(newBalance2)/100)
+	msgP1, _ := messages.GenerateBalanceUpdateMessage(newBalance1)
+	msgP2, _ := messages.GenerateBalanceUpdateMessage(newBalance2)
 
 	// then notify player and store it in redis.
 	rw.RedisClient.UpdatePlayer(player)
@@ -203,16 +245,20 @@ func (rw *RoomWorker) HandleReadyRoomNew(player *models.Player, proom *models.Ro
 	rw.RedisClient.PublishPlayerEvent(player, string(msgP1))
 	rw.RedisClient.PublishPlayerEvent(player2, string(msgP2))
 
+	rw.startRoomGame(proom)
+}
+
+// startRoomGame closes the room and hands it over to the gameworker, once the bets are posted.
+func (rw *RoomWorker) startRoomGame(proom *models.Room) {
 	rw.RedisClient.PublishToRoomPubSub(proom.ID, "game_start")
 
 	// Then we start a match
 	roomdata, _ := json.Marshal(proom)
 	key := fmt.Sprintf("create_game:{%v}", rw.GameName)
-	err = rw.RedisClient.RPushGeneric(key, roomdata)
+	err := rw.RedisClient.RPushGeneric(key, roomdata)
 	if err != nil {
 		log.Printf("[RoomWorker-%d] - Error handleReadyRoom Creating Game RPushGeneric:%s\n", pid, err)
 	}
-
 }
 
 func (rw *RoomWorker) HandleUnReadyRoomNew(player *models.Player, proom *models.Room) {
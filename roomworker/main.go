@@ -5,7 +5,6 @@ import (
 
 	"github.com/Lavizord/checkers-server/config"
 	"github.com/Lavizord/checkers-server/logger"
-	"github.com/Lavizord/checkers-server/models"
 	"github.com/Lavizord/checkers-server/postgrescli"
 	"github.com/Lavizord/checkers-server/redisdb"
 )
@@ -52,7 +51,7 @@ func main() {
 		logger.Default.Fatalf("no GAME_ENGINE env variable defined, exiting")
 	}
 
-	worker := NewRoomWorker(redisClient, gameEngine, models.DamasValidBetAmounts)
+	worker := NewRoomWorker(redisClient, gameEngine)
 
 	worker.Run()
 	//workerCheckers.Run()
@@ -85,7 +85,7 @@ func (rw *RoomWorker) ListenRoom(ctx context.Context, rdb *redisdb.RedisClient,
 					playerID := strings.TrimPrefix(msg.Payload, "player_reconnect:")
 					opponent, _ := room.GetOpponentPlayer(playerID)
 					player, _ := room.GetOpponentPlayer(opponent.ID)
-					outBoundMsg, _ := messages.GeneratePairedMessage(player, opponent, room.ID, room.DeducePlayerColor(playerID), interfaces.CalculateWinAmount(int64(room.BetValue*100), room.OperatorIdentifier.WinFactor), countdown)
+					outBoundMsg, _ := messages.GeneratePairedMessage(player, opponent, room.ID, room.DeducePlayerColor(playerID), interfaces.CalculateWinAmount(room.BetValue, room.WinFactor()), countdown, room.TimeControl)
 					rdb.PublishToPlayerID(playerID, string(outBoundMsg))
 				}
 
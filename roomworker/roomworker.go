@@ -13,16 +13,17 @@ import (
 )
 
 type RoomWorker struct {
-	RedisClient      *redisdb.RedisClient
-	GameName         string
-	QueueBetAmmounts []float64
+	RedisClient *redisdb.RedisClient
+	GameName    string
+	// Bet queues with a goroutine already, only ProcessQueue touches it.
+	betQueues map[redisdb.BetQueue]bool
 }
 
-func NewRoomWorker(redis *redisdb.RedisClient, gn string, betAmounts []float64) *RoomWorker {
+func NewRoomWorker(redis *redisdb.RedisClient, gn string) *RoomWorker {
 	return &RoomWorker{
-		RedisClient:      redis,
-		GameName:         gn,
-		QueueBetAmmounts: betAmounts,
+		RedisClient: redis,
+		GameName:    gn,
+		betQueues:   map[redisdb.BetQueue]bool{},
 	}
 }
 
@@ -32,16 +33,32 @@ func (rw *RoomWorker) Run() {
 }
 
 func (rw *RoomWorker) ProcessQueue() {
-	// Launch a goroutine for each bet queue
-	for _, bet := range rw.QueueBetAmmounts {
-		go rw.ProcessQueueForBet(bet)
+	// The bets depend on the tiers of each operator and currency, so the queues are picked up from the
+	// registry as the players join them. Launch a goroutine for each new bet queue.
+	for {
+		queues, err := rw.RedisClient.GetBetQueues(rw.GameName)
+		if err != nil {
+			logger.Default.Errorf("error retrieving the bet queues: %v", err)
+		}
+		for _, queue := range queues {
+			if rw.betQueues[queue] {
+				continue
+			}
+			rw.betQueues[queue] = true
+			logger.Default.Infof("processing new bet queue: %v", redisdb.GenerateQueueName(rw.GameName, queue))
+			go rw.ProcessQueueForBet(queue)
+		}
+		time.Sleep(time.Second * 2)
 	}
-	// Block forever or wait on a channel (to prevent the main goroutine from exiting)
-	select {}
 }
 
-func (rw *RoomWorker) ProcessQueueForBet(bet float64) {
-	queueName := fmt.Sprintf("queue:{%v}:%f", rw.GameName, bet)
+// ProcessQueueForBet pairs the players of a bet queue. The queue is of a single rule variant, so any two of
+// its players can play each other.
+func (rw *RoomWorker) ProcessQueueForBet(queue redisdb.BetQueue) {
+	bet := queue.Bet
+	queueName := redisdb.GenerateQueueName(rw.GameName, queue)
+	// When each player 1 started waiting alone, for the house bot. Only this goroutine reads this queue.
+	waitingSince := map[string]time.Time{}
 	for {
 		// Block indefinitely for player1 (this goroutine is dedicated to this queue)
 		player1, err := rw.RedisClient.BLPop(queueName, 0)
@@ -77,10 +94,20 @@ func (rw *RoomWorker) ProcessQueueForBet(bet float64) {
 			rw.RedisClient.DecrementQueueCount(rw.GameName, bet)
 			continue
 		}
+		if _, ok := waitingSince[player1.ID]; !ok {
+			waitingSince[player1.ID] = time.Now()
+		}
 
 		// Try fetching the second player with a timeout
 		player2, err := rw.RedisClient.BLPop(queueName, config.Cfg.Services["roomworker"].Timer)
 		if err != nil {
+			if rw.botCanJoin(player1Details, p1disc, waitingSince[player1.ID]) {
+				delete(waitingSince, player1.ID)
+				logger.Default.Infof("player with id: %v waited alone in queue: %v, pairing with the house bot", player1.ID, queueName)
+				rw.HandleBotPaired(player1)
+				continue
+			}
+			pruneWaiting(waitingSince)
 			logger.Default.Infof("No second player found in queue: %s, re-queueing player 1 with id: %v", player1.ID, queueName)
 			// Since we failed to get the player2, we will requeue the player1.
 			time.Sleep(time.Second * 1)
@@ -130,6 +157,8 @@ func (rw *RoomWorker) ProcessQueueForBet(bet float64) {
 
 		// Process both players
 		// log.Printf("[RoomWorker-%d] - Pairing players: %s and %s from %s\n", pid, player1, player2, queueName)
+		delete(waitingSince, player1.ID)
+		delete(waitingSince, player2.ID)
 		rw.HandleQueuePaired(player1, player2, p1disc, p2disc)
 	}
 }
@@ -159,6 +188,11 @@ func (rw *RoomWorker) ProcessReadyQueue() {
 }
 
 func (rw *RoomWorker) AddPlayerToQueue(player *models.Player, incrementQueueCount, notify bool) {
+	// The bot only exists for the room it was created for, it never goes back to the queue.
+	if player.IsBot {
+		rw.RedisClient.RemovePlayer(player.ID)
+		return
+	}
 	// Reset both player data.
 	player.RoomID = ""
 	player.GameID = ""
@@ -169,7 +203,7 @@ func (rw *RoomWorker) AddPlayerToQueue(player *models.Player, incrementQueueCoun
 	}
 
 	// Pushing the player to the "queue" Redis list
-	queueName := fmt.Sprintf("queue:{%v}:%f", rw.GameName, player.SelectedBet)
+	queueName := redisdb.GenerateQueueName(rw.GameName, redisdb.PlayerBetQueue(player))
 	err2 := rw.RedisClient.RPush(queueName, player)
 	if err2 != nil {
 		log.Printf("[RoomWorker-%d] - Error adding player to queue:%v\n", pid, err2)
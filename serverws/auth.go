@@ -7,6 +7,7 @@ import (
 	"github.com/Lavizord/checkers-server/interfaces"
 	"github.com/Lavizord/checkers-server/logger"
 	"github.com/Lavizord/checkers-server/models"
+	"github.com/Lavizord/checkers-server/postgrescli"
 	"github.com/Lavizord/checkers-server/redisdb"
 )
 
@@ -51,11 +52,11 @@ func fetchAndValidateSession(token, sessionID, currency string, redis *redisdb.R
 	return session, nil
 }
 
-func FetchWalletBallance(session *models.Session, redis *redisdb.RedisClient) (int64, error) {
-	module := interfaces.OperatorModules[session.OperatorIdentifier.OperatorName]
-	walletBalance, err := module.HandleFetchWalletBalance(*session, redis)
+func FetchWalletBallance(session *models.Session, db *postgrescli.PostgresCli, redis *redisdb.RedisClient) (models.Money, error) {
+	module := interfaces.GetOperatorModule(session.OperatorIdentifier.OperatorName)
+	walletBalance, err := module.HandleFetchWalletBalance(db, redis, *session)
 	if err != nil {
-		return 0, fmt.Errorf("failed to fetch wallet for session: %v, with error: %v", session.ID, err)
+		return models.Money{}, fmt.Errorf("failed to fetch wallet for session: %v, with error: %v", session.ID, err)
 	}
 	return walletBalance, nil
 }
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Lavizord/checkers-server/logger"
@@ -72,6 +73,9 @@ type Client struct {
 	// Context, to be used to close
 	ctx    context.Context
 	cancel context.CancelFunc
+
+	// Unix time of the last resign command, the resign only happens if confirmed within resignConfirmWindow.
+	resignRequestedAt atomic.Int64
 }
 
 // CloseConnection cancels the client context
@@ -237,7 +241,7 @@ func serveWs(hub *Hub, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	balance, err := FetchWalletBallance(session, hub.redis)
+	balance, err := FetchWalletBallance(session, hub.db, hub.redis)
 	if err != nil {
 		logger.Default.Errorf("[Client] - serveWs - error fetching wallet for session: %v, with err: %v", session.ID, err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/Lavizord/checkers-server/config"
 	"github.com/Lavizord/checkers-server/logger"
 	"github.com/Lavizord/checkers-server/models"
+	"github.com/Lavizord/checkers-server/postgrescli"
 	"github.com/Lavizord/checkers-server/redisdb"
 	"github.com/redis/go-redis/v9"
 )
@@ -27,6 +29,9 @@ type Hub struct {
 
 	redis *redisdb.RedisClient
 
+	// The operators missing from the redis cache are loaded from postgres.
+	db *postgrescli.PostgresCli
+
 	broadastpubsub *redis.PubSub
 
 	gameName string
@@ -40,12 +45,24 @@ func newHub(addr string, username string, password string, redistls bool, gn str
 	if err != nil {
 		logger.Default.Fatalf("[Redis] Error initializing Redis client: %v", err)
 	}
+	sqlcliente, err := postgrescli.NewPostgresCli(
+		config.Cfg.Postgres.User,
+		config.Cfg.Postgres.Password,
+		config.Cfg.Postgres.DBName,
+		config.Cfg.Postgres.Host,
+		config.Cfg.Postgres.Port,
+		config.Cfg.Postgres.Ssl,
+	)
+	if err != nil {
+		logger.Default.Fatalf("[Postgres] Error initializing POSTGRES client: %v", err)
+	}
 	return &Hub{
 		broadcast:  make(chan []byte),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
 		clients:    make(map[*Client]bool),
 		redis:      redisclient,
+		db:         sqlcliente,
 		gameName:   gn,
 	}
 }
@@ -55,6 +72,7 @@ func (h *Hub) Close() {
 		h.broadastpubsub.Close()
 	}
 	h.redis.CloseRedisClient()
+	h.db.Close()
 }
 
 func (h *Hub) run() {
@@ -38,6 +38,9 @@ func main() {
 	http.HandleFunc(url, func(w http.ResponseWriter, r *http.Request) {
 		serveWs(hub, w, r)
 	})
+	http.HandleFunc(fmt.Sprintf("/ws/%s/spectate", urlSufix), func(w http.ResponseWriter, r *http.Request) {
+		serveSpectator(hub, w, r)
+	})
 	http.HandleFunc("/ws/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/Lavizord/checkers-server/logger"
 	"github.com/Lavizord/checkers-server/messages"
@@ -65,6 +66,50 @@ func RouteMessages(message *messages.Message[json.RawMessage], client *Client, r
 		}
 		handleMovePiece(message, client, redis)
 		return
+
+	case "request_takeback", "accept_takeback":
+		logger.Default.Infof("[wsapi] - RouteMessages - received %v message, sending it to handleTakeback for session id: %v", message.Command, client.player.ID)
+		if client.player.Status != models.StatusInGame {
+			logger.Default.Warnf("[wsapi] - RouteMessages - cant issue a %v when not in a Game for session id: %v", message.Command, client.player.ID)
+			msg, _ := messages.GenerateGenericMessage("invalid", "Can't issue a takeback command when not in a game.")
+			client.send <- msg
+			return
+		}
+		handleTakeback(message, client, redis)
+		return
+
+	case "premove", "cancel_premove":
+		logger.Default.Infof("[wsapi] - RouteMessages - received %v message, sending it to handlePremove for session id: %v", message.Command, client.player.ID)
+		if client.player.Status != models.StatusInGame {
+			logger.Default.Warnf("[wsapi] - RouteMessages - cant issue a %v when not in a Game for session id: %v", message.Command, client.player.ID)
+			msg, _ := messages.GenerateGenericMessage("invalid", "Can't issue a premove when not in a game.")
+			client.send <- msg
+			return
+		}
+		handlePremove(message, client, redis)
+		return
+
+	case "resign", "confirm_resign", "abort_game":
+		logger.Default.Infof("[wsapi] - RouteMessages - received %v message, sending it to handleResign for session id: %v", message.Command, client.player.ID)
+		if client.player.Status != models.StatusInGame {
+			logger.Default.Warnf("[wsapi] - RouteMessages - cant issue a %v when not in a Game for session id: %v", message.Command, client.player.ID)
+			msg, _ := messages.GenerateGenericMessage("invalid", "Can't resign or abort when not in a game.")
+			client.send <- msg
+			return
+		}
+		handleResign(message, client, redis)
+		return
+
+	case "offer_draw", "respond_draw":
+		logger.Default.Infof("[wsapi] - RouteMessages - received %v message, sending it to handleDraw for session id: %v", message.Command, client.player.ID)
+		if client.player.Status != models.StatusInGame {
+			logger.Default.Warnf("[wsapi] - RouteMessages - cant issue a %v when not in a Game for session id: %v", message.Command, client.player.ID)
+			msg, _ := messages.GenerateGenericMessage("invalid", "Can't issue a draw command when not in a game.")
+			client.send <- msg
+			return
+		}
+		handleDraw(message, client, redis)
+		return
 	}
 }
 
@@ -83,7 +128,7 @@ func handleLeaveQueue(client *Client, redis *redisdb.RedisClient) {
 	client.player.SetStatusOnline()
 	redis.UpdatePlayer(client.player) // This is important, we will only re-add players to a queue that are in queue.
 	//redis.UpdatePlayersInQueueSet(client.player.ID, models.StatusOnline)
-	queueName := fmt.Sprintf("queue:{%v}:%f", client.hub.gameName, client.player.SelectedBet)
+	queueName := redisdb.GenerateQueueName(client.hub.gameName, redisdb.PlayerBetQueue(client.player))
 	err := redis.RemovePlayerFromQueue(queueName, client.player)
 	if err != nil {
 		//log.Printf("Error removing player from Redis queue: %v\n", err)	//! This was commented, it fails when there is only 1 player in queue.
@@ -180,15 +225,19 @@ func handleMovePiece(message *messages.Message[json.RawMessage], client *Client,
 		logger.Default.Errorf("[wsapi] - handleMovePiece - JSON Unmarshal Error for session id: %v", client.player.ID)
 		msg, _ := messages.GenerateGenericMessage("invalid", "Handle Move Piece - JSON Unmarshal Error.")
 		client.send <- msg
+		msg, _ = messages.GenerateMoveRejectedMessage(0, models.MoveRejectBadRequest)
+		client.send <- msg
 		return
 	}
 	if move.GetPlayerID() != client.player.ID {
 		logger.Default.Errorf("[wsapi] - handleMovePiece - move.PlayerID != player.ID for session id: %v", client.player.ID)
 		msg, _ := messages.GenerateGenericMessage("invalid", "Handle Move Piece - move.PlayerID != player.ID.")
 		client.send <- msg
+		msg, _ = messages.GenerateMoveRejectedMessage(move.GetSeq(), models.MoveRejectBadRequest)
+		client.send <- msg
 		return
 	}
-	queueName := fmt.Sprintf("move_piece:{%v}", client.hub.gameName)
+	queueName := redisdb.GenerateMoveListKey(client.hub.gameName, client.player.GameID)
 	// movement message is sent to the game worker
 	err = redis.RPushGeneric(queueName, message.Value)
 	if err != nil {
@@ -199,3 +248,107 @@ func handleMovePiece(message *messages.Message[json.RawMessage], client *Client,
 	}
 	logger.Default.Infof("[wsapi] - handleMovePiece - sent move_piece to gameworker for session id: %v", client.player.ID)
 }
+
+func handleTakeback(message *messages.Message[json.RawMessage], client *Client, redis *redisdb.RedisClient) {
+	req := models.TakebackRequest{
+		PlayerID: client.player.ID,
+		Command:  message.Command,
+	}
+	if message.Command == "accept_takeback" {
+		json.Unmarshal(message.Value, &req.Accept)
+	}
+	data, _ := json.Marshal(req)
+	queueName := fmt.Sprintf("takeback_game:{%v}", client.hub.gameName)
+	if err := redis.RPushGeneric(queueName, data); err != nil {
+		logger.Default.Errorf("[wsapi] - handleTakeback - error pushing %v to redis for session id: %v, err: %v", message.Command, client.player.ID, err)
+		msg, _ := messages.GenerateGenericMessage("error", "error pushing takeback command to gameworker.")
+		client.send <- msg
+		return
+	}
+	logger.Default.Infof("[wsapi] - handleTakeback - sent %v to gameworker for session id: %v", message.Command, client.player.ID)
+}
+
+func handlePremove(message *messages.Message[json.RawMessage], client *Client, redis *redisdb.RedisClient) {
+	req := models.PremoveRequest{
+		PlayerID: client.player.ID,
+		Command:  message.Command,
+	}
+	if message.Command == "premove" {
+		move, err := models.UnmarshalMove([]byte(message.Value), client.hub.gameName)
+		if err != nil || move.GetPlayerID() != client.player.ID {
+			logger.Default.Errorf("[wsapi] - handlePremove - invalid premove for session id: %v", client.player.ID)
+			msg, _ := messages.GenerateGenericMessage("invalid", "Handle Premove - the value must be a move of the player.")
+			client.send <- msg
+			return
+		}
+		req.Move = message.Value
+	}
+	data, _ := json.Marshal(req)
+	queueName := fmt.Sprintf("premove_game:{%v}", client.hub.gameName)
+	if err := redis.RPushGeneric(queueName, data); err != nil {
+		logger.Default.Errorf("[wsapi] - handlePremove - error pushing %v to redis for session id: %v, err: %v", message.Command, client.player.ID, err)
+		msg, _ := messages.GenerateGenericMessage("error", "error pushing premove to gameworker.")
+		client.send <- msg
+		return
+	}
+	logger.Default.Infof("[wsapi] - handlePremove - sent %v to gameworker for session id: %v", message.Command, client.player.ID)
+}
+
+func handleDraw(message *messages.Message[json.RawMessage], client *Client, redis *redisdb.RedisClient) {
+	req := models.DrawRequest{
+		PlayerID: client.player.ID,
+		Command:  message.Command,
+	}
+	if message.Command == "respond_draw" {
+		if err := json.Unmarshal(message.Value, &req.Accept); err != nil {
+			logger.Default.Errorf("[wsapi] - handleDraw - JSON Unmarshal Error for session id: %v", client.player.ID)
+			msg, _ := messages.GenerateGenericMessage("invalid", "Handle Draw - respond_draw value must be true or false.")
+			client.send <- msg
+			return
+		}
+	}
+	data, _ := json.Marshal(req)
+	queueName := fmt.Sprintf("draw_game:{%v}", client.hub.gameName)
+	if err := redis.RPushGeneric(queueName, data); err != nil {
+		logger.Default.Errorf("[wsapi] - handleDraw - error pushing %v to redis for session id: %v, err: %v", message.Command, client.player.ID, err)
+		msg, _ := messages.GenerateGenericMessage("error", "error pushing draw command to gameworker.")
+		client.send <- msg
+		return
+	}
+	logger.Default.Infof("[wsapi] - handleDraw - sent %v to gameworker for session id: %v", message.Command, client.player.ID)
+}
+
+// How long the player has to confirm a resign.
+const resignConfirmWindow = 10 * time.Second
+
+// handleResign asks for confirmation on resign, and sends the confirmed resigns and the aborts to the gameworker.
+func handleResign(message *messages.Message[json.RawMessage], client *Client, redis *redisdb.RedisClient) {
+	req := models.ResignRequest{PlayerID: client.player.ID}
+	switch message.Command {
+	case "resign":
+		client.resignRequestedAt.Store(time.Now().Unix())
+		msg, _ := messages.NewMessage("resign_confirmation", int(resignConfirmWindow.Seconds()))
+		client.send <- msg
+		return
+	case "confirm_resign":
+		requestedAt := client.resignRequestedAt.Swap(0)
+		if requestedAt == 0 || time.Since(time.Unix(requestedAt, 0)) > resignConfirmWindow {
+			logger.Default.Warnf("[wsapi] - handleResign - confirm_resign without a recent resign for session id: %v", client.player.ID)
+			msg, _ := messages.GenerateGenericMessage("invalid", "There is no resign to confirm, send a resign first.")
+			client.send <- msg
+			return
+		}
+		req.Command = "resign"
+	case "abort_game":
+		req.Command = "abort"
+	}
+	data, _ := json.Marshal(req)
+	queueName := fmt.Sprintf("resign_game:{%v}", client.hub.gameName)
+	if err := redis.RPushGeneric(queueName, data); err != nil {
+		logger.Default.Errorf("[wsapi] - handleResign - error pushing %v to redis for session id: %v, err: %v", req.Command, client.player.ID, err)
+		msg, _ := messages.GenerateGenericMessage("error", "error pushing resign to gameworker.")
+		client.send <- msg
+		return
+	}
+	logger.Default.Infof("[wsapi] - handleResign - sent %v to gameworker for session id: %v", req.Command, client.player.ID)
+}
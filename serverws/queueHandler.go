@@ -3,7 +3,6 @@ package main
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"time"
 
 	"github.com/Lavizord/checkers-server/logger"
@@ -49,14 +48,7 @@ func (qh *QueueHandler) Process() {
 		return
 	}
 
-	found := false
-	for _, v := range models.DamasValidBetAmounts {
-		if v == betValue {
-			found = true
-			break
-		}
-	}
-	if !found {
+	if !qh.Client.player.OperatorIdentifier.BetTiers.IsValid(betValue) {
 		qh.initialValidationsFailed = true
 		logger.Default.Errorf("Invalid bet for session id: %v, with bet value: %v", qh.Client.player.ID, betValue)
 		return
@@ -87,7 +79,7 @@ func (qh *QueueHandler) cleanup() {
 	}
 
 	if qh.addedToQueue {
-		queueName := fmt.Sprintf("queue:%f", qh.Client.player.SelectedBet)
+		queueName := redisdb.GenerateQueueName(qh.GameName, redisdb.PlayerBetQueue(qh.Client.player))
 		qh.RedisClient.Client.LRem(context.Background(), queueName, 1, qh.Client.player)
 		sendFailedQueueConfirmation = true
 	}
@@ -113,25 +105,35 @@ func (qh *QueueHandler) validateStatusTransition() bool {
 	return true
 }
 
-func (qh *QueueHandler) parseBetValue() (float64, error) {
-	var betValue float64
-	err := json.Unmarshal(qh.Msg.Value, &betValue)
+// The bet comes in major units of the player currency, it is read from the text so 0.1 stays 0.1.
+func (qh *QueueHandler) parseBetValue() (models.Money, error) {
+	var number json.Number
+	err := json.Unmarshal(qh.Msg.Value, &number)
 	if err != nil {
 		msgBytes, _ := messages.GenerateGenericMessage("error", "Error determining player bet value")
 		qh.Client.send <- msgBytes
-		return 0, err
+		return models.Money{}, err
+	}
+	betValue, err := models.ParseMoney(number.String(), qh.Client.player.Currency)
+	if err != nil {
+		msgBytes, _ := messages.GenerateGenericMessage("error", "Error determining player bet value")
+		qh.Client.send <- msgBytes
+		return models.Money{}, err
 	}
 	return betValue, nil
 }
 
-func (qh *QueueHandler) updatePlayerState(betValue float64) {
+func (qh *QueueHandler) updatePlayerState(betValue models.Money) {
 	qh.Client.player.SelectedBet = betValue
 	qh.Client.player.Status = models.StatusInQueue
 	qh.RedisClient.UpdatePlayer(qh.Client.player)
 }
 
 func (qh *QueueHandler) addToRedisQueue() error {
-	queueName := fmt.Sprintf("queue:{%v}:%f", qh.GameName, qh.Client.player.SelectedBet)
+	queueName := redisdb.GenerateQueueName(qh.GameName, redisdb.PlayerBetQueue(qh.Client.player))
+	if err := qh.RedisClient.RegisterBetQueue(qh.GameName, redisdb.PlayerBetQueue(qh.Client.player)); err != nil {
+		logger.Default.Errorf("Failed to register bet queue %v: %v", queueName, err)
+	}
 	err := qh.RedisClient.RPush(queueName, qh.Client.player)
 	if err != nil {
 		msgBytes, _ := messages.GenerateGenericMessage("error", "error adding player to queue")
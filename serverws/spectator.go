@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Lavizord/checkers-server/logger"
+	"github.com/Lavizord/checkers-server/messages"
+	"github.com/Lavizord/checkers-server/redisdb"
+
+	"github.com/gorilla/websocket"
+	"github.com/redis/go-redis/v9"
+)
+
+// Spectator is a read only connection, it watches one game at a time through the game spectator channel.
+// It has no session, no wallet and is not registered on the hub, so it never gets to the workers.
+type Spectator struct {
+	hub *Hub
+
+	conn *websocket.Conn
+
+	send chan []byte
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Only one spectate at a time, the url one and the ones of the messages can overlap.
+	spectateMu sync.Mutex
+
+	// Subscription of the game being watched, nil when not watching.
+	mu     sync.Mutex
+	gameID string
+	pubsub *redis.PubSub
+}
+
+func (s *Spectator) readPump() {
+	defer func() {
+		s.cancel()
+		s.stopSpectating()
+		s.conn.Close()
+	}()
+	s.conn.SetReadLimit(maxMessageSize)
+	s.conn.SetReadDeadline(time.Now().Add(pongWait))
+	s.conn.SetPongHandler(func(string) error { s.conn.SetReadDeadline(time.Now().Add(pongWait)); return nil })
+
+	for {
+		_, message, err := s.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				logger.Default.Warnf("[Spectator] - readPump socket with unexpected close with err: %v", err)
+			}
+			break
+		}
+		parsedmessage, err := messages.ParseMessage(message)
+		if err != nil {
+			msg, _ := messages.GenerateGenericMessage("error", "Invalid message format."+err.Error())
+			s.send <- msg
+			continue
+		}
+		switch parsedmessage.Command {
+		case "ping":
+			msgBytes, _ := json.Marshal(messages.MessageSimple{Command: "pong"})
+			s.send <- msgBytes
+		case "spectate":
+			var gameID string
+			json.Unmarshal(parsedmessage.Value, &gameID)
+			s.spectate(gameID)
+		case "stop_spectating":
+			s.stopSpectating()
+		default:
+			msg, _ := messages.GenerateGenericMessage("invalid", "Spectators can't send game commands.")
+			s.send <- msg
+		}
+	}
+}
+
+// spectate moves the subscription to the game and sends the current board. We subscribe before reading
+// the game, so no move is lost between the snapshot and the live updates.
+func (s *Spectator) spectate(gameID string) {
+	s.spectateMu.Lock()
+	defer s.spectateMu.Unlock()
+	s.stopSpectating()
+
+	pubsub := s.hub.redis.Client.Subscribe(s.ctx, redisdb.GetGameSpectatorChannel(gameID))
+	if _, err := pubsub.Receive(s.ctx); err != nil {
+		logger.Default.Errorf("[Spectator] - spectate - failed subscribing to game: %v, with err: %v", gameID, err)
+		pubsub.Close()
+		msg, _ := messages.GenerateGenericMessage("error", "Failed to spectate the game.")
+		s.send <- msg
+		return
+	}
+
+	game, err := s.hub.redis.GetGame(gameID)
+	if err != nil || game.OperatorIdentifier.GameName != s.hub.gameName {
+		pubsub.Close()
+		msg, _ := messages.GenerateGenericMessage("invalid", "Game not found.")
+		s.send <- msg
+		return
+	}
+	if clock, err := s.hub.redis.GetClock(game.ID); err == nil {
+		clock.SyncTimers(game, time.Now())
+	}
+	msg, err := messages.GenerateGameSpectateMessage(*game)
+	if err != nil {
+		logger.Default.Errorf("[Spectator] - spectate - failed to generate spectate message for game: %v, with err: %v", gameID, err)
+		pubsub.Close()
+		return
+	}
+	s.send <- msg
+
+	s.mu.Lock()
+	previous := s.pubsub
+	s.gameID = gameID
+	s.pubsub = pubsub
+	s.mu.Unlock()
+	if previous != nil {
+		previous.Close()
+	}
+
+	go func() {
+		for msg := range pubsub.Channel() {
+			select {
+			case s.send <- []byte(msg.Payload):
+			case <-s.ctx.Done():
+				return
+			}
+			// The game is over, nothing else will come through this channel.
+			if parsed, err := messages.DecodeRawMessage([]byte(msg.Payload)); err == nil && parsed.Command == "game_over" {
+				go s.stopWatching(pubsub)
+				return
+			}
+		}
+	}()
+}
+
+// stopWatching drops the subscription, if the spectator did not move to another game already.
+func (s *Spectator) stopWatching(pubsub *redis.PubSub) {
+	s.mu.Lock()
+	if s.pubsub != pubsub {
+		s.mu.Unlock()
+		return
+	}
+	s.pubsub = nil
+	s.gameID = ""
+	s.mu.Unlock()
+	pubsub.Close()
+}
+
+func (s *Spectator) stopSpectating() {
+	s.mu.Lock()
+	pubsub := s.pubsub
+	s.pubsub = nil
+	s.gameID = ""
+	s.mu.Unlock()
+	if pubsub != nil {
+		pubsub.Close()
+	}
+}
+
+func (s *Spectator) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		s.cancel()
+		ticker.Stop()
+		s.conn.Close()
+	}()
+	for {
+		select {
+		case message := <-s.send:
+			s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				logger.Default.Warnf("[Spectator] - writePump - failed writing message with err: %v", err)
+				return
+			}
+		case <-ticker.C:
+			s.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := s.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// serveSpectator handles the spectator websocket, no auth is needed since nothing can be played from it.
+func serveSpectator(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Default.Errorf("[Spectator] - serveSpectator - error upgrading conn with err: %v", err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	spectator := &Spectator{
+		hub: hub, conn: conn,
+		send: make(chan []byte, 256),
+		ctx:  ctx, cancel: cancel,
+	}
+	go spectator.writePump()
+	go spectator.readPump()
+
+	// A game id on the url starts watching right away.
+	if gameID := r.URL.Query().Get("game"); gameID != "" {
+		go spectator.spectate(gameID)
+	}
+}
@@ -0,0 +1,7 @@
+package walletrequests
+
+import "errors"
+
+// ErrNoAnswer is wrapped by the errors of the posts that got no answer of the operator, like a timeout or a
+// dropped connection. The operator may have done the transaction anyway.
+var ErrNoAnswer = errors.New("no answer from the wallet")
@@ -0,0 +1,79 @@
+package walletrequests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/Lavizord/checkers-server/models"
+)
+
+// GenericWalletPost posts the body to an endpoint of a generic wallet, with the auth of the config and the
+// signing when there is one, and gives back the decoded answer. Answers that are not 2xx, or whose status is not
+// the success value, are errors.
+func GenericWalletPost(baseUrl string, cfg models.WalletConfig, signing *models.WalletSigning, endpoint string, token string, body map[string]interface{}) (map[string]interface{}, error) {
+	parsedUrl, err := url.Parse(baseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %v", err)
+	}
+	parsedUrl.Path = path.Join(parsedUrl.Path, endpoint)
+
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize wallet request: %v", err)
+	}
+	req, err := http.NewRequest("POST", parsedUrl.String(), bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wallet request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if cfg.Auth.Type == models.WalletAuthBearer {
+		header := cfg.Auth.Header
+		if header == "" {
+			header = "Authorization"
+		}
+		req.Header.Set(header, "Bearer "+token)
+	}
+	requestNonce := SignRequest(req, jsonData, signing)
+
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send wallet request: %v: %w", err, ErrNoAnswer)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wallet response body: %v: %w", err, ErrNoAnswer)
+	}
+	if err := verifyResponse(resp, respBody, signing, requestNonce); err != nil {
+		return nil, err
+	}
+	var answer map[string]interface{}
+	if err := json.Unmarshal(respBody, &answer); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, fmt.Errorf("api error: status %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("failed to parse wallet response: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("api error: status %d: %s", resp.StatusCode, cfg.ResponseString(answer, "error"))
+	}
+	if cfg.SuccessValue != "" {
+		if status := cfg.ResponseString(answer, "status"); status != cfg.SuccessValue {
+			return nil, fmt.Errorf("api error: %s: %s", status, cfg.ResponseString(answer, "error"))
+		}
+	}
+	return answer, nil
+}
@@ -0,0 +1,98 @@
+package walletrequests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Lavizord/checkers-server/models"
+)
+
+// Signature is the hex HMAC-SHA256 of "timestamp.nonce.body" with the secret.
+func Signature(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + nonce + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ResponseSignature is the signature of an answer of the operator, the hex HMAC-SHA256 of
+// "timestamp.nonce.requestNonce.body", so an answer can't be replayed for another of our calls.
+func ResponseSignature(secret, timestamp, nonce, requestNonce string, body []byte) string {
+	return Signature(secret, timestamp, nonce+"."+requestNonce, body)
+}
+
+// SignRequest adds the timestamp, nonce and signature headers of the body to the request and gives back the
+// nonce, the answer has to be signed with it. Nothing is done without signing.
+func SignRequest(req *http.Request, body []byte, signing *models.WalletSigning) string {
+	if signing == nil {
+		return ""
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := models.GenerateUUID()
+	req.Header.Set(signing.TimestampHeaderName(), timestamp)
+	req.Header.Set(signing.NonceHeaderName(), nonce)
+	req.Header.Set(signing.SignatureHeaderName(), Signature(signing.Secret, timestamp, nonce, body))
+	return nonce
+}
+
+// VerifySignature checks the signature headers of a message of the operator against its body. It gives back the
+// nonce, so the callers that care about replays can check it was not used before.
+func VerifySignature(header http.Header, body []byte, signing *models.WalletSigning, now time.Time) (string, error) {
+	return verifySignature(header, signing, now, func(timestamp, nonce string) string {
+		return Signature(signing.Secret, timestamp, nonce, body)
+	})
+}
+
+func verifySignature(header http.Header, signing *models.WalletSigning, now time.Time, sign func(timestamp, nonce string) string) (string, error) {
+	timestamp := header.Get(signing.TimestampHeaderName())
+	nonce := header.Get(signing.NonceHeaderName())
+	signature := header.Get(signing.SignatureHeaderName())
+	if timestamp == "" || nonce == "" || signature == "" {
+		return "", fmt.Errorf("missing signature headers")
+	}
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature timestamp: %s", timestamp)
+	}
+	skew := now.Unix() - sent
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > int64(signing.MaxSkew()) {
+		return "", fmt.Errorf("signature timestamp out of the allowed window: %s", timestamp)
+	}
+	if !hmac.Equal([]byte(sign(timestamp, nonce)), []byte(signature)) {
+		return "", fmt.Errorf("invalid signature")
+	}
+	return nonce, nil
+}
+
+// verifyResponse checks the signature of an answer of the operator to the request with the nonce, when the
+// signing asks for it. The nonce of the answer is claimed, an answer seen before is turned down.
+func verifyResponse(resp *http.Response, body []byte, signing *models.WalletSigning, requestNonce string) error {
+	if signing == nil || !signing.VerifyResponses {
+		return nil
+	}
+	nonce, err := verifySignature(resp.Header, signing, time.Now(), func(timestamp, nonce string) string {
+		return ResponseSignature(signing.Secret, timestamp, nonce, requestNonce, body)
+	})
+	if err != nil {
+		return fmt.Errorf("unverified wallet response: %v", err)
+	}
+	if signing.ClaimNonce == nil {
+		return fmt.Errorf("unverified wallet response: no nonce store to check replays")
+	}
+	fresh, err := signing.ClaimNonce(nonce, 2*time.Duration(signing.MaxSkew())*time.Second)
+	if err != nil {
+		return fmt.Errorf("unverified wallet response: %v", err)
+	}
+	if !fresh {
+		return fmt.Errorf("unverified wallet response: nonce already used")
+	}
+	return nil
+}
@@ -14,7 +14,7 @@ import (
 	"github.com/Lavizord/checkers-server/models"
 )
 
-func SokkerDuelGetWallet(baseUrl string, token string) (*models.WalletResponse, error) {
+func SokkerDuelGetWallet(baseUrl string, token string, signing *models.WalletSigning) (*models.WalletResponse, error) {
 	// Parse the base URL
 	baseUrlParsed, err := url.Parse(baseUrl)
 	if err != nil {
@@ -28,6 +28,7 @@ func SokkerDuelGetWallet(baseUrl string, token string) (*models.WalletResponse,
 	}
 	req.Header.Set("x-access-token", token)
 	req.Header.Set("Content-Type", "application/json")
+	requestNonce := SignRequest(req, nil, signing)
 
 	// Print request (updated to show all headers)
 	//fmt.Println("===== API REQUEST =====")
@@ -50,6 +51,9 @@ func SokkerDuelGetWallet(baseUrl string, token string) (*models.WalletResponse,
 	if err != nil {
 		return nil, fmt.Errorf("Failed to read response body: %v", err)
 	}
+	if err := verifyResponse(resp, body, signing, requestNonce); err != nil {
+		return nil, err
+	}
 
 	// Print response
 	//fmt.Println("===== API RESPONSE =====")
@@ -69,7 +73,7 @@ func SokkerDuelGetWallet(baseUrl string, token string) (*models.WalletResponse,
 	return &walletResponse, nil
 }
 
-func SokkerDuelPostBet(session models.Session, betData models.SokkerDuelBet) (*models.SokkerDuelBetResponse, error) {
+func SokkerDuelPostBet(session models.Session, betData models.SokkerDuelBet, signing *models.WalletSigning) (*models.SokkerDuelBetResponse, error) {
 	baseUrl, err := url.Parse(session.OperatorBaseUrl)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse base URL: %v", err)
@@ -85,6 +89,7 @@ func SokkerDuelPostBet(session models.Session, betData models.SokkerDuelBet) (*m
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-access-token", session.Token)
+	requestNonce := SignRequest(req, jsonData, signing)
 
 	// Print request
 	//log.Println("===== API REQUEST =====")
@@ -95,13 +100,16 @@ func SokkerDuelPostBet(session models.Session, betData models.SokkerDuelBet) (*m
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send bet request: %v", err)
+		return nil, fmt.Errorf("failed to send bet request: %v: %w", err, ErrNoAnswer)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, fmt.Errorf("failed to read response body: %v: %w", err, ErrNoAnswer)
+	}
+	if err := verifyResponse(resp, body, signing, requestNonce); err != nil {
+		return nil, err
 	}
 
 	// Print response
@@ -122,7 +130,7 @@ func SokkerDuelPostBet(session models.Session, betData models.SokkerDuelBet) (*m
 	return &walletResponse, nil
 }
 
-func SokkerDuelPostWin(session models.Session, winData models.SokkerDuelWin) (*models.SokkerDuelWinResponse, error) {
+func SokkerDuelPostWin(session models.Session, winData models.SokkerDuelWin, signing *models.WalletSigning) (*models.SokkerDuelWinResponse, error) {
 	baseUrl, err := url.Parse(session.OperatorBaseUrl)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse base URL: %v", err)
@@ -139,6 +147,7 @@ func SokkerDuelPostWin(session models.Session, winData models.SokkerDuelWin) (*m
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-access-token", session.Token)
+	requestNonce := SignRequest(req, jsonData, signing)
 
 	// Print request
 	//log.Println("===== API REQUEST =====")
@@ -149,13 +158,16 @@ func SokkerDuelPostWin(session models.Session, winData models.SokkerDuelWin) (*m
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send win request: %v", err)
+		return nil, fmt.Errorf("failed to send win request: %v: %w", err, ErrNoAnswer)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read win response body: %v", err)
+		return nil, fmt.Errorf("failed to read win response body: %v: %w", err, ErrNoAnswer)
+	}
+	if err := verifyResponse(resp, body, signing, requestNonce); err != nil {
+		return nil, err
 	}
 	// Print response
 	//log.Println("===== API RESPONSE =====")